@@ -0,0 +1,4 @@
+// Package v1 包含iam-apiserver自定义的grpc接口.
+package v1
+
+//go:generate protoc -I../../../.. --go_out=../../../.. --go_opt=paths=source_relative --go-grpc_out=../../../.. --go-grpc_opt=paths=source_relative api/proto/apiserver/v1/watch.proto api/proto/apiserver/v1/user.proto api/proto/apiserver/v1/secret.proto api/proto/apiserver/v1/policy.proto
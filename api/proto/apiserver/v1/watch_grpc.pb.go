@@ -0,0 +1,136 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: api/proto/apiserver/v1/watch.proto
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CacheWatcherClient is the client API for CacheWatcher service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CacheWatcherClient interface {
+	// Watch 先发送全量快照，然后按revision顺序推送变更事件.
+	// 请求中带有revision时从该revision之后继续推送，revision已经被清理时重新发送快照.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CacheWatcher_WatchClient, error)
+}
+
+type cacheWatcherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCacheWatcherClient(cc grpc.ClientConnInterface) CacheWatcherClient {
+	return &cacheWatcherClient{cc}
+}
+
+func (c *cacheWatcherClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CacheWatcher_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CacheWatcher_ServiceDesc.Streams[0], "/iam.apiserver.v1.CacheWatcher/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheWatcherWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CacheWatcher_WatchClient interface {
+	Recv() (*WatchResponse, error)
+	grpc.ClientStream
+}
+
+type cacheWatcherWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheWatcherWatchClient) Recv() (*WatchResponse, error) {
+	m := new(WatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CacheWatcherServer is the server API for CacheWatcher service.
+// All implementations must embed UnimplementedCacheWatcherServer
+// for forward compatibility
+type CacheWatcherServer interface {
+	// Watch 先发送全量快照，然后按revision顺序推送变更事件.
+	// 请求中带有revision时从该revision之后继续推送，revision已经被清理时重新发送快照.
+	Watch(*WatchRequest, CacheWatcher_WatchServer) error
+	mustEmbedUnimplementedCacheWatcherServer()
+}
+
+// UnimplementedCacheWatcherServer must be embedded to have forward compatible implementations.
+type UnimplementedCacheWatcherServer struct {
+}
+
+func (UnimplementedCacheWatcherServer) Watch(*WatchRequest, CacheWatcher_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedCacheWatcherServer) mustEmbedUnimplementedCacheWatcherServer() {}
+
+// UnsafeCacheWatcherServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CacheWatcherServer will
+// result in compilation errors.
+type UnsafeCacheWatcherServer interface {
+	mustEmbedUnimplementedCacheWatcherServer()
+}
+
+func RegisterCacheWatcherServer(s grpc.ServiceRegistrar, srv CacheWatcherServer) {
+	s.RegisterService(&CacheWatcher_ServiceDesc, srv)
+}
+
+func _CacheWatcher_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheWatcherServer).Watch(m, &cacheWatcherWatchServer{stream})
+}
+
+type CacheWatcher_WatchServer interface {
+	Send(*WatchResponse) error
+	grpc.ServerStream
+}
+
+type cacheWatcherWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheWatcherWatchServer) Send(m *WatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CacheWatcher_ServiceDesc is the grpc.ServiceDesc for CacheWatcher service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CacheWatcher_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iam.apiserver.v1.CacheWatcher",
+	HandlerType: (*CacheWatcherServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _CacheWatcher_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/proto/apiserver/v1/watch.proto",
+}
@@ -73,19 +73,99 @@ CREATE TABLE `user` (
     UNIQUE KEY `instanceID_UNIQUE` (`instanceID`)
 ) ENGINE=InnoDB AUTO_INCREMENT=38 DEFAULT CHARSET=utf8;
 
+DROP TABLE IF EXISTS `user_mfa`;
+CREATE TABLE `user_mfa` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `username` varchar(45) NOT NULL,
+    `secret` varchar(64) NOT NULL,
+    `enabled` tinyint(1) unsigned NOT NULL DEFAULT 0 COMMENT '1: 已启用，0: 登记中',
+    `required` tinyint(1) unsigned NOT NULL DEFAULT 0 COMMENT '1: 管理员要求必须使用MFA',
+    `recoveryCodes` text DEFAULT NULL COMMENT 'sha256后的恢复码，逗号分隔',
+    `lastUsedStep` bigint(20) NOT NULL DEFAULT 0,
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updatedAt` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_username` (`username`),
+    CONSTRAINT `fk_mfa_user` FOREIGN KEY (`username`) REFERENCES `user` (`name`) ON DELETE CASCADE ON UPDATE NO ACTION
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
+DROP TABLE IF EXISTS `access_token`;
+CREATE TABLE `access_token` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `instanceID` varchar(32) DEFAULT NULL,
+    `name` varchar(45) NOT NULL,
+    `username` varchar(45) NOT NULL,
+    `tokenHash` char(64) NOT NULL COMMENT 'sha256后的令牌',
+    `scopes` varchar(255) NOT NULL COMMENT '逗号分隔的权限范围',
+    `allowedIPs` varchar(1024) DEFAULT NULL COMMENT '逗号分隔的IP或CIDR',
+    `expiresAt` timestamp NULL DEFAULT NULL,
+    `lastUsedAt` timestamp NULL DEFAULT NULL,
+    `lastUsedIP` varchar(64) DEFAULT NULL,
+    `description` varchar(255) NOT NULL DEFAULT '',
+    `extendShadow` longtext DEFAULT NULL,
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updatedAt` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `instanceID_UNIQUE` (`instanceID`),
+    UNIQUE KEY `idx_token_hash` (`tokenHash`),
+    UNIQUE KEY `idx_username_name` (`username`, `name`),
+    CONSTRAINT `fk_token_user` FOREIGN KEY (`username`) REFERENCES `user` (`name`) ON DELETE CASCADE ON UPDATE NO ACTION
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
+DROP TABLE IF EXISTS `oauth_client`;
+CREATE TABLE `oauth_client` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `instanceID` varchar(32) DEFAULT NULL,
+    `name` varchar(45) NOT NULL,
+    `clientID` varchar(64) NOT NULL,
+    `secretHash` char(64) DEFAULT NULL COMMENT 'sha256后的客户端密钥，公开客户端为空',
+    `public` tinyint(1) unsigned NOT NULL DEFAULT 0,
+    `username` varchar(45) NOT NULL COMMENT '注册客户端的用户',
+    `redirectURIs` text DEFAULT NULL COMMENT '逗号分隔的回调地址',
+    `grantTypes` varchar(255) NOT NULL COMMENT '逗号分隔的授权类型',
+    `scopes` varchar(1024) DEFAULT NULL COMMENT '逗号分隔的可申请scope',
+    `description` varchar(255) NOT NULL DEFAULT '',
+    `extendShadow` longtext DEFAULT NULL,
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updatedAt` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `instanceID_UNIQUE` (`instanceID`),
+    UNIQUE KEY `idx_name` (`name`),
+    UNIQUE KEY `idx_client_id` (`clientID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `resource_change`;
+CREATE TABLE `resource_change` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '变更的revision',
+    `kind` varchar(16) NOT NULL COMMENT '资源类型: secret, policy',
+    `type` varchar(16) NOT NULL COMMENT '变更类型: created, updated, deleted',
+    `username` varchar(45) NOT NULL,
+    `name` varchar(45) NOT NULL,
+    `object` longtext DEFAULT NULL COMMENT '变更后的对象，删除时是删除前的对象',
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `idx_createdAt` (`createdAt`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
-
-
-
-
-
-
-
-
-
-
-
-
-
+DROP TABLE IF EXISTS `audit_event`;
+CREATE TABLE `audit_event` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `username` varchar(45) NOT NULL DEFAULT '' COMMENT '发起请求的用户，未认证的请求为空',
+    `authMethod` varchar(16) NOT NULL DEFAULT '' COMMENT '认证方式: basic, jwt, token, cert',
+    `clientIP` varchar(64) NOT NULL DEFAULT '' COMMENT '客户端地址，只有请求来自信任的代理时才取自X-Forwarded-For',
+    `remoteIP` varchar(64) NOT NULL DEFAULT '' COMMENT '连接的对端地址',
+    `requestID` varchar(64) NOT NULL DEFAULT '',
+    `method` varchar(8) NOT NULL,
+    `route` varchar(255) NOT NULL DEFAULT '' COMMENT '匹配的路由',
+    `path` varchar(1024) NOT NULL,
+    `resource` varchar(32) NOT NULL DEFAULT '' COMMENT '资源类型: users, policies, secrets等',
+    `resourceName` varchar(255) NOT NULL DEFAULT '',
+    `statusCode` int(11) NOT NULL,
+    `request` longtext DEFAULT NULL COMMENT '隐藏了敏感字段的请求体',
+    `diff` longtext DEFAULT NULL COMMENT '请求前后资源的差异',
+    `createdAt` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `idx_createdAt` (`createdAt`),
+    KEY `idx_username` (`username`),
+    KEY `idx_resource_name` (`resource`, `resourceName`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,107 @@
+package apiserver
+
+import (
+	"github.com/gin-gonic/gin"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/apiserver/config"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store/mysql"
+	"github.com/cuizhaoyue/iams/internal/pkg/audit"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// newAuditor 根据配置创建审计日志的sink，未启用审计日志时返回nil.
+func newAuditor(cfg *config.Config) (*audit.Auditor, error) {
+	opts := cfg.AuditOptions
+	if !opts.Enabled {
+		return nil, nil
+	}
+
+	var sinks []audit.Sink
+	for _, name := range opts.Sinks {
+		switch name {
+		case audit.SinkFile:
+			sinks = append(sinks, audit.NewFileSink(opts.ToFileConfig()))
+		case audit.SinkDB:
+			storeIns, err := mysql.GetMySQLFactoryOr(nil)
+			if err != nil {
+				return nil, err
+			}
+
+			sinks = append(sinks, audit.NewDBSink(storeIns.Audits()))
+		case audit.SinkWebhook:
+			sinks = append(sinks, audit.NewWebhookSink(opts.ToWebhookConfig()))
+		default:
+			return nil, errors.Errorf("unknown audit sink: %s", name)
+		}
+	}
+
+	log.Infof("audit events are written to %v", opts.Sinks)
+
+	return audit.New(opts.BufferSize, sinks...), nil
+}
+
+// auditLoaders 返回读取资源当前状态的Loader，用于记录修改前后的差异.
+func auditLoaders(storeIns store.Factory) map[string]audit.Loader {
+	loadUser := func(c *gin.Context) (interface{}, error) {
+		user, err := storeIns.Users().Get(c, c.Param("name"), metav1.GetOptions{})
+		if err != nil {
+			return notFound(err, code.ErrUserNotFound)
+		}
+
+		return user, nil
+	}
+
+	loadOAuthClient := func(c *gin.Context) (interface{}, error) {
+		client, err := storeIns.OAuthClients().Get(c, c.Param("name"), metav1.GetOptions{})
+		if err != nil {
+			return notFound(err, code.ErrOAuthClientNotFound)
+		}
+
+		return client, nil
+	}
+
+	return map[string]audit.Loader{
+		"/v1/users/:name":                 loadUser,
+		"/v1/users/:name/change-password": loadUser,
+		"/v1/users/:name/mfa/required": func(c *gin.Context) (interface{}, error) {
+			mfa, err := storeIns.MFA().Get(c, c.Param("name"))
+			if err != nil {
+				return notFound(err, code.ErrMFANotEnrolled)
+			}
+
+			return mfa, nil
+		},
+		"/v1/policies/:name": func(c *gin.Context) (interface{}, error) {
+			policy, err := storeIns.Polices().Get(c, c.GetString(middleware.UsernameKey), c.Param("name"), metav1.GetOptions{})
+			if err != nil {
+				return notFound(err, code.ErrPolicyNotFound)
+			}
+
+			return policy, nil
+		},
+		"/v1/secrets/:name": func(c *gin.Context) (interface{}, error) {
+			secret, err := storeIns.Secrets().Get(c, c.GetString(middleware.UsernameKey), c.Param("name"), metav1.GetOptions{})
+			if err != nil {
+				return notFound(err, code.ErrSecretNotFound)
+			}
+
+			return secret, nil
+		},
+		"/v1/oauth2/clients/:name":        loadOAuthClient,
+		"/v1/oauth2/clients/:name/secret": loadOAuthClient,
+	}
+}
+
+// notFound 把资源不存在转换为nil，表示资源被创建或者删除.
+func notFound(err error, notFoundCode int) (interface{}, error) {
+	if errors.IsCode(err, notFoundCode) {
+		return nil, nil
+	}
+
+	return nil, err
+}
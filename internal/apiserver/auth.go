@@ -1 +1,583 @@
 package apiserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/apiserver/config"
+	"github.com/cuizhaoyue/iams/internal/apiserver/oauth2"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/session"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware/auth"
+	"github.com/cuizhaoyue/iams/pkg/jwks"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+const (
+	// APIServerAudience 定义了jwt中audience字段的值.
+	APIServerAudience = "iam.api.marmotedu.com"
+
+	// APIServerIssuer 定义了jwt中issuer字段的值.
+	APIServerIssuer = "iam-apiserver"
+
+	// MFAAudience 定义了MFA预认证token中audience字段的值，这类token只能用于完成MFA验证.
+	MFAAudience = "iam.mfa.marmotedu.com"
+
+	// 启用MFA时生成的恢复码在gin.Context中的key，随登录响应一起返回.
+	recoveryCodesKey = "mfa_recovery_codes"
+
+	// 保存会话ID的claim名称.
+	sessionIDKey = "sid"
+)
+
+// 登录请求参数
+type loginInfo struct {
+	Username string `form:"username" json:"username" binding:"required"`
+	Password string `form:"password" json:"password" binding:"required"`
+}
+
+// MFA登录请求参数，code可以是TOTP验证码或者恢复码
+type mfaLoginInfo struct {
+	Code string `form:"code" json:"code" binding:"required"`
+}
+
+func newBasicAuth() middleware.AuthStrategy {
+	return auth.NewBasicStrategy(func(username string, password string) bool {
+		return basicAuthenticate(context.TODO(), username, password) == nil
+	})
+}
+
+// basicAuthenticate 校验用户名和密码，启用LDAP时LDAP用户使用LDAP校验. 需要MFA验证的用户不能使用Basic认证.
+// Basic认证每个请求都会调用，不更新登录时间，登录时间只在/login时更新.
+func basicAuthenticate(ctx context.Context, username, password string) error {
+	srv := srvv1.NewService(store.Client())
+	if _, err := srv.Users().Authenticate(ctx, username, password); err != nil {
+		return err
+	}
+
+	challenge, err := srv.MFA().Challenge(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if challenge {
+		return errors.WithCode(code.ErrPermissionDenied, "user %s requires mfa verification", username)
+	}
+
+	return nil
+}
+
+func newJWTAuth(cfg *config.Config) auth.JWTStrategy {
+	opts := cfg.JwtOptions
+
+	return auth.NewJWTStrategy(auth.JWTConfig{
+		Realm:          opts.Realm,
+		Key:            []byte(opts.Key),
+		KeySet:         cfg.JWTKeys,
+		Leeway:         opts.Leeway,
+		Timeout:        opts.Timeout,
+		MaxRefresh:     opts.MaxRefresh,
+		IdentityKey:    middleware.UsernameKey,
+		Audience:       APIServerAudience,
+		Authenticator:  authenticator(),
+		PayloadFunc:    payloadFunc(),
+		Authorizator:   authorizator(),
+		LoginClaims:    loginClaims(),
+		TokenValidator: tokenValidator(),
+		LoginResponse:  loginResponse(),
+		SendCookie:     true,
+	})
+}
+
+// newPreAuthJWT 创建用于签发和校验MFA预认证token的jwt策略.
+func newPreAuthJWT(cfg *config.Config) auth.JWTStrategy {
+	return auth.NewJWTStrategy(auth.JWTConfig{
+		Realm:       cfg.JwtOptions.Realm,
+		Key:         []byte(cfg.JwtOptions.Key),
+		KeySet:      cfg.JWTKeys,
+		Leeway:      cfg.JwtOptions.Leeway,
+		Timeout:     cfg.MFAOptions.PreAuthTimeout,
+		IdentityKey: middleware.UsernameKey,
+		Audience:    MFAAudience,
+		PayloadFunc: func(data interface{}) jwt.MapClaims {
+			claims := payloadFunc()(data)
+			claims["aud"] = MFAAudience
+
+			return claims
+		},
+	})
+}
+
+// newTokenAuth 创建个人访问令牌认证策略，令牌只能访问其权限范围内的资源.
+func newTokenAuth() auth.TokenStrategy {
+	return auth.NewTokenStrategy(srvv1.AccessTokenPrefix, func(c *gin.Context, raw string) (string, error) {
+		token, err := srvv1.NewService(store.Client()).AccessTokens().Authenticate(c, raw, c.ClientIP())
+		if err != nil {
+			return "", err
+		}
+
+		scope := requiredScope(c)
+		if scope == "" || !token.HasScope(scope) {
+			return "", errors.WithCode(code.ErrInsufficientScope, "access token %s requires scope `%s`", token.Name, scope)
+		}
+
+		return token.Username, nil
+	})
+}
+
+// newOAuthProvider 创建OAuth2/OIDC提供者，授权端点使用jwt认证已经登录的用户.
+func newOAuthProvider(cfg *config.Config, j auth.JWTStrategy) *oauth2.Provider {
+	opts := cfg.OAuthOptions
+
+	return oauth2.NewProvider(oauth2.Config{
+		Issuer:             opts.Issuer,
+		LoginURL:           opts.LoginURL,
+		CodeTimeout:        opts.CodeTimeout,
+		AccessTokenTimeout: opts.AccessTokenTimeout,
+		IDTokenTimeout:     opts.IDTokenTimeout,
+		MaxRefresh:         cfg.JwtOptions.MaxRefresh,
+		Signer: auth.NewJWTStrategy(auth.JWTConfig{
+			Realm:  cfg.JwtOptions.Realm,
+			Key:    []byte(cfg.JwtOptions.Key),
+			KeySet: cfg.JWTKeys,
+			Leeway: cfg.JwtOptions.Leeway,
+		}),
+		Authenticator: func(c *gin.Context) (*oauth2.Identity, error) {
+			claims, err := j.GetClaimsFromJWT(c)
+			if err != nil {
+				return nil, err
+			}
+
+			identity := &oauth2.Identity{AuthTime: time.Now()}
+			identity.Username, _ = claims[middleware.UsernameKey].(string)
+			identity.SessionID, _ = claims[sessionIDKey].(string)
+
+			if origIat, ok := claims["orig_iat"].(float64); ok {
+				identity.AuthTime = time.Unix(int64(origIat), 0)
+			}
+
+			return identity, nil
+		},
+		SessionValidator: sessionValidator,
+		SessionRevoker: func(c *gin.Context, username, sid string) error {
+			if session.Default() == nil {
+				return nil
+			}
+
+			return session.Default().Revoke(c, username, sid)
+		},
+	})
+}
+
+// newSecretAuth 创建密钥对认证策略，供其他服务调用令牌内省和吊销接口.
+func newSecretAuth() auth.SecretStrategy {
+	return auth.NewSecretStrategy(func(ctx context.Context, secretID string) (auth.Secret, error) {
+		secret, err := store.Client().Secrets().GetBySecretID(ctx, secretID)
+		if err != nil {
+			return auth.Secret{}, err
+		}
+
+		return auth.Secret{
+			Username: secret.Username,
+			ID:       secret.SecretID,
+			Key:      secret.SecretKey,
+			Expires:  secret.Expires,
+		}, nil
+	})
+}
+
+// newCertAuth 创建客户端证书认证策略，证书中的用户名必须是已经存在并且没有被禁用的用户.
+// 未启用客户端证书认证时返回的策略不会匹配任何请求.
+func newCertAuth(cfg *config.Config) auth.CertStrategy {
+	opts := cfg.SecureServing.ClientAuth
+	if !opts.Enabled() {
+		return auth.CertStrategy{}
+	}
+
+	return auth.NewCertStrategy(opts.UsernameField, func(c *gin.Context, username string) error {
+		return certUserAvailable(c, username)
+	})
+}
+
+// certUserAvailable 校验客户端证书中的用户存在并且没有被禁用.
+func certUserAvailable(ctx context.Context, username string) error {
+	user, err := store.Client().Users().Get(ctx, username, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("user %s of the client certificate is not available", username)
+	}
+
+	if user.Status != 1 {
+		return fmt.Errorf("user %s of the client certificate is disabled", username)
+	}
+
+	return nil
+}
+
+func newAutoAuth(cfg *config.Config) middleware.AuthStrategy {
+	return auth.NewAutoStrategy(newBasicAuth(), newJWTAuth(cfg), newTokenAuth(), newCertAuth(cfg))
+}
+
+// requiredScope 根据路由返回访问令牌需要的权限范围. 返回空字符串表示不允许使用访问令牌访问，
+// 例如管理访问令牌、MFA、会话和修改密码等凭证相关的接口.
+func requiredScope(c *gin.Context) string {
+	path := c.FullPath()
+	if strings.Contains(path, "/mfa") || strings.Contains(path, "/sessions") ||
+		strings.HasSuffix(path, "/change-password") {
+		return ""
+	}
+
+	var resource string
+
+	switch {
+	case strings.HasPrefix(path, "/v1/users"):
+		resource = "users"
+	case strings.HasPrefix(path, "/v1/secrets"):
+		resource = "secrets"
+	case strings.HasPrefix(path, "/v1/policies"):
+		resource = "policies"
+	default:
+		return ""
+	}
+
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		return resource + ":read"
+	}
+
+	return resource + ":write"
+}
+
+// 登录认证，支持从Basic header和请求体中获取用户名和密码.
+func authenticator() func(c *gin.Context) (interface{}, error) {
+	return func(c *gin.Context) (interface{}, error) {
+		var login loginInfo
+		var err error
+
+		if c.Request.Header.Get("Authorization") != "" {
+			login, err = parseWithHeader(c)
+		} else {
+			login, err = parseWithBody(c)
+		}
+		if err != nil {
+			observeLogin("password", err)
+
+			return nil, err
+		}
+
+		// 校验用户密码，启用LDAP时LDAP用户使用LDAP校验，第一次登录的LDAP用户会自动创建
+		user, err := srvv1.NewService(store.Client()).Users().Authenticate(c, login.Username, login.Password)
+		observeLogin("password", err)
+		if err != nil {
+			log.L(c).Errorf("authenticate user %s failed: %s", login.Username, err.Error())
+
+			return nil, errors.WithCode(code.ErrPasswordIncorrect, "incorrect username or password")
+		}
+
+		user.LoginedAt = time.Now()
+		_ = store.Client().Users().Update(c, user, metav1.UpdateOptions{})
+
+		return user, nil
+	}
+}
+
+func parseWithHeader(c *gin.Context) (loginInfo, error) {
+	header := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+	if len(header) != 2 || header[0] != "Basic" {
+		log.L(c).Errorf("get basic string from Authorization header failed")
+
+		return loginInfo{}, errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong.")
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(header[1])
+	if err != nil {
+		log.L(c).Errorf("decode basic string: %s", err.Error())
+
+		return loginInfo{}, errors.WithCode(code.ErrInvalidAuthHeader, err.Error())
+	}
+
+	pair := strings.SplitN(string(payload), ":", 2)
+	if len(pair) != 2 {
+		log.L(c).Errorf("parse payload failed")
+
+		return loginInfo{}, errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong.")
+	}
+
+	return loginInfo{
+		Username: pair[0],
+		Password: pair[1],
+	}, nil
+}
+
+func parseWithBody(c *gin.Context) (loginInfo, error) {
+	var login loginInfo
+	if err := c.ShouldBindJSON(&login); err != nil {
+		log.L(c).Errorf("parse login parameters: %s", err.Error())
+
+		return loginInfo{}, errors.WithCode(code.ErrBind, err.Error())
+	}
+
+	return login, nil
+}
+
+func payloadFunc() func(data interface{}) jwt.MapClaims {
+	return func(data interface{}) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss": APIServerIssuer,
+			"aud": APIServerAudience,
+		}
+		if u, ok := data.(*v1.User); ok {
+			claims[middleware.UsernameKey] = u.Name
+			claims["sub"] = u.Name
+		}
+
+		return claims
+	}
+}
+
+// mfaChallenger 在密码校验通过后检查用户是否需要MFA验证，需要时只签发短期的预认证token.
+func mfaChallenger(preAuth auth.JWTStrategy) func(c *gin.Context, data interface{}) bool {
+	return func(c *gin.Context, data interface{}) bool {
+		user, ok := data.(*v1.User)
+		if !ok {
+			return false
+		}
+
+		mfa, err := srvv1.NewService(store.Client()).MFA().Get(c, user.Name)
+		if err != nil {
+			if errors.IsCode(err, code.ErrMFANotEnrolled) {
+				return false
+			}
+
+			core.WriteResponse(c, err, nil)
+
+			return true
+		}
+
+		if !mfa.Enabled && !mfa.Required {
+			return false
+		}
+
+		token, expire, err := preAuth.TokenGenerator(user)
+		if err != nil {
+			core.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, err.Error()), nil)
+
+			return true
+		}
+
+		// 未登记MFA的用户需要先使用预认证token调用/login/mfa/enroll登记
+		core.WriteResponse(c, nil, gin.H{
+			"mfaRequired":  true,
+			"mfaEnrolled":  mfa.Enabled,
+			"preAuthToken": token,
+			"expire":       expire.Format(time.RFC3339),
+		})
+
+		return true
+	}
+}
+
+// mfaLoginHandler 使用预认证token和验证码完成登录. 用户尚未启用MFA时，该请求同时完成MFA的启用.
+func mfaLoginHandler(j auth.JWTStrategy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var r mfaLoginInfo
+		if err := c.ShouldBindJSON(&r); err != nil {
+			core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+			return
+		}
+
+		user, err := store.Client().Users().Get(c, c.GetString(middleware.UsernameKey), metav1.GetOptions{})
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+
+		srv := srvv1.NewService(store.Client()).MFA()
+
+		mfa, err := srv.Get(c, user.Name)
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+
+		if mfa.Enabled {
+			err = srv.Verify(c, user.Name, r.Code)
+		} else {
+			var codes []string
+			codes, err = srv.Activate(c, user.Name, r.Code)
+			c.Set(recoveryCodesKey, codes)
+		}
+
+		observeLogin("mfa", err)
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+
+		j.LoginWith(c, user)
+	}
+}
+
+func loginResponse() func(c *gin.Context, token string, expire time.Time) {
+	return func(c *gin.Context, token string, expire time.Time) {
+		resp := gin.H{
+			"token":  token,
+			"expire": expire.Format(time.RFC3339),
+		}
+
+		if codes, ok := c.Get(recoveryCodesKey); ok {
+			resp["recoveryCodes"] = codes
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// loginClaims 登录成功后创建会话，会话ID写入token的sid claim.
+func loginClaims() func(c *gin.Context, data interface{}) (jwt.MapClaims, error) {
+	return func(c *gin.Context, data interface{}) (jwt.MapClaims, error) {
+		user, ok := data.(*v1.User)
+		if !ok || session.Default() == nil {
+			return nil, nil
+		}
+
+		s, err := session.Default().Create(c, user.Name, c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			log.L(c).Errorf("create session for user %s failed: %s", user.Name, err.Error())
+
+			return nil, errors.WithCode(code.ErrUnknown, "create session failed")
+		}
+
+		return jwt.MapClaims{sessionIDKey: s.ID}, nil
+	}
+}
+
+// tokenValidator 校验token对应的会话没有被吊销. redis不可用时无法确认会话是否被吊销，拒绝请求.
+func tokenValidator() func(c *gin.Context, claims jwt.MapClaims) error {
+	return func(c *gin.Context, claims jwt.MapClaims) error {
+		return validateSession(c, claims, c.ClientIP())
+	}
+}
+
+// validateSession 校验claims中的会话仍然有效，并更新会话的最后活跃时间和IP.
+func validateSession(ctx context.Context, claims jwt.MapClaims, clientIP string) error {
+	registry := session.Default()
+	if registry == nil {
+		return nil
+	}
+
+	sid, _ := claims[sessionIDKey].(string)
+	username, _ := claims[middleware.UsernameKey].(string)
+
+	if sid == "" {
+		return errors.WithCode(code.ErrTokenInvalid, "token has no session")
+	}
+
+	err := registry.Touch(ctx, sid, username, clientIP)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, storage.ErrRedisDown) {
+		return errors.WithCode(code.ErrUnknown, "check session %s: %s", sid, err.Error())
+	}
+
+	return errors.WithCode(code.ErrTokenInvalid, "session has been revoked")
+}
+
+// sessionValidator 校验OAuth2授权关联的登录会话仍然有效，客户端凭证授权没有会话. redis不可用时拒绝.
+func sessionValidator(c *gin.Context, username, sid string) error {
+	registry := session.Default()
+	if registry == nil || (username == "" && sid == "") {
+		return nil
+	}
+
+	s, err := registry.Get(c, sid)
+	if err != nil {
+		if errors.Is(err, storage.ErrRedisDown) {
+			return errors.WithCode(code.ErrUnknown, "check session %s: %s", sid, err.Error())
+		}
+
+		return errors.WithCode(code.ErrTokenInvalid, "session has been revoked")
+	}
+
+	if s.Username != username {
+		return errors.WithCode(code.ErrTokenInvalid, "session does not belong to user %s", username)
+	}
+
+	return nil
+}
+
+// logoutHandler 退出登录时吊销token对应的会话.
+func logoutHandler(j auth.JWTStrategy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, err := j.GetClaimsFromJWT(c); err == nil && session.Default() != nil {
+			sid, _ := claims[sessionIDKey].(string)
+			username, _ := claims[middleware.UsernameKey].(string)
+
+			if err := session.Default().Revoke(c, username, sid); err != nil {
+				log.L(c).Warnf("revoke session %s failed: %s", sid, err.Error())
+			}
+		}
+
+		j.LogoutHandler(c)
+	}
+}
+
+func authorizator() func(claims jwt.MapClaims, c *gin.Context) bool {
+	return func(claims jwt.MapClaims, c *gin.Context) bool {
+		if v, ok := claims[middleware.UsernameKey].(string); ok {
+			log.L(c).Infof("user `%s` is authenticated.", v)
+
+			return true
+		}
+
+		return false
+	}
+}
+
+// jwksHandler 返回当前发布的公钥，使用HS256签名时返回空的密钥集合.
+func jwksHandler(keys *jwks.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set := jwks.Set{Keys: []jwks.JWK{}}
+		if keys != nil {
+			set = keys.JWKS(time.Now())
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
+
+// adminChecker 从数据库中查询用户是否是管理员，供middleware.Validation使用.
+func adminChecker(storeIns store.Factory) middleware.AdminChecker {
+	return func(c *gin.Context, username string) error {
+		user, err := storeIns.Users().Get(c, username, metav1.GetOptions{})
+		if err != nil {
+			return errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		if user.IsAdmin != 1 {
+			return errors.WithCode(code.ErrPermissionDenied, "user %s is not a administrator", username)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package apiserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/marmotedu/errors"
+
+	genericoptions "github.com/cuizhaoyue/iams/internal/pkg/options"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/selfsigned"
+	"github.com/cuizhaoyue/iams/pkg/shutdown"
+)
+
+// 生成的自签名服务端证书的CN.
+const selfSignedCommonName = "iam-apiserver"
+
+// 检查生成的自签名证书是否需要重新生成的间隔.
+const certRenewInterval = 12 * time.Hour
+
+// ensureServingCert 证书目录中没有证书时生成自签名证书，之前生成的证书即将过期时重新生成.
+func ensureServingCert(cert *genericoptions.GeneratableKeyCert) error {
+	if !cert.Generatable() {
+		return nil
+	}
+
+	opts := cert.SelfSignedOptions(selfSignedCommonName)
+
+	generated, err := selfsigned.Ensure(opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate self-signed certificate")
+	}
+
+	if generated {
+		log.Warnf("Generated self-signed certificate %s, clients can verify it with CA %s", opts.CertFile(), opts.CAFile())
+	}
+
+	return nil
+}
+
+// initCertRenewal 定期检查生成的自签名证书，重新生成的证书由证书监听自动加载.
+func (s *apiServer) initCertRenewal() {
+	cert := &s.cfg.SecureServing.ServerCert
+	if !cert.Generatable() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.gs.AddPhaseCallback(shutdown.PhaseStopBackground, "cert-renewal", 0, shutdown.ShutdownFunc(func(string) error {
+		cancel()
+
+		return nil
+	}))
+
+	go func() {
+		ticker := time.NewTicker(certRenewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ensureServingCert(cert); err != nil {
+					log.Errorf("renew self-signed certificate failed: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
@@ -1,13 +1,24 @@
 package config
 
-import "github.com/cuizhaoyue/iams/internal/apiserver/options"
+import (
+	"github.com/cuizhaoyue/iams/internal/apiserver/options"
+	"github.com/cuizhaoyue/iams/pkg/jwks"
+)
 
 // Config 是iam apiserver服务的配置.
 type Config struct {
 	*options.Options
+
+	// JWTKeys 非对称jwt签名密钥，使用HS256签名时为nil.
+	JWTKeys *jwks.KeySet
 }
 
 // CreateConfigFromOptions 根据提供的Options创建服务配置.
 func CreateConfigFromOptions(opts *options.Options) (*Config, error) {
-	return &Config{opts}, nil
+	keys, err := opts.JwtOptions.KeySet()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{Options: opts, JWTKeys: keys}, nil
 }
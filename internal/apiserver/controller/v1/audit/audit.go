@@ -0,0 +1,18 @@
+package audit
+
+import (
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+)
+
+// AuditController 处理查询审计记录的请求.
+type AuditController struct {
+	srv srvv1.Service
+}
+
+// NewAuditController 创建审计记录控制器.
+func NewAuditController(store store.Factory) *AuditController {
+	return &AuditController{
+		srv: srvv1.NewService(store),
+	}
+}
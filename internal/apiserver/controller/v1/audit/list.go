@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// List 按用户、资源和时间范围查询审计记录，时间使用RFC3339格式.
+func (a *AuditController) List(c *gin.Context) {
+	log.L(c).Info("list audit event function called.")
+
+	var r modelv1.AuditListOptions
+	if err := c.ShouldBindQuery(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	if r.Since != nil && r.Until != nil && r.Since.After(*r.Until) {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation, "since must be before until"), nil)
+
+		return
+	}
+
+	events, err := a.srv.Audits().List(c, r)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, events)
+}
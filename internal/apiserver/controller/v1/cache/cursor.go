@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// 缺失的revision超过这个时间还没有读到时，认为对应的事务已经回滚.
+	watchGapTimeout = time.Minute
+
+	// 最多同时跟踪的缺失revision数量，避免revision跳跃很大时占用过多内存.
+	watchMaxGaps = 10000
+)
+
+// cursor 记录Watch读取变更的位置. revision由自增id生成，id在事务提交之前分配，
+// 事务的提交顺序可能和id的顺序不同. 读到的revision之前还没有读到的revision记录为gap，
+// 在watchGapTimeout内反复检查，避免漏掉提交较晚的变更.
+type cursor struct {
+	// revision 已经读取的最大revision.
+	revision int64
+	// gaps 还没有读到的revision和发现的时间.
+	gaps map[int64]time.Time
+}
+
+func newCursor(revision int64) *cursor {
+	return &cursor{revision: revision, gaps: make(map[int64]time.Time)}
+}
+
+// markMissing 把revision之前不在seen中的revision记录为gap，用于发现开始Watch时还没有提交的变更.
+func (c *cursor) markMissing(from int64, seen map[int64]bool, now time.Time) {
+	for id := from + 1; id <= c.revision && len(c.gaps) < watchMaxGaps; id++ {
+		if !seen[id] {
+			c.gaps[id] = now
+		}
+	}
+}
+
+// advance 记录读到了revision，跳过的revision记录为gap. 返回false表示revision已经读取过.
+func (c *cursor) advance(revision int64, now time.Time) bool {
+	if _, ok := c.gaps[revision]; ok {
+		delete(c.gaps, revision)
+
+		return true
+	}
+
+	if revision <= c.revision {
+		return false
+	}
+
+	for id := c.revision + 1; id < revision && len(c.gaps) < watchMaxGaps; id++ {
+		c.gaps[id] = now
+	}
+
+	c.revision = revision
+
+	return true
+}
+
+// expire 清除超时的gap.
+func (c *cursor) expire(now time.Time) {
+	for id, found := range c.gaps {
+		if now.Sub(found) > watchGapTimeout {
+			delete(c.gaps, id)
+		}
+	}
+}
+
+// pending 按升序返回还没有读到的revision.
+func (c *cursor) pending() []int64 {
+	ids := make([]int64, 0, len(c.gaps))
+	for id := range c.gaps {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// safe 返回调用方断线重连时使用的revision，不大于它的变更都已经推送或者确认不存在.
+func (c *cursor) safe() int64 {
+	safe := c.revision
+	for id := range c.gaps {
+		if id-1 < safe {
+			safe = id - 1
+		}
+	}
+
+	return safe
+}
@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/rpc"
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 每次从变更表和资源表中读取的最大记录数.
+const watchBatchSize = 500
+
+// Watcher 基于resource_change表向调用方推送secret和policy的变更.
+type Watcher struct {
+	apiv1.UnimplementedCacheWatcherServer
+
+	store    store.Factory
+	interval time.Duration
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// NewWatcher 创建Watcher，interval是检查新变更的间隔.
+func NewWatcher(store store.Factory, interval time.Duration) *Watcher {
+	return &Watcher{store: store, interval: interval, stopped: make(chan struct{})}
+}
+
+// Stop 结束所有的Watch调用，调用方收到Unavailable后可以使用最后的revision连接其他实例.
+// 服务优雅关闭时会等待所有的stream结束，需要在关闭服务前调用.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopped)
+	})
+}
+
+// Watch 先发送全量快照，然后持续推送变更事件. 事件至少推送一次，快照和事件中的对象都是完整的，
+// 调用方按对象覆盖缓存即可. 事件中的revision是调用方断线重连时使用的revision，提交较晚的变更
+// 推送之前revision不会超过它，所以重连后可能重复收到部分事件.
+func (w *Watcher) Watch(r *apiv1.WatchRequest, stream apiv1.CacheWatcher_WatchServer) error {
+	ctx := stream.Context()
+	log.L(ctx).Infof("watch function called from revision %d.", r.Revision)
+
+	kinds := make(map[string]bool)
+	for _, kind := range r.Kinds {
+		switch kind {
+		case apiv1.Kind_KIND_SECRET:
+			kinds[modelv1.ResourceKindSecret] = true
+		case apiv1.Kind_KIND_POLICY:
+			kinds[modelv1.ResourceKindPolicy] = true
+		case apiv1.Kind_KIND_UNSPECIFIED:
+		}
+	}
+
+	watched := func(kind string) bool {
+		return len(kinds) == 0 || kinds[kind]
+	}
+
+	oldest, latest, err := w.store.ResourceChanges().Bounds(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "get revision failed: %s", err.Error())
+	}
+
+	// 没有指定revision、revision不是由当前变更表生成的或者之后的变更已经被清理时，重新发送快照
+	revision := r.Revision
+	if revision <= 0 || revision > latest || (oldest > 0 && revision < oldest-1) {
+		revision = latest
+		if err := w.sendSnapshot(stream, revision, watched); err != nil {
+			return err
+		}
+	}
+
+	// 开始时还没有提交的变更的revision可能小于revision，检查之前的一段revision
+	cur := newCursor(revision)
+	if err := w.seedGaps(ctx, cur, oldest); err != nil {
+		return status.Errorf(codes.Internal, "list changes failed: %s", err.Error())
+	}
+
+	send := func(changes []*modelv1.ResourceChange) error {
+		for _, change := range changes {
+			if !cur.advance(change.Revision, time.Now()) || !watched(change.Kind) {
+				continue
+			}
+
+			event, err := toEvent(change)
+			if err != nil {
+				log.L(ctx).Errorf("skip malformed change %d: %s", change.Revision, err.Error())
+
+				continue
+			}
+
+			resp := &apiv1.WatchResponse{Revision: cur.safe(), Payload: &apiv1.WatchResponse_Event{Event: event}}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		// 先检查之前缺失的revision是否已经提交
+		cur.expire(time.Now())
+		if pending := cur.pending(); len(pending) > 0 {
+			if len(pending) > watchBatchSize {
+				pending = pending[:watchBatchSize]
+			}
+
+			changes, err := w.store.ResourceChanges().ListRevisions(ctx, pending)
+			if err != nil {
+				return status.Errorf(codes.Internal, "list changes failed: %s", err.Error())
+			}
+
+			if err := send(changes); err != nil {
+				return err
+			}
+		}
+
+		changes, err := w.store.ResourceChanges().List(ctx, cur.revision, watchBatchSize)
+		if err != nil {
+			return status.Errorf(codes.Internal, "list changes failed: %s", err.Error())
+		}
+
+		if err := send(changes); err != nil {
+			return err
+		}
+
+		// 还有没有读取的变更时继续读取
+		if len(changes) == watchBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.stopped:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ticker.C:
+		}
+	}
+}
+
+// seedGaps 把cur.revision之前watchBatchSize个revision中不存在的revision记录为gap，已经被清理的revision除外.
+func (w *Watcher) seedGaps(ctx context.Context, cur *cursor, oldest int64) error {
+	from := cur.revision - watchBatchSize
+	if from < oldest-1 {
+		from = oldest - 1
+	}
+
+	if from < 0 {
+		from = 0
+	}
+
+	changes, err := w.store.ResourceChanges().List(ctx, from, watchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int64]bool, len(changes))
+	for _, change := range changes {
+		seen[change.Revision] = true
+	}
+
+	cur.markMissing(from, seen, time.Now())
+
+	return nil
+}
+
+// sendSnapshot 分批发送全量的secret和policy，最后一条消息的last为true.
+func (w *Watcher) sendSnapshot(
+	stream apiv1.CacheWatcher_WatchServer,
+	revision int64,
+	watched func(kind string) bool,
+) error {
+	ctx := stream.Context()
+
+	send := func(snapshot *apiv1.Snapshot) error {
+		return stream.Send(&apiv1.WatchResponse{
+			Revision: revision,
+			Payload:  &apiv1.WatchResponse_Snapshot{Snapshot: snapshot},
+		})
+	}
+
+	if watched(modelv1.ResourceKindSecret) {
+		for offset := int64(0); ; offset += watchBatchSize {
+			secrets, err := w.store.Secrets().List(ctx, "", listOptions(offset))
+			if err != nil {
+				return status.Errorf(codes.Internal, "list secrets failed: %s", err.Error())
+			}
+
+			if len(secrets.Items) == 0 {
+				break
+			}
+
+			snapshot := &apiv1.Snapshot{Secrets: make([]*apiv1.Secret, 0, len(secrets.Items))}
+			for _, secret := range secrets.Items {
+				snapshot.Secrets = append(snapshot.Secrets, rpc.ToSecret(secret))
+			}
+
+			if err := send(snapshot); err != nil {
+				return err
+			}
+		}
+	}
+
+	if watched(modelv1.ResourceKindPolicy) {
+		for offset := int64(0); ; offset += watchBatchSize {
+			policies, err := w.store.Polices().List(ctx, "", listOptions(offset))
+			if err != nil {
+				return status.Errorf(codes.Internal, "list policies failed: %s", err.Error())
+			}
+
+			if len(policies.Items) == 0 {
+				break
+			}
+
+			snapshot := &apiv1.Snapshot{Policies: make([]*apiv1.Policy, 0, len(policies.Items))}
+			for _, pol := range policies.Items {
+				snapshot.Policies = append(snapshot.Policies, rpc.ToPolicy(pol))
+			}
+
+			if err := send(snapshot); err != nil {
+				return err
+			}
+		}
+	}
+
+	return send(&apiv1.Snapshot{Last: true})
+}
+
+func listOptions(offset int64) metav1.ListOptions {
+	limit := int64(watchBatchSize)
+
+	return metav1.ListOptions{Offset: &offset, Limit: &limit}
+}
+
+// toEvent 把变更记录转换为grpc事件.
+func toEvent(change *modelv1.ResourceChange) (*apiv1.Event, error) {
+	event := &apiv1.Event{}
+
+	switch change.Type {
+	case modelv1.ChangeTypeCreated:
+		event.Type = apiv1.EventType_EVENT_TYPE_CREATED
+	case modelv1.ChangeTypeUpdated:
+		event.Type = apiv1.EventType_EVENT_TYPE_UPDATED
+	case modelv1.ChangeTypeDeleted:
+		event.Type = apiv1.EventType_EVENT_TYPE_DELETED
+	}
+
+	switch change.Kind {
+	case modelv1.ResourceKindSecret:
+		var secret v1.Secret
+		if err := json.Unmarshal([]byte(change.Object), &secret); err != nil {
+			return nil, err
+		}
+
+		event.Kind = apiv1.Kind_KIND_SECRET
+		event.Object = &apiv1.Event_Secret{Secret: rpc.ToSecret(&secret)}
+	case modelv1.ResourceKindPolicy:
+		var pol v1.Policy
+		if err := json.Unmarshal([]byte(change.Object), &pol); err != nil {
+			return nil, err
+		}
+
+		event.Kind = apiv1.Kind_KIND_POLICY
+		event.Object = &apiv1.Event_Policy{Policy: rpc.ToPolicy(&pol)}
+	}
+
+	return event, nil
+}
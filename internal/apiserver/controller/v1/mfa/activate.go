@@ -0,0 +1,31 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Activate 校验验证码并启用MFA，返回恢复码. 恢复码只会返回这一次.
+func (m *MFAController) Activate(c *gin.Context) {
+	log.L(c).Info("activate mfa function called.")
+
+	var r CodeRequest
+	if err := c.ShouldBindJSON(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	codes, err := m.srv.MFA().Activate(c, username(c), r.Code)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, gin.H{"recoveryCodes": codes})
+}
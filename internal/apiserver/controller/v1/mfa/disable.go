@@ -0,0 +1,40 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Disable 关闭用户的MFA. 用户关闭自己的MFA时需要提交验证码，管理员重置其他用户时不需要.
+func (m *MFAController) Disable(c *gin.Context) {
+	log.L(c).Info("disable mfa function called.")
+
+	name := username(c)
+	if c.GetString(middleware.UsernameKey) == name {
+		var r CodeRequest
+		if err := c.ShouldBindJSON(&r); err != nil {
+			core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+			return
+		}
+
+		if err := m.srv.MFA().Verify(c, name, r.Code); err != nil {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+	}
+
+	if err := m.srv.MFA().Disable(c, name); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
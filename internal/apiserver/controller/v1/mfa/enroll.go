@@ -0,0 +1,22 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Enroll 为用户生成TOTP密钥，返回密钥和otpauth URI.
+func (m *MFAController) Enroll(c *gin.Context) {
+	log.L(c).Info("enroll mfa function called.")
+
+	enrollment, err := m.srv.MFA().Enroll(c, username(c))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, enrollment)
+}
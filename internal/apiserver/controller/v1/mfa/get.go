@@ -0,0 +1,22 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Get 返回用户的MFA状态.
+func (m *MFAController) Get(c *gin.Context) {
+	log.L(c).Info("get mfa function called.")
+
+	mfa, err := m.srv.MFA().Get(c, username(c))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, mfa)
+}
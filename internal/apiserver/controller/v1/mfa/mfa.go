@@ -0,0 +1,35 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+// MFAController 处理用户多因素认证相关的请求.
+type MFAController struct {
+	srv srvv1.Service
+}
+
+// NewMFAController 创建MFA控制器.
+func NewMFAController(store store.Factory) *MFAController {
+	return &MFAController{
+		srv: srvv1.NewService(store),
+	}
+}
+
+// CodeRequest 定义了提交TOTP验证码或恢复码的数据结构.
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// 优先使用path参数中的用户名，登录流程中的路由没有该参数，使用认证后的用户名.
+func username(c *gin.Context) string {
+	if name := c.Param("name"); name != "" {
+		return name
+	}
+
+	return c.GetString(middleware.UsernameKey)
+}
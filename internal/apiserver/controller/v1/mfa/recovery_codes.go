@@ -0,0 +1,38 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，旧的恢复码全部失效.
+func (m *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	log.L(c).Info("regenerate mfa recovery codes function called.")
+
+	var r CodeRequest
+	if err := c.ShouldBindJSON(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	name := username(c)
+	if err := m.srv.MFA().Verify(c, name, r.Code); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	codes, err := m.srv.MFA().RegenerateRecoveryCodes(c, name)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, gin.H{"recoveryCodes": codes})
+}
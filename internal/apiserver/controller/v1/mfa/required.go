@@ -0,0 +1,35 @@
+package mfa
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// RequiredRequest 定义了设置用户是否必须使用MFA的数据结构.
+type RequiredRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// SetRequired 设置用户是否必须使用MFA，只有管理员可以调用.
+func (m *MFAController) SetRequired(c *gin.Context) {
+	log.L(c).Info("set mfa required function called.")
+
+	var r RequiredRequest
+	if err := c.ShouldBindJSON(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	if err := m.srv.MFA().SetRequired(c, username(c), *r.Required); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
@@ -0,0 +1,41 @@
+package oauthclient
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Create 注册OAuth2客户端，客户端密钥只在响应中返回这一次.
+func (o *OAuthClientController) Create(c *gin.Context) {
+	log.L(c).Info("create oauth client function called.")
+
+	var r modelv1.OAuthClient
+	if err := c.ShouldBindJSON(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	if err := r.Validate(); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation, err.Error()), nil)
+
+		return
+	}
+
+	r.Username = c.GetString(middleware.UsernameKey)
+
+	if err := o.srv.OAuthClients().Create(c, &r, metav1.CreateOptions{}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, r)
+}
@@ -0,0 +1,22 @@
+package oauthclient
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Delete 删除OAuth2客户端，已经签发的token在过期前仍然有效.
+func (o *OAuthClientController) Delete(c *gin.Context) {
+	log.L(c).Info("delete oauth client function called.")
+
+	if err := o.srv.OAuthClients().Delete(c, c.Param("name"), metav1.DeleteOptions{Unscoped: true}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
@@ -0,0 +1,23 @@
+package oauthclient
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Get 返回OAuth2客户端的详情，不包含客户端密钥.
+func (o *OAuthClientController) Get(c *gin.Context) {
+	log.L(c).Info("get oauth client function called.")
+
+	client, err := o.srv.OAuthClients().Get(c, c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, client)
+}
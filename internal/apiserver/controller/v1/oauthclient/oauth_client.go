@@ -0,0 +1,18 @@
+package oauthclient
+
+import (
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+)
+
+// OAuthClientController 处理OAuth2客户端注册相关的请求.
+type OAuthClientController struct {
+	srv srvv1.Service
+}
+
+// NewOAuthClientController 创建OAuth2客户端控制器.
+func NewOAuthClientController(store store.Factory) *OAuthClientController {
+	return &OAuthClientController{
+		srv: srvv1.NewService(store),
+	}
+}
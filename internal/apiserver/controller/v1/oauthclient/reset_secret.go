@@ -0,0 +1,22 @@
+package oauthclient
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// ResetSecret 重新生成客户端密钥，新密钥只在响应中返回这一次.
+func (o *OAuthClientController) ResetSecret(c *gin.Context) {
+	log.L(c).Info("reset oauth client secret function called.")
+
+	client, err := o.srv.OAuthClients().ResetSecret(c, c.Param("name"))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, client)
+}
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// PolicyServer 实现授权策略管理的grpc接口，调用方只能操作自己的策略.
+type PolicyServer struct {
+	apiv1.UnimplementedPolicyServiceServer
+
+	srv srvv1.Service
+}
+
+// NewPolicyServer 创建授权策略管理的grpc服务.
+func NewPolicyServer(store store.Factory) *PolicyServer {
+	return &PolicyServer{srv: srvv1.NewService(store)}
+}
+
+// CreatePolicy 创建一个新的ladon策略.
+func (p *PolicyServer) CreatePolicy(ctx context.Context, r *apiv1.CreatePolicyRequest) (*apiv1.Policy, error) {
+	log.L(ctx).Info("create policy function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	pol := &v1.Policy{ObjectMeta: metav1.ObjectMeta{Name: r.Name}}
+	if err := json.Unmarshal([]byte(r.Policy), &pol.Policy); err != nil {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrBind, err.Error()))
+	}
+
+	if errs := pol.Validate(); len(errs) != 0 {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()))
+	}
+
+	pol.Username = username
+	if err := p.srv.Policies().Create(ctx, pol, metav1.CreateOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToPolicy(pol), nil
+}
+
+// GetPolicy 获取调用方的策略.
+func (p *PolicyServer) GetPolicy(ctx context.Context, r *apiv1.GetPolicyRequest) (*apiv1.Policy, error) {
+	log.L(ctx).Info("get policy function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	pol, err := p.srv.Policies().Get(ctx, username, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToPolicy(pol), nil
+}
+
+// UpdatePolicy 替换调用方策略中的ladon策略.
+func (p *PolicyServer) UpdatePolicy(ctx context.Context, r *apiv1.UpdatePolicyRequest) (*apiv1.Policy, error) {
+	log.L(ctx).Info("update policy function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	var policy v1.AuthzPolicy
+	if err := json.Unmarshal([]byte(r.Policy), &policy); err != nil {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrBind, err.Error()))
+	}
+
+	pol, err := p.srv.Policies().Get(ctx, username, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	pol.Policy = policy
+	if errs := pol.Validate(); len(errs) != 0 {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()))
+	}
+
+	if err := p.srv.Policies().Update(ctx, pol, metav1.UpdateOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToPolicy(pol), nil
+}
+
+// DeletePolicy 删除调用方的策略.
+func (p *PolicyServer) DeletePolicy(ctx context.Context, r *apiv1.DeletePolicyRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("delete policy function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := p.srv.Policies().Delete(ctx, username, r.Name, metav1.DeleteOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeletePolicies 批量删除调用方的策略.
+func (p *PolicyServer) DeletePolicies(ctx context.Context, r *apiv1.DeletePoliciesRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("batch delete policy function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := p.srv.Policies().DeleteCollection(ctx, username, r.Names, metav1.DeleteOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListPolicies 列出调用方的策略.
+func (p *PolicyServer) ListPolicies(ctx context.Context, r *apiv1.ListPoliciesRequest) (*apiv1.ListPoliciesResponse, error) {
+	log.L(ctx).Info("list policy function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	policies, err := p.srv.Policies().List(ctx, username, metav1.ListOptions{Offset: r.Offset, Limit: r.Limit})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	items := make([]*apiv1.Policy, 0, len(policies.Items))
+	for _, pol := range policies.Items {
+		items = append(items, ToPolicy(pol))
+	}
+
+	return &apiv1.ListPoliciesResponse{TotalCount: policies.TotalCount, Items: items}, nil
+}
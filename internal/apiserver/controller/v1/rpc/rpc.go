@@ -0,0 +1,87 @@
+// Package rpc 实现用户、密钥和策略的grpc管理接口，业务逻辑委托给service/v1，权限规则和REST接口相同.
+package rpc
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/interceptor"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// caller 返回通过认证的调用方用户名.
+func caller(ctx context.Context) (string, error) {
+	id, ok := interceptor.IdentityFrom(ctx)
+	if !ok || id.Name == "" {
+		return "", errors.WithCode(code.ErrTokenInvalid, "caller is not authenticated")
+	}
+
+	return id.Name, nil
+}
+
+// isAdmin 确认调用方是否是管理员.
+func isAdmin(ctx context.Context, srv srvv1.Service, username string) bool {
+	user, err := srv.Users().Get(ctx, username, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+
+	return user.IsAdmin == 1
+}
+
+// toStatus 记录错误并转换为grpc状态错误.
+func toStatus(ctx context.Context, err error) error {
+	log.L(ctx).Errorf("%#+v", err)
+
+	return code.GRPCError(err)
+}
+
+// ToUser 把用户转换为grpc消息，不包含密码.
+func ToUser(user *v1.User) *apiv1.User {
+	return &apiv1.User{
+		InstanceId:  user.InstanceID,
+		Name:        user.Name,
+		Nickname:    user.Nickname,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		IsAdmin:     user.IsAdmin == 1,
+		Status:      int32(user.Status),
+		TotalPolicy: user.TotalPolicy,
+		CreatedAt:   user.CreatedAt.Format(time.DateTime),
+		UpdatedAt:   user.UpdatedAt.Format(time.DateTime),
+		LoginedAt:   user.LoginedAt.Format(time.DateTime),
+	}
+}
+
+// ToSecret 把密钥对转换为grpc消息.
+func ToSecret(secret *v1.Secret) *apiv1.Secret {
+	return &apiv1.Secret{
+		Name:        secret.Name,
+		SecretId:    secret.SecretID,
+		Username:    secret.Username,
+		SecretKey:   secret.SecretKey,
+		Expires:     secret.Expires,
+		Description: secret.Description,
+		CreatedAt:   secret.CreatedAt.Format(time.DateTime),
+		UpdatedAt:   secret.UpdatedAt.Format(time.DateTime),
+	}
+}
+
+// ToPolicy 把策略转换为grpc消息.
+func ToPolicy(pol *v1.Policy) *apiv1.Policy {
+	return &apiv1.Policy{
+		Name:         pol.Name,
+		Username:     pol.Username,
+		PolicyStr:    pol.Policy.String(),
+		PolicyShadow: pol.PolicyShadow,
+		CreatedAt:    pol.CreatedAt.Format(time.DateTime),
+		UpdatedAt:    pol.UpdatedAt.Format(time.DateTime),
+	}
+}
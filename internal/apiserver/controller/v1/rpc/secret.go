@@ -0,0 +1,174 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/AlekSi/pointer"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/component-base/pkg/util/idutil"
+	"github.com/marmotedu/errors"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 每个用户最多可以创建的密钥对数量，和REST接口相同.
+const maxSecretCount = 10
+
+// SecretServer 实现密钥对管理的grpc接口，调用方只能操作自己的密钥对.
+type SecretServer struct {
+	apiv1.UnimplementedSecretServiceServer
+
+	srv srvv1.Service
+}
+
+// NewSecretServer 创建密钥对管理的grpc服务.
+func NewSecretServer(store store.Factory) *SecretServer {
+	return &SecretServer{srv: srvv1.NewService(store)}
+}
+
+// CreateSecret 创建密钥对，secretID和secretKey由服务端生成.
+func (s *SecretServer) CreateSecret(ctx context.Context, r *apiv1.CreateSecretRequest) (*apiv1.Secret, error) {
+	log.L(ctx).Info("create secret function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta:  metav1.ObjectMeta{Name: r.Name},
+		Expires:     r.Expires,
+		Description: r.Description,
+	}
+
+	if errs := secret.Validate(); len(errs) != 0 {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()))
+	}
+
+	secrets, err := s.srv.Secrets().List(ctx, username, metav1.ListOptions{
+		Offset: pointer.ToInt64(0),
+		Limit:  pointer.ToInt64(-1),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if secrets.TotalCount >= maxSecretCount {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrReachMaxCount, "secret count: %d", secrets.TotalCount))
+	}
+
+	secret.Username = username
+	secret.SecretID = idutil.NewSecretID()
+	secret.SecretKey = idutil.NewSecretKey()
+
+	if err := s.srv.Secrets().Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToSecret(secret), nil
+}
+
+// GetSecret 获取调用方的密钥对.
+func (s *SecretServer) GetSecret(ctx context.Context, r *apiv1.GetSecretRequest) (*apiv1.Secret, error) {
+	log.L(ctx).Info("get secret function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	secret, err := s.srv.Secrets().Get(ctx, username, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToSecret(secret), nil
+}
+
+// UpdateSecret 更新密钥对的过期时间和描述.
+func (s *SecretServer) UpdateSecret(ctx context.Context, r *apiv1.UpdateSecretRequest) (*apiv1.Secret, error) {
+	log.L(ctx).Info("update secret function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	secret, err := s.srv.Secrets().Get(ctx, username, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	secret.Expires = r.Expires
+	secret.Description = r.Description
+
+	if errs := secret.Validate(); len(errs) != 0 {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()))
+	}
+
+	if err := s.srv.Secrets().Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToSecret(secret), nil
+}
+
+// DeleteSecret 删除调用方的密钥对.
+func (s *SecretServer) DeleteSecret(ctx context.Context, r *apiv1.DeleteSecretRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("delete secret function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := s.srv.Secrets().Delete(ctx, username, r.Name, metav1.DeleteOptions{Unscoped: true}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteSecrets 批量删除调用方的密钥对.
+func (s *SecretServer) DeleteSecrets(ctx context.Context, r *apiv1.DeleteSecretsRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("batch delete secret function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := s.srv.Secrets().DeleteCollection(ctx, username, r.Names, metav1.DeleteOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListSecrets 列出调用方的密钥对.
+func (s *SecretServer) ListSecrets(ctx context.Context, r *apiv1.ListSecretsRequest) (*apiv1.ListSecretsResponse, error) {
+	log.L(ctx).Info("list secret function called.")
+
+	username, err := caller(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	secrets, err := s.srv.Secrets().List(ctx, username, metav1.ListOptions{Offset: r.Offset, Limit: r.Limit})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	items := make([]*apiv1.Secret, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		items = append(items, ToSecret(secret))
+	}
+
+	return &apiv1.ListSecretsResponse{TotalCount: secrets.TotalCount, Items: items}, nil
+}
@@ -0,0 +1,205 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/component-base/pkg/validation"
+	"github.com/marmotedu/errors"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/password"
+)
+
+// UserServer 实现用户管理的grpc接口. 普通用户只能查看和修改自己，列出和删除用户需要管理员权限.
+type UserServer struct {
+	apiv1.UnimplementedUserServiceServer
+
+	srv srvv1.Service
+}
+
+// NewUserServer 创建用户管理的grpc服务.
+func NewUserServer(store store.Factory) *UserServer {
+	return &UserServer{srv: srvv1.NewService(store)}
+}
+
+// CreateUser 创建用户，和REST接口一样不需要认证.
+func (u *UserServer) CreateUser(ctx context.Context, r *apiv1.CreateUserRequest) (*apiv1.User, error) {
+	log.L(ctx).Info("user create function called.")
+
+	user := &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: r.Name},
+		Nickname:   r.Nickname,
+		Password:   r.Password,
+		Email:      r.Email,
+		Phone:      r.Phone,
+	}
+
+	if errs := user.Validate(); len(errs) != 0 {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()))
+	}
+
+	hashed, err := password.Hash(user.Password)
+	if err != nil {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrEncrypt, err.Error()))
+	}
+
+	user.Password = hashed
+	user.Status = 1
+	user.LoginedAt = time.Now()
+
+	if err := u.srv.Users().Create(ctx, user, metav1.CreateOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToUser(user), nil
+}
+
+// GetUser 获取用户信息.
+func (u *UserServer) GetUser(ctx context.Context, r *apiv1.GetUserRequest) (*apiv1.User, error) {
+	log.L(ctx).Info("get user function called.")
+
+	if err := u.authorize(ctx, r.Name, false); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	user, err := u.srv.Users().Get(ctx, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToUser(user), nil
+}
+
+// UpdateUser 更新用户的昵称、邮箱和手机号.
+func (u *UserServer) UpdateUser(ctx context.Context, r *apiv1.UpdateUserRequest) (*apiv1.User, error) {
+	log.L(ctx).Info("update user function called.")
+
+	if err := u.authorize(ctx, r.Name, false); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	user, err := u.srv.Users().Get(ctx, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	user.Nickname = r.Nickname
+	user.Email = r.Email
+	user.Phone = r.Phone
+
+	if errs := user.ValidateUpdate(); len(errs) != 0 {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, errs.ToAggregate().Error()))
+	}
+
+	if err := u.srv.Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return ToUser(user), nil
+}
+
+// DeleteUser 删除用户，只允许管理员调用.
+func (u *UserServer) DeleteUser(ctx context.Context, r *apiv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("delete user function called.")
+
+	if err := u.authorize(ctx, "", true); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := u.srv.Users().Delete(ctx, r.Name, metav1.DeleteOptions{Unscoped: true}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteUsers 批量删除用户，只允许管理员调用.
+func (u *UserServer) DeleteUsers(ctx context.Context, r *apiv1.DeleteUsersRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("batch delete user function called.")
+
+	if err := u.authorize(ctx, "", true); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := u.srv.Users().DeleteCollection(ctx, r.Names, metav1.DeleteOptions{}); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListUsers 列出用户，只允许管理员调用.
+func (u *UserServer) ListUsers(ctx context.Context, r *apiv1.ListUsersRequest) (*apiv1.ListUsersResponse, error) {
+	log.L(ctx).Info("list user function called.")
+
+	if err := u.authorize(ctx, "", true); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	users, err := u.srv.Users().List(ctx, metav1.ListOptions{Offset: r.Offset, Limit: r.Limit})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	items := make([]*apiv1.User, 0, len(users.Items))
+	for _, user := range users.Items {
+		items = append(items, ToUser(user))
+	}
+
+	return &apiv1.ListUsersResponse{TotalCount: users.TotalCount, Items: items}, nil
+}
+
+// ChangePassword 校验旧密码后修改用户密码，修改后用户之前的会话全部失效.
+func (u *UserServer) ChangePassword(ctx context.Context, r *apiv1.ChangePasswordRequest) (*emptypb.Empty, error) {
+	log.L(ctx).Info("change password function called.")
+
+	if err := u.authorize(ctx, r.Name, false); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	user, err := u.srv.Users().Get(ctx, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := u.srv.Users().VerifyPassword(ctx, user, r.OldPassword); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := validation.IsValidPassword(r.NewPassword); err != nil {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrValidation, err.Error()))
+	}
+
+	user.Password, err = password.Hash(r.NewPassword)
+	if err != nil {
+		return nil, toStatus(ctx, errors.WithCode(code.ErrEncrypt, err.Error()))
+	}
+
+	if err := u.srv.Users().ChangePassword(ctx, user); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// authorize 管理员可以操作所有用户，非管理员只能操作自己并且不能调用管理员接口.
+func (u *UserServer) authorize(ctx context.Context, name string, adminOnly bool) error {
+	username, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if isAdmin(ctx, u.srv, username) || (!adminOnly && username == name) {
+		return nil
+	}
+
+	return errors.WithCode(code.ErrPermissionDenied, "user %s is not allowed to operate user %s", username, name)
+}
@@ -0,0 +1,28 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Delete 吊销用户的一个会话.
+func (s *SessionController) Delete(c *gin.Context) {
+	log.L(c).Info("delete session function called.")
+
+	r, err := s.registry()
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if err := r.Revoke(c, c.Param("name"), c.Param("sid")); err != nil {
+		core.WriteResponse(c, withCode(err), nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
@@ -0,0 +1,28 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// DeleteCollection 吊销用户的所有会话.
+func (s *SessionController) DeleteCollection(c *gin.Context) {
+	log.L(c).Info("batch delete session function called.")
+
+	r, err := s.registry()
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if err := r.RevokeAll(c, c.Param("name")); err != nil {
+		core.WriteResponse(c, withCode(err), nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
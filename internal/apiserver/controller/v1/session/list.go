@@ -0,0 +1,29 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// List 返回用户所有未过期的会话.
+func (s *SessionController) List(c *gin.Context) {
+	log.L(c).Info("list session function called.")
+
+	r, err := s.registry()
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	sessions, err := r.List(c, c.Param("name"))
+	if err != nil {
+		core.WriteResponse(c, withCode(err), nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, gin.H{"totalCount": len(sessions), "items": sessions})
+}
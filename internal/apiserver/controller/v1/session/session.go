@@ -0,0 +1,35 @@
+package session
+
+import (
+	"github.com/marmotedu/errors"
+
+	registry "github.com/cuizhaoyue/iams/internal/apiserver/session"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+// SessionController 处理用户登录会话相关的请求.
+type SessionController struct{}
+
+// NewSessionController 创建会话控制器.
+func NewSessionController() *SessionController {
+	return &SessionController{}
+}
+
+func (s *SessionController) registry() (*registry.Registry, error) {
+	r := registry.Default()
+	if r == nil {
+		return nil, errors.WithCode(code.ErrUnknown, "session registry is not configured")
+	}
+
+	return r, nil
+}
+
+// 把会话存储返回的错误转换为错误码.
+func withCode(err error) error {
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return errors.WithCode(code.ErrSessionNotFound, err.Error())
+	}
+
+	return errors.WithCode(code.ErrDatabase, err.Error())
+}
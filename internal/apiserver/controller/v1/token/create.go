@@ -0,0 +1,44 @@
+package token
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Create 创建个人访问令牌，令牌明文只在响应中返回这一次.
+func (t *TokenController) Create(c *gin.Context) {
+	log.L(c).Info("create access token function called.")
+
+	var r modelv1.AccessToken
+	if err := c.ShouldBindJSON(&r); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrBind, err.Error()), nil)
+
+		return
+	}
+
+	if err := r.Validate(); err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrValidation, err.Error()), nil)
+
+		return
+	}
+
+	// 只能为自己创建访问令牌
+	r.Username = c.GetString(middleware.UsernameKey)
+	r.LastUsedAt = nil
+	r.LastUsedIP = ""
+
+	if err := t.srv.AccessTokens().Create(c, &r, metav1.CreateOptions{}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, r)
+}
@@ -0,0 +1,24 @@
+package token
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Delete 吊销个人访问令牌.
+func (t *TokenController) Delete(c *gin.Context) {
+	log.L(c).Info("delete access token function called.")
+
+	if err := t.srv.AccessTokens().Delete(c, c.GetString(middleware.UsernameKey), c.Param("name"),
+		metav1.DeleteOptions{Unscoped: true}); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, nil)
+}
@@ -0,0 +1,24 @@
+package token
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// Get 返回个人访问令牌的详情，不包含令牌明文.
+func (t *TokenController) Get(c *gin.Context) {
+	log.L(c).Info("get access token function called.")
+
+	token, err := t.srv.AccessTokens().Get(c, c.GetString(middleware.UsernameKey), c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	core.WriteResponse(c, nil, token)
+}
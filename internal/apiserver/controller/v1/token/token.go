@@ -0,0 +1,18 @@
+package token
+
+import (
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+)
+
+// TokenController 处理个人访问令牌相关的请求.
+type TokenController struct {
+	srv srvv1.Service
+}
+
+// NewTokenController 创建个人访问令牌控制器.
+func NewTokenController(store store.Factory) *TokenController {
+	return &TokenController{
+		srv: srvv1.NewService(store),
+	}
+}
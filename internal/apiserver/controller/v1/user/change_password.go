@@ -3,8 +3,8 @@ package user
 import (
 	"github.com/cuizhaoyue/iams/internal/pkg/code"
 	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/password"
 	"github.com/gin-gonic/gin"
-	"github.com/marmotedu/component-base/pkg/auth"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
@@ -40,14 +40,20 @@ func (u *UserController) ChangePassword(c *gin.Context) {
 	}
 
 	// 比较密码是否正确
-	if err := user.Compare(r.OldPassword); err != nil {
-		core.WriteResponse(c, errors.WithCode(code.ErrPasswordIncorrect, err.Error()), nil)
+	if err := u.srv.Users().VerifyPassword(c, user, r.OldPassword); err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	// 使用当前配置的哈希算法对新密码进行加密.
+	user.Password, err = password.Hash(r.NewPassword)
+	if err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrEncrypt, err.Error()), nil)
 
 		return
 	}
 
-	// 使用golang.org/x/crypto/bcrypt包对新密码进行加密.
-	user.Password, _ = auth.Encrypt(r.NewPassword)
 	if err := u.srv.Users().ChangePassword(c, user); err != nil {
 		core.WriteResponse(c, err, nil)
 
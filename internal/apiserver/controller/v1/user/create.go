@@ -5,9 +5,9 @@ import (
 
 	"github.com/cuizhaoyue/iams/internal/pkg/code"
 	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/password"
 	"github.com/gin-gonic/gin"
 	v1 "github.com/marmotedu/api/apiserver/v1"
-	"github.com/marmotedu/component-base/pkg/auth"
 	"github.com/marmotedu/component-base/pkg/core"
 	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
 	"github.com/marmotedu/errors"
@@ -33,9 +33,17 @@ func (u *UserController) Create(c *gin.Context) {
 		return
 	}
 
-	r.Password, _ = auth.Encrypt(r.Password) // 密码加密
-	r.Status = 1                             // 设置用户状态
-	r.LoginedAt = time.Now()                 // 设置登录时间
+	// 密码加密
+	hashed, err := password.Hash(r.Password)
+	if err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrEncrypt, err.Error()), nil)
+
+		return
+	}
+
+	r.Password = hashed
+	r.Status = 1             // 设置用户状态
+	r.LoginedAt = time.Now() // 设置登录时间
 
 	// Insert the user to the storage. 向数据库插入数据
 	if err := u.srv.Users().Create(c, &r, metav1.CreateOptions{}); err != nil {
@@ -1,38 +1,145 @@
 package apiserver
 
 import (
+	"context"
 	"net"
+	"time"
 
-	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/marmotedu/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/cache"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/storage"
 )
 
 // 提供apiserver的grpc服务.
 type grpcAPIServer struct {
 	*grpc.Server
-	address string
+	address        string
+	health         *health.Server
+	healthInterval time.Duration
+	// 等待正在处理的请求完成的最长时间，为0时一直等待
+	shutdownTimeout time.Duration
+	// watch调用不会自己结束，关闭服务前需要停止
+	watcher *cache.Watcher
+	cancel  context.CancelFunc
 }
 
-// Run 启动grpc服务
-func (s *grpcAPIServer) Run() {
+// Run 监听地址并在后台启动grpc服务，监听失败时返回错误.
+func (s *grpcAPIServer) Run() error {
 	// 创建监听器
 	listener, err := net.Listen("tcp", s.address)
 	if err != nil {
-		log.Fatalf("failed to listen: %s", err.Error())
+		return errors.Wrapf(err, "failed to listen grpc address %s", s.address)
 	}
 
-	// 启动grpc服务
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go s.checkHealth(ctx)
+
+	// 启动grpc服务，服务异常退出时只记录日志，由健康检查和监控发现
 	go func() {
 		if err := s.Serve(listener); err != nil {
-			log.Fatalf("failed to start grpc server: %s", err.Error())
+			log.Errorf("grpc server on %s exited: %s", s.address, err.Error())
 		}
 	}()
 
 	log.Infof("start grpc server at %s", s.address)
+
+	return nil
+}
+
+// StopReadiness 把所有服务的健康状态设置为NOT_SERVING，之后不再更新.
+func (s *grpcAPIServer) StopReadiness() {
+	if s == nil {
+		return
+	}
+
+	s.health.Shutdown()
 }
 
-// Close 优雅关闭grpc服务
+// Close 优雅关闭grpc服务，关闭前把健康状态设置为NOT_SERVING. 超过shutdownTimeout后强制关闭所有连接.
 func (s *grpcAPIServer) Close() {
-	s.GracefulStop()
+	if s == nil {
+		return
+	}
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	s.health.Shutdown()
+	s.watcher.Stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	var timeout <-chan time.Time
+	if s.shutdownTimeout > 0 {
+		timer := time.NewTimer(s.shutdownTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-stopped:
+	case <-timeout:
+		log.Warnf("GRPC server on %s did not stop within %s, close all connections", s.address, s.shutdownTimeout)
+		s.Stop()
+		<-stopped
+	}
+
 	log.Infof("GRPC server on %s stopped", s.address)
 }
+
+// checkHealth 定期检查MySQL和Redis的状态，并更新所有服务的健康状态.
+func (s *grpcAPIServer) checkHealth(ctx context.Context) {
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		st := healthpb.HealthCheckResponse_SERVING
+		if err := s.dependencyErr(ctx); err != nil {
+			log.Warnf("grpc server is not serving: %s", err.Error())
+
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		// 空服务名表示整个服务器的状态
+		s.health.SetServingStatus("", st)
+		for name := range s.GetServiceInfo() {
+			if name != healthpb.Health_ServiceDesc.ServiceName {
+				s.health.SetServingStatus(name, st)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *grpcAPIServer) dependencyErr(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.healthInterval)
+	defer cancel()
+
+	if err := store.Client().Ping(ctx); err != nil {
+		return errors.Wrap(err, "mysql is unavailable")
+	}
+
+	if !storage.Connected() {
+		return errors.New("redis is unavailable")
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/marmotedu/errors"
+	"google.golang.org/grpc/peer"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/config"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+// grpcCacheServices 是会返回密钥明文的缓存服务，只允许grpc.auth.allowed-identities中的身份调用.
+// marmotedu/api生成的代码没有导出Cache服务的ServiceDesc.
+var grpcCacheServices = []string{
+	"/proto.Cache/",
+	"/" + apiv1.CacheWatcher_ServiceDesc.ServiceName + "/",
+}
+
+// grpc服务对应的访问令牌权限范围中的资源.
+var grpcScopeResources = map[string]string{
+	apiv1.UserService_ServiceDesc.ServiceName:   "users",
+	apiv1.SecretService_ServiceDesc.ServiceName: "secrets",
+	apiv1.PolicyService_ServiceDesc.ServiceName: "policies",
+}
+
+func isGRPCCacheMethod(method string) bool {
+	for _, prefix := range grpcCacheServices {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// grpcTokenAuth 校验grpc调用方的bearer token并返回token所属的用户名. 缓存服务接受个人访问令牌和使用密钥对签名的jwt，
+// 其他服务和REST接口一样接受登录签发的jwt和个人访问令牌，个人访问令牌需要具有方法对应的权限范围.
+func grpcTokenAuth(cfg *config.Config) func(ctx context.Context, method, token string) (string, error) {
+	secretAuth := newSecretAuth()
+	jwtAuth := newJWTAuth(cfg)
+
+	return func(ctx context.Context, method, token string) (string, error) {
+		cache := isGRPCCacheMethod(method)
+
+		if strings.HasPrefix(token, srvv1.AccessTokenPrefix) {
+			if cache {
+				t, err := srvv1.NewService(store.Client()).AccessTokens().Lookup(ctx, token)
+				if err != nil {
+					return "", err
+				}
+
+				return t.Username, nil
+			}
+
+			t, err := srvv1.NewService(store.Client()).AccessTokens().Authenticate(ctx, token, peerIP(ctx))
+			if err != nil {
+				return "", err
+			}
+
+			scope := grpcRequiredScope(method)
+			if scope == "" || !t.HasScope(scope) {
+				return "", errors.WithCode(code.ErrInsufficientScope, "access token %s requires scope `%s`", t.Name, scope)
+			}
+
+			return t.Username, nil
+		}
+
+		if cache {
+			secret, err := secretAuth.Verify(ctx, token)
+			if err != nil {
+				return "", err
+			}
+
+			return secret.Username, nil
+		}
+
+		claims, err := jwtAuth.ParseToken(token)
+		if err != nil {
+			return "", err
+		}
+
+		if err := validateSession(ctx, claims, peerIP(ctx)); err != nil {
+			return "", err
+		}
+
+		username, _ := claims[middleware.UsernameKey].(string)
+		if username == "" {
+			return "", errors.WithCode(code.ErrTokenInvalid, "token has no identity")
+		}
+
+		return username, nil
+	}
+}
+
+// grpcCertAuth 校验客户端证书中的身份，缓存服务的调用方是其他服务，其他服务和REST接口一样要求是已经存在并且没有被禁用的用户.
+func grpcCertAuth(ctx context.Context, method, name string) error {
+	if isGRPCCacheMethod(method) {
+		return nil
+	}
+
+	return certUserAvailable(ctx, name)
+}
+
+// grpcRequiredScope 根据方法返回访问令牌需要的权限范围，规则和requiredScope相同. 返回空字符串表示不允许使用访问令牌访问.
+func grpcRequiredScope(method string) string {
+	parts := strings.Split(strings.TrimPrefix(method, "/"), "/")
+	if len(parts) != 2 || parts[1] == "ChangePassword" {
+		return ""
+	}
+
+	resource, ok := grpcScopeResources[parts[0]]
+	if !ok {
+		return ""
+	}
+
+	if strings.HasPrefix(parts[1], "Get") || strings.HasPrefix(parts[1], "List") {
+		return resource + ":read"
+	}
+
+	return resource + ":write"
+}
+
+// peerIP 返回grpc调用方的IP，无法获取时返回空字符串.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+
+	return host
+}
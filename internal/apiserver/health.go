@@ -0,0 +1,56 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	genericapiserver "github.com/cuizhaoyue/iams/internal/pkg/server"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+// 单个就绪检查的最长时间.
+const healthCheckTimeout = 2 * time.Second
+
+// initHealthChecks 添加依赖的MySQL、Redis和grpc服务的就绪检查.
+func (s *apiServer) initHealthChecks() {
+	s.genericAPIServer.AddReadyzChecks(
+		genericapiserver.NamedCheck("mysql", func(r *http.Request) error {
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			defer cancel()
+
+			return store.Client().Ping(ctx)
+		}),
+		genericapiserver.NamedCheck("redis", func(*http.Request) error {
+			if !storage.Connected() {
+				return fmt.Errorf("redis is not connected")
+			}
+
+			return nil
+		}),
+	)
+
+	if s.gRPCAPIServer != nil {
+		s.genericAPIServer.AddReadyzChecks(genericapiserver.NamedCheck("grpc", func(r *http.Request) error {
+			return s.gRPCAPIServer.servingErr(r.Context())
+		}))
+	}
+}
+
+// servingErr grpc服务没有处于SERVING状态时返回错误.
+func (s *grpcAPIServer) servingErr(ctx context.Context) error {
+	resp, err := s.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc server is %s", resp.Status)
+	}
+
+	return nil
+}
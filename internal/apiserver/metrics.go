@@ -0,0 +1,45 @@
+package apiserver
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cuizhaoyue/iams/pkg/shutdown"
+)
+
+var (
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "iam_login_attempts_total",
+		Help: "Total number of logins, by method (password, mfa) and result.",
+	}, []string{"method", "result"})
+
+	shutdownDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "iam_shutdown_callback_duration_seconds",
+		Help: "Duration of each shutdown callback in the last shutdown, in seconds.",
+	}, []string{"phase", "callback", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(loginAttempts, shutdownDuration)
+}
+
+// observeLogin 记录登录结果.
+func observeLogin(method string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	loginAttempts.WithLabelValues(method, result).Inc()
+}
+
+// observeShutdown 记录关闭服务时每个回调的耗时.
+func observeShutdown(phase shutdown.Phase, name string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	shutdownDuration.WithLabelValues(phase.String(), name, result).Set(duration.Seconds())
+}
@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/component-base/pkg/util/idutil"
+	"gorm.io/gorm"
+)
+
+// 个人访问令牌支持的权限范围，格式为`资源:操作`.
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeSecretsRead   = "secrets:read"
+	ScopeSecretsWrite  = "secrets:write"
+	ScopePoliciesRead  = "policies:read"
+	ScopePoliciesWrite = "policies:write"
+)
+
+// Scopes 返回所有支持的权限范围.
+func Scopes() []string {
+	return []string{
+		ScopeUsersRead, ScopeUsersWrite,
+		ScopeSecretsRead, ScopeSecretsWrite,
+		ScopePoliciesRead, ScopePoliciesWrite,
+	}
+}
+
+// AccessToken 表示用户的个人访问令牌，令牌本身只保存sha256哈希值.
+type AccessToken struct {
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Username 令牌所属用户.
+	Username string `json:"username" gorm:"column:username"`
+
+	// Token 令牌明文，只在创建时返回一次，不保存到数据库.
+	Token string `json:"token,omitempty" gorm:"-"`
+
+	// TokenHash 令牌的sha256哈希值.
+	TokenHash string `json:"-" gorm:"column:tokenHash"`
+
+	// Scopes 令牌的权限范围.
+	Scopes []string `json:"scopes" gorm:"-"`
+
+	// AllowedIPs 允许使用该令牌的IP或者CIDR，为空时不限制.
+	AllowedIPs []string `json:"allowedIPs,omitempty" gorm:"-"`
+
+	// ExpiresAt 过期时间，为空时永不过期.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" gorm:"column:expiresAt"`
+
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" gorm:"column:lastUsedAt"`
+	LastUsedIP string     `json:"lastUsedIP,omitempty" gorm:"column:lastUsedIP"`
+
+	Description string `json:"description" gorm:"column:description"`
+
+	// 以逗号分隔的Scopes和AllowedIPs，不要直接修改.
+	ScopesShadow     string `json:"-" gorm:"column:scopes"`
+	AllowedIPsShadow string `json:"-" gorm:"column:allowedIPs"`
+}
+
+// AccessTokenList 是个人访问令牌列表.
+type AccessTokenList struct {
+	// Standard list metadata.
+	metav1.ListMeta `json:",inline"`
+
+	Items []*AccessToken `json:"items"`
+}
+
+// TableName 映射到mysql中的表名.
+func (t *AccessToken) TableName() string {
+	return "access_token"
+}
+
+// Validate 校验令牌的名称、权限范围和IP白名单.
+func (t *AccessToken) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if len(t.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+
+	for _, scope := range t.Scopes {
+		if !validScope(scope) {
+			return fmt.Errorf("unsupported scope `%s`, must be one of: %s", scope, strings.Join(Scopes(), ", "))
+		}
+	}
+
+	for _, ip := range t.AllowedIPs {
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("invalid allowed ip `%s`", ip)
+			}
+		}
+	}
+
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("expiresAt must be in the future")
+	}
+
+	return nil
+}
+
+// HasScope 判断令牌是否拥有指定的权限范围.
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired 判断令牌是否已经过期.
+func (t *AccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// AllowIP 判断是否允许从指定的IP使用该令牌.
+func (t *AccessToken) AllowIP(ip string) bool {
+	if len(t.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, allowed := range t.AllowedIPs {
+		if _, cidr, err := net.ParseCIDR(allowed); err == nil {
+			if cidr.Contains(addr) {
+				return true
+			}
+
+			continue
+		}
+
+		if a := net.ParseIP(allowed); a != nil && a.Equal(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BeforeCreate run before create database record.
+func (t *AccessToken) BeforeCreate(tx *gorm.DB) error {
+	if err := t.ObjectMeta.BeforeCreate(tx); err != nil {
+		return fmt.Errorf("failed to run `BeforeCreate` hook: %w", err)
+	}
+
+	t.ScopesShadow = strings.Join(t.Scopes, ",")
+	t.AllowedIPsShadow = strings.Join(t.AllowedIPs, ",")
+
+	return nil
+}
+
+// AfterCreate run after create database record.
+func (t *AccessToken) AfterCreate(tx *gorm.DB) error {
+	t.InstanceID = idutil.GetInstanceID(t.ID, "token-")
+
+	return tx.Save(t).Error
+}
+
+// BeforeUpdate run before update database record.
+func (t *AccessToken) BeforeUpdate(tx *gorm.DB) error {
+	if err := t.ObjectMeta.BeforeUpdate(tx); err != nil {
+		return fmt.Errorf("failed to run `BeforeUpdate` hook: %w", err)
+	}
+
+	t.ScopesShadow = strings.Join(t.Scopes, ",")
+	t.AllowedIPsShadow = strings.Join(t.AllowedIPs, ",")
+
+	return nil
+}
+
+// AfterFind run after find to split scopes and allowed ips.
+func (t *AccessToken) AfterFind(tx *gorm.DB) error {
+	if err := t.ObjectMeta.AfterFind(tx); err != nil {
+		return fmt.Errorf("failed to run `AfterFind` hook: %w", err)
+	}
+
+	t.Scopes = splitShadow(t.ScopesShadow)
+	t.AllowedIPs = splitShadow(t.AllowedIPsShadow)
+
+	return nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitShadow(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"gorm.io/gorm"
+)
+
+// AuditEvent 记录一次修改资源的请求.
+type AuditEvent struct {
+	ID uint64 `json:"id" gorm:"column:id;primaryKey"`
+
+	// Username 发起请求的用户，未认证的请求为空.
+	Username string `json:"username" gorm:"column:username"`
+	// AuthMethod 认证方式: basic, jwt, token, cert.
+	AuthMethod string `json:"authMethod,omitempty" gorm:"column:authMethod"`
+	// ClientIP 客户端地址，只有请求来自信任的代理时才取自X-Forwarded-For等请求头.
+	ClientIP string `json:"clientIP" gorm:"column:clientIP"`
+	// RemoteIP 连接的对端地址，不受请求头影响.
+	RemoteIP  string `json:"remoteIP"  gorm:"column:remoteIP"`
+	RequestID string `json:"requestID" gorm:"column:requestID"`
+
+	Method string `json:"method" gorm:"column:method"`
+	// Route 匹配的路由，例如: /v1/users/:name，没有匹配的路由时为空.
+	Route string `json:"route" gorm:"column:route"`
+	Path  string `json:"path"  gorm:"column:path"`
+
+	// Resource 资源类型，取自路由的第二段，例如: users、policies.
+	Resource     string `json:"resource"               gorm:"column:resource"`
+	ResourceName string `json:"resourceName,omitempty" gorm:"column:resourceName"`
+
+	// StatusCode 响应的http状态码.
+	StatusCode int `json:"statusCode" gorm:"column:statusCode"`
+
+	// Request 隐藏了敏感字段的请求体.
+	Request json.RawMessage `json:"request,omitempty" gorm:"-"`
+	// Diff 请求前后资源的差异，格式为{"字段路径": {"old": 旧值, "new": 新值}}.
+	Diff json.RawMessage `json:"diff,omitempty" gorm:"-"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:createdAt"`
+
+	// 数据库中保存的Request和Diff，不要直接修改.
+	RequestShadow string `json:"-" gorm:"column:request"`
+	DiffShadow    string `json:"-" gorm:"column:diff"`
+}
+
+// AuditEventList 是审计记录列表.
+type AuditEventList struct {
+	// Standard list metadata.
+	metav1.ListMeta `json:",inline"`
+
+	Items []*AuditEvent `json:"items"`
+}
+
+// AuditListOptions 是查询审计记录的条件，为空的条件不做过滤.
+type AuditListOptions struct {
+	Username     string     `form:"username"`
+	Resource     string     `form:"resource"`
+	ResourceName string     `form:"resourceName"`
+	Since        *time.Time `form:"since"        time_format:"2006-01-02T15:04:05Z07:00"`
+	Until        *time.Time `form:"until"        time_format:"2006-01-02T15:04:05Z07:00"`
+	Offset       *int64     `form:"offset"`
+	Limit        *int64     `form:"limit"`
+}
+
+// TableName 映射到mysql中的表名.
+func (e *AuditEvent) TableName() string {
+	return "audit_event"
+}
+
+// BeforeCreate run before create database record.
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	e.RequestShadow = string(e.Request)
+	e.DiffShadow = string(e.Diff)
+
+	return nil
+}
+
+// AfterFind run after find to restore request and diff.
+func (e *AuditEvent) AfterFind(tx *gorm.DB) error {
+	if e.RequestShadow != "" {
+		e.Request = json.RawMessage(e.RequestShadow)
+	}
+
+	if e.DiffShadow != "" {
+		e.Diff = json.RawMessage(e.DiffShadow)
+	}
+
+	return nil
+}
@@ -0,0 +1,3 @@
+// Package v1 定义了iam-apiserver自身使用的数据模型，
+// 这些模型不属于对外发布的github.com/marmotedu/api.
+package v1
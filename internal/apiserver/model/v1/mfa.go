@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"time"
+)
+
+// UserMFA 保存用户的多因素认证(TOTP)配置.
+type UserMFA struct {
+	ID uint64 `json:"id,omitempty" gorm:"primary_key;AUTO_INCREMENT;column:id"`
+
+	// Username 所属用户.
+	Username string `json:"username" gorm:"column:username"`
+
+	// Secret TOTP密钥，只在登记时返回给用户一次.
+	Secret string `json:"-" gorm:"column:secret"`
+
+	// Enabled 用户是否已经完成登记并启用了MFA.
+	Enabled bool `json:"enabled" gorm:"column:enabled"`
+
+	// Required 管理员是否要求该用户必须使用MFA.
+	Required bool `json:"required" gorm:"column:required"`
+
+	// RecoveryCodes 经过哈希的恢复码，以逗号分隔，使用后即删除.
+	RecoveryCodes string `json:"-" gorm:"column:recoveryCodes"`
+
+	// LastUsedStep 最后一次校验成功的TOTP时间窗口，防止同一个验证码被重放.
+	LastUsedStep int64 `json:"-" gorm:"column:lastUsedStep"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty" gorm:"column:createdAt"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty" gorm:"column:updatedAt"`
+}
+
+// TableName 映射到mysql中的表名.
+func (m *UserMFA) TableName() string {
+	return "user_mfa"
+}
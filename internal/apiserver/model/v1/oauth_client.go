@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/component-base/pkg/util/idutil"
+	"gorm.io/gorm"
+)
+
+// OAuth2客户端支持的授权类型.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// OAuthClient 表示一个注册到iam的OAuth2/OIDC客户端，客户端密钥只保存sha256哈希值.
+type OAuthClient struct {
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// ClientID 客户端标识.
+	ClientID string `json:"clientID" gorm:"column:clientID"`
+
+	// ClientSecret 客户端密钥明文，只在创建时返回一次，不保存到数据库.
+	ClientSecret string `json:"clientSecret,omitempty" gorm:"-"`
+
+	// SecretHash 客户端密钥的sha256哈希值，公开客户端为空.
+	SecretHash string `json:"-" gorm:"column:secretHash"`
+
+	// Public 是否是公开客户端(例如单页应用)，公开客户端没有密钥，必须使用PKCE.
+	Public bool `json:"public" gorm:"column:public"`
+
+	// Username 注册该客户端的用户.
+	Username string `json:"username" gorm:"column:username"`
+
+	RedirectURIs []string `json:"redirectURIs"         gorm:"-"`
+	GrantTypes   []string `json:"grantTypes"           gorm:"-"`
+	Scopes       []string `json:"scopes"               gorm:"-"`
+	Description  string   `json:"description"          gorm:"column:description"`
+
+	// 以逗号分隔的RedirectURIs、GrantTypes和Scopes，不要直接修改.
+	RedirectURIsShadow string `json:"-" gorm:"column:redirectURIs"`
+	GrantTypesShadow   string `json:"-" gorm:"column:grantTypes"`
+	ScopesShadow       string `json:"-" gorm:"column:scopes"`
+}
+
+// OAuthClientList 是OAuth2客户端列表.
+type OAuthClientList struct {
+	// Standard list metadata.
+	metav1.ListMeta `json:",inline"`
+
+	Items []*OAuthClient `json:"items"`
+}
+
+// TableName 映射到mysql中的表名.
+func (o *OAuthClient) TableName() string {
+	return "oauth_client"
+}
+
+// Validate 校验客户端的回调地址和授权类型.
+func (o *OAuthClient) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if len(o.GrantTypes) == 0 {
+		return fmt.Errorf("at least one grant type is required")
+	}
+
+	for _, gt := range o.GrantTypes {
+		switch gt {
+		case GrantTypeAuthorizationCode, GrantTypeRefreshToken:
+		case GrantTypeClientCredentials:
+			if o.Public {
+				return fmt.Errorf("public clients can not use the client_credentials grant")
+			}
+		default:
+			return fmt.Errorf("unsupported grant type `%s`", gt)
+		}
+	}
+
+	if o.HasGrantType(GrantTypeAuthorizationCode) && len(o.RedirectURIs) == 0 {
+		return fmt.Errorf("redirectURIs are required for the authorization_code grant")
+	}
+
+	for _, uri := range o.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" || strings.Contains(uri, ",") {
+			return fmt.Errorf("invalid redirect uri `%s`", uri)
+		}
+	}
+
+	for _, scope := range o.Scopes {
+		if scope == "" || strings.ContainsAny(scope, ", ") {
+			return fmt.Errorf("invalid scope `%s`", scope)
+		}
+	}
+
+	return nil
+}
+
+// HasGrantType 判断客户端是否允许使用指定的授权类型.
+func (o *OAuthClient) HasGrantType(grantType string) bool {
+	for _, gt := range o.GrantTypes {
+		if gt == grantType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRedirectURI 判断回调地址是否已经注册，要求完全匹配.
+func (o *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range o.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowScope 判断客户端是否可以申请指定的scope，没有配置Scopes时不限制.
+func (o *OAuthClient) AllowScope(scope string) bool {
+	if len(o.Scopes) == 0 {
+		return true
+	}
+
+	for _, s := range o.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BeforeCreate run before create database record.
+func (o *OAuthClient) BeforeCreate(tx *gorm.DB) error {
+	if err := o.ObjectMeta.BeforeCreate(tx); err != nil {
+		return fmt.Errorf("failed to run `BeforeCreate` hook: %w", err)
+	}
+
+	o.fillShadow()
+
+	return nil
+}
+
+// AfterCreate run after create database record.
+func (o *OAuthClient) AfterCreate(tx *gorm.DB) error {
+	o.InstanceID = idutil.GetInstanceID(o.ID, "oauth-client-")
+
+	return tx.Save(o).Error
+}
+
+// BeforeUpdate run before update database record.
+func (o *OAuthClient) BeforeUpdate(tx *gorm.DB) error {
+	if err := o.ObjectMeta.BeforeUpdate(tx); err != nil {
+		return fmt.Errorf("failed to run `BeforeUpdate` hook: %w", err)
+	}
+
+	o.fillShadow()
+
+	return nil
+}
+
+// AfterFind run after find to split redirect uris, grant types and scopes.
+func (o *OAuthClient) AfterFind(tx *gorm.DB) error {
+	if err := o.ObjectMeta.AfterFind(tx); err != nil {
+		return fmt.Errorf("failed to run `AfterFind` hook: %w", err)
+	}
+
+	o.RedirectURIs = splitShadow(o.RedirectURIsShadow)
+	o.GrantTypes = splitShadow(o.GrantTypesShadow)
+	o.Scopes = splitShadow(o.ScopesShadow)
+
+	return nil
+}
+
+func (o *OAuthClient) fillShadow() {
+	o.RedirectURIsShadow = strings.Join(o.RedirectURIs, ",")
+	o.GrantTypesShadow = strings.Join(o.GrantTypes, ",")
+	o.ScopesShadow = strings.Join(o.Scopes, ",")
+}
@@ -0,0 +1,37 @@
+package v1
+
+import "time"
+
+// 发生变更的资源类型.
+const (
+	ResourceKindSecret = "secret"
+	ResourceKindPolicy = "policy"
+)
+
+// 资源的变更类型.
+const (
+	ChangeTypeCreated = "created"
+	ChangeTypeUpdated = "updated"
+	ChangeTypeDeleted = "deleted"
+)
+
+// ResourceChange 记录一次secret或policy的变更，自增的ID作为revision，供watch按顺序推送.
+type ResourceChange struct {
+	// Revision 变更的revision，单调递增.
+	Revision int64 `json:"revision" gorm:"column:id;primaryKey"`
+
+	Kind     string `json:"kind"     gorm:"column:kind"`
+	Type     string `json:"type"     gorm:"column:type"`
+	Username string `json:"username" gorm:"column:username"`
+	Name     string `json:"name"     gorm:"column:name"`
+
+	// Object 变更后的对象，删除时是删除前的对象，json格式.
+	Object string `json:"object" gorm:"column:object"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:createdAt"`
+}
+
+// TableName 映射到mysql中的表名.
+func (c *ResourceChange) TableName() string {
+	return "resource_change"
+}
@@ -0,0 +1,187 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 授权请求参数，同时支持GET查询参数和POST表单
+type authorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	Prompt              string `form:"prompt"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// Authorize 处理授权请求. 用户需要已经登录iam，授权码通过回调地址返回给客户端.
+// iam只为内部应用提供登录，因此不需要用户确认授权.
+func (p *Provider) Authorize(c *gin.Context) {
+	var r authorizeRequest
+	if err := c.ShouldBind(&r); err != nil {
+		writeError(c, newError(http.StatusBadRequest, errInvalidRequest, err.Error()))
+
+		return
+	}
+
+	// 客户端和回调地址校验失败时不能跳转到回调地址，直接返回错误
+	client, err := store.Client().OAuthClients().GetByClientID(c, r.ClientID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrOAuthClientNotFound) {
+			writeError(c, newError(http.StatusBadRequest, errInvalidRequest, "unknown client_id"))
+		} else {
+			log.L(c).Errorf("get oauth client %s failed: %s", r.ClientID, err.Error())
+			writeError(c, newError(http.StatusInternalServerError, errServerError, ""))
+		}
+
+		return
+	}
+
+	redirectURI := r.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+
+	if !client.HasRedirectURI(redirectURI) {
+		writeError(c, newError(http.StatusBadRequest, errInvalidRequest, "redirect_uri is not registered"))
+
+		return
+	}
+
+	if oerr := p.validateAuthorizeRequest(client, &r); oerr != nil {
+		redirectError(c, redirectURI, r.State, oerr)
+
+		return
+	}
+
+	identity, err := p.cfg.Authenticator(c)
+	if err != nil {
+		p.requireLogin(c, &r, redirectURI)
+
+		return
+	}
+
+	g := &grant{
+		ClientID:            client.ClientID,
+		Username:            identity.Username,
+		SessionID:           identity.SessionID,
+		Scopes:              splitScope(r.Scope),
+		RedirectURI:         r.RedirectURI,
+		Nonce:               r.Nonce,
+		AuthTime:            identity.AuthTime,
+		CodeChallenge:       r.CodeChallenge,
+		CodeChallengeMethod: r.CodeChallengeMethod,
+	}
+
+	authCode, err := p.grants.issue(c, p.grants.codes, g, p.cfg.CodeTimeout)
+	if err != nil {
+		log.L(c).Errorf("save authorization code failed: %s", err.Error())
+		redirectError(c, redirectURI, r.State, newError(http.StatusInternalServerError, errServerError, ""))
+
+		return
+	}
+
+	q := url.Values{}
+	q.Set("code", authCode)
+
+	if r.State != "" {
+		q.Set("state", r.State)
+	}
+
+	c.Redirect(http.StatusFound, appendQuery(redirectURI, q))
+}
+
+func (p *Provider) validateAuthorizeRequest(client *modelv1.OAuthClient, r *authorizeRequest) *Error {
+	if r.ResponseType != "code" {
+		return newError(http.StatusBadRequest, errUnsupportedResponseType, "only response_type=code is supported")
+	}
+
+	if !client.HasGrantType(modelv1.GrantTypeAuthorizationCode) {
+		return newError(http.StatusBadRequest, errUnauthorizedClient, "client can not use the authorization_code grant")
+	}
+
+	for _, scope := range splitScope(r.Scope) {
+		if !client.AllowScope(scope) {
+			return newError(http.StatusBadRequest, errInvalidScope, "scope `"+scope+"` is not allowed")
+		}
+	}
+
+	if r.CodeChallenge == "" {
+		if client.Public {
+			return newError(http.StatusBadRequest, errInvalidRequest, "public clients must use PKCE")
+		}
+
+		return nil
+	}
+
+	if r.CodeChallengeMethod == "" {
+		r.CodeChallengeMethod = codeChallengePlain
+	}
+
+	if r.CodeChallengeMethod != codeChallengeS256 && r.CodeChallengeMethod != codeChallengePlain {
+		return newError(http.StatusBadRequest, errInvalidRequest, "unsupported code_challenge_method")
+	}
+
+	if !pkceValueRegexp.MatchString(r.CodeChallenge) {
+		return newError(http.StatusBadRequest, errInvalidRequest, "invalid code_challenge")
+	}
+
+	return nil
+}
+
+// requireLogin 处理未登录的用户，配置了登录页面时跳转到登录页面，登录后再回到授权请求.
+func (p *Provider) requireLogin(c *gin.Context, r *authorizeRequest, redirectURI string) {
+	if r.Prompt == "none" {
+		redirectError(c, redirectURI, r.State, newError(http.StatusUnauthorized, errLoginRequired, ""))
+
+		return
+	}
+
+	if p.cfg.LoginURL == "" {
+		writeError(c, newError(http.StatusUnauthorized, errLoginRequired, "user is not logged in"))
+
+		return
+	}
+
+	q := url.Values{}
+	q.Set("return_to", p.issuer(c)+c.Request.URL.Path+"?"+authorizeQuery(c))
+
+	c.Redirect(http.StatusFound, appendQuery(p.cfg.LoginURL, q))
+	c.Abort()
+}
+
+// authorizeQuery 返回授权请求的参数，POST请求的表单参数转换为查询参数.
+func authorizeQuery(c *gin.Context) string {
+	if c.Request.Method == http.MethodPost {
+		return c.Request.PostForm.Encode()
+	}
+
+	return c.Request.URL.RawQuery
+}
+
+func splitScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RFC 6749定义的错误码.
+const (
+	errInvalidRequest          = "invalid_request"
+	errInvalidClient           = "invalid_client"
+	errInvalidGrant            = "invalid_grant"
+	errUnauthorizedClient      = "unauthorized_client"
+	errUnsupportedGrantType    = "unsupported_grant_type"
+	errUnsupportedResponseType = "unsupported_response_type"
+	errInvalidScope            = "invalid_scope"
+	errServerError             = "server_error"
+	errLoginRequired           = "login_required"
+	errInvalidToken            = "invalid_token"
+	errInsufficientScope       = "insufficient_scope"
+	errUnsupportedTokenType    = "unsupported_token_type"
+)
+
+// Error 是返回给OAuth2客户端的错误，格式由RFC 6749第5.2节定义.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+
+	status int
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newError(status int, code, description string) *Error {
+	return &Error{Code: code, Description: description, status: status}
+}
+
+// writeError 以JSON格式返回错误.
+func writeError(c *gin.Context, err *Error) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.AbortWithStatusJSON(err.status, err)
+}
+
+// redirectError 把错误通过回调地址返回给客户端.
+func redirectError(c *gin.Context, redirectURI, state string, err *Error) {
+	q := url.Values{}
+	q.Set("error", err.Code)
+
+	if err.Description != "" {
+		q.Set("error_description", err.Description)
+	}
+
+	if state != "" {
+		q.Set("state", state)
+	}
+
+	c.Redirect(http.StatusFound, appendQuery(redirectURI, q))
+	c.Abort()
+}
+
+// appendQuery 把参数追加到地址已有的查询参数之后.
+func appendQuery(rawURL string, q url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	values := u.Query()
+	for k, v := range q {
+		values[k] = v
+	}
+
+	u.RawQuery = values.Encode()
+
+	return u.String()
+}
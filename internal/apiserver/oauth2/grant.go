@@ -0,0 +1,115 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+// grant 记录一次授权的内容，保存在授权码和刷新令牌对应的redis key中.
+type grant struct {
+	ClientID    string    `json:"clientID"`
+	Username    string    `json:"username"`
+	SessionID   string    `json:"sessionID"`
+	Scopes      []string  `json:"scopes"`
+	RedirectURI string    `json:"redirectURI,omitempty"`
+	Nonce       string    `json:"nonce,omitempty"`
+	AuthTime    time.Time `json:"authTime"`
+
+	CodeChallenge       string `json:"codeChallenge,omitempty"`
+	CodeChallengeMethod string `json:"codeChallengeMethod,omitempty"`
+}
+
+// grantStore 在redis中保存授权码、刷新令牌和被吊销的access token，授权码和刷新令牌的key是令牌的哈希值.
+type grantStore struct {
+	codes   storage.RedisCluster
+	refresh storage.RedisCluster
+	revoked storage.RedisCluster
+}
+
+func newGrantStore() *grantStore {
+	return &grantStore{
+		codes:   storage.RedisCluster{KeyPrefix: "iam-oauth-code-", HashKey: true},
+		refresh: storage.RedisCluster{KeyPrefix: "iam-oauth-refresh-", HashKey: true},
+		revoked: storage.RedisCluster{KeyPrefix: "iam-oauth-revoked-"},
+	}
+}
+
+// issue 生成一个随机令牌并保存授权内容.
+func (s *grantStore) issue(ctx context.Context, store storage.RedisCluster, g *grant, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	if err := store.SetKey(ctx, token, string(data), ttl); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// lookup 返回授权内容，不删除令牌.
+func (s *grantStore) lookup(ctx context.Context, store storage.RedisCluster, token string) (*grant, error) {
+	data, err := store.GetKey(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var g grant
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// revoke 记录被吊销的access token，记录在token过期后自动删除.
+func (s *grantStore) revoke(ctx context.Context, jti string, expire time.Time) error {
+	ttl := time.Until(expire)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.revoked.SetKey(ctx, jti, "1", ttl)
+}
+
+// isRevoked 判断access token是否已经被吊销. redis不可用时无法确认，按已吊销处理.
+func (s *grantStore) isRevoked(ctx context.Context, jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	revoked, err := s.revoked.Exists(ctx, jti)
+
+	return err != nil || revoked
+}
+
+// consume 取出授权内容并删除令牌，保证令牌只能使用一次. 并发使用同一个令牌时只有一个请求能成功.
+func (s *grantStore) consume(ctx context.Context, store storage.RedisCluster, token string) (*grant, error) {
+	data, err := store.GetKey(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !store.DeleteKey(ctx, token) {
+		return nil, storage.ErrKeyNotFound
+	}
+
+	var g grant
+	if err := json.Unmarshal([]byte(data), &g); err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
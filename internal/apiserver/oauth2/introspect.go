@@ -0,0 +1,254 @@
+package oauth2
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// apiserver签发的令牌种类，在内省响应的token_use字段中返回.
+const (
+	tokenUsePersonal = "personal_access_token"
+	tokenUseSession  = "session"
+	tokenUseID       = "id_token"
+	tokenUseRefresh  = "refresh_token"
+	tokenUseOther    = "other"
+)
+
+// introspection 是令牌内省端点的响应，格式由RFC 7662第2.2节定义.
+type introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	TokenUse  string `json:"token_use,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Nbf       int64  `json:"nbf,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Aud       string `json:"aud,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// tokenInfo 是内省的结果，revoke为nil表示该类令牌不支持吊销.
+type tokenInfo struct {
+	introspection
+
+	revoke func(c *gin.Context) error
+}
+
+// Introspect 返回令牌的状态，支持apiserver签发的所有令牌. 调用方使用密钥对认证，
+// 非管理员的密钥只能查看属于自己的令牌，其他令牌返回active=false.
+func (p *Provider) Introspect(c *gin.Context) {
+	info := p.inspect(c, c.PostForm("token"))
+
+	resp := introspection{Active: false}
+	if info != nil && p.authorized(c, info) {
+		resp = info.introspection
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+// authorized 判断调用方是否可以查看或吊销令牌，管理员可以操作所有令牌.
+func (p *Provider) authorized(c *gin.Context, info *tokenInfo) bool {
+	caller := c.GetString(middleware.UsernameKey)
+	if caller != "" && caller == info.Username {
+		return true
+	}
+
+	user, err := store.Client().Users().Get(c, caller, metav1.GetOptions{})
+
+	return err == nil && user.IsAdmin == 1
+}
+
+// inspect 识别令牌的种类并校验令牌，令牌无效时返回nil. token_type_hint只是提示，这里根据令牌的格式判断种类.
+func (p *Provider) inspect(c *gin.Context, raw string) *tokenInfo {
+	switch {
+	case raw == "":
+		return nil
+	case strings.HasPrefix(raw, srvv1.AccessTokenPrefix):
+		return p.inspectPersonalToken(c, raw)
+	case strings.Count(raw, ".") == 2:
+		return p.inspectJWT(c, raw)
+	default:
+		return p.inspectRefreshToken(c, raw)
+	}
+}
+
+func (p *Provider) inspectPersonalToken(c *gin.Context, raw string) *tokenInfo {
+	srv := srvv1.NewService(store.Client()).AccessTokens()
+
+	token, err := srv.Lookup(c, raw)
+	if err != nil {
+		return nil
+	}
+
+	info := &tokenInfo{
+		introspection: introspection{
+			Active:    true,
+			Scope:     strings.Join(token.Scopes, " "),
+			Username:  token.Username,
+			TokenType: "Bearer",
+			TokenUse:  tokenUsePersonal,
+			Iat:       token.CreatedAt.Unix(),
+			Sub:       token.Username,
+			Iss:       p.issuer(c),
+		},
+		revoke: func(c *gin.Context) error {
+			return srv.Delete(c, token.Username, token.Name, metav1.DeleteOptions{Unscoped: true})
+		},
+	}
+
+	if token.ExpiresAt != nil {
+		info.Exp = token.ExpiresAt.Unix()
+	}
+
+	return info
+}
+
+func (p *Provider) inspectJWT(c *gin.Context, raw string) *tokenInfo {
+	claims, err := p.cfg.Signer.ParseToken(raw)
+	if err != nil {
+		return nil
+	}
+
+	info := &tokenInfo{introspection: introspection{
+		Active:    true,
+		Scope:     stringClaim(claims, "scope"),
+		ClientID:  stringClaim(claims, "client_id"),
+		TokenType: "Bearer",
+		Exp:       int64Claim(claims, "exp"),
+		Iat:       int64Claim(claims, "iat"),
+		Nbf:       int64Claim(claims, "nbf"),
+		Sub:       stringClaim(claims, "sub"),
+		Aud:       stringClaim(claims, "aud"),
+		Iss:       stringClaim(claims, "iss"),
+		Jti:       stringClaim(claims, "jti"),
+	}}
+
+	sid := stringClaim(claims, sessionIDKey)
+	username := stringClaim(claims, middleware.UsernameKey)
+
+	switch {
+	case claims[tokenUseKey] == tokenUseAccess:
+		info.TokenUse = tokenUseAccess
+		if info.ClientID != info.Sub {
+			username = info.Sub
+		}
+
+		if p.grants.isRevoked(c, info.Jti) {
+			return nil
+		}
+
+		info.revoke = func(c *gin.Context) error {
+			return p.grants.revoke(c, info.Jti, time.Unix(info.Exp, 0))
+		}
+	case stringClaim(claims, "azp") != "":
+		info.TokenUse = tokenUseID
+		info.ClientID = stringClaim(claims, "azp")
+		info.TokenType = ""
+		username = info.Sub
+	case sid != "":
+		info.TokenUse = tokenUseSession
+		info.revoke = func(c *gin.Context) error {
+			if p.cfg.SessionRevoker == nil {
+				return nil
+			}
+
+			return p.cfg.SessionRevoker(c, username, sid)
+		}
+	default:
+		info.TokenUse = tokenUseOther
+	}
+
+	info.Username = username
+
+	if sid != "" && p.cfg.SessionValidator != nil && p.cfg.SessionValidator(c, username, sid) != nil {
+		return nil
+	}
+
+	return info
+}
+
+func (p *Provider) inspectRefreshToken(c *gin.Context, raw string) *tokenInfo {
+	g, err := p.grants.lookup(c, p.grants.refresh, raw)
+	if err != nil {
+		return nil
+	}
+
+	if p.cfg.SessionValidator != nil && p.cfg.SessionValidator(c, g.Username, g.SessionID) != nil {
+		return nil
+	}
+
+	return &tokenInfo{
+		introspection: introspection{
+			Active:   true,
+			Scope:    strings.Join(g.Scopes, " "),
+			ClientID: g.ClientID,
+			Username: g.Username,
+			TokenUse: tokenUseRefresh,
+			Exp:      g.AuthTime.Add(p.cfg.MaxRefresh).Unix(),
+			Iat:      g.AuthTime.Unix(),
+			Sub:      g.Username,
+			Iss:      p.issuer(c),
+		},
+		revoke: func(c *gin.Context) error {
+			p.grants.refresh.DeleteKey(c, raw)
+
+			return nil
+		},
+	}
+}
+
+// Revoke 吊销令牌，格式由RFC 7009定义. 无效的令牌同样返回成功，调用方使用密钥对认证，
+// 非管理员的密钥只能吊销属于自己的令牌.
+func (p *Provider) Revoke(c *gin.Context) {
+	info := p.inspect(c, c.PostForm("token"))
+	if info == nil {
+		c.Status(http.StatusOK)
+
+		return
+	}
+
+	if !p.authorized(c, info) {
+		writeError(c, newError(http.StatusForbidden, errUnauthorizedClient, "token does not belong to the caller"))
+
+		return
+	}
+
+	if info.revoke == nil {
+		writeError(c, newError(http.StatusBadRequest, errUnsupportedTokenType, "token of type "+info.TokenUse+" can not be revoked"))
+
+		return
+	}
+
+	if err := info.revoke(c); err != nil {
+		log.L(c).Errorf("revoke %s token failed: %s", info.TokenUse, err.Error())
+		writeError(c, newError(http.StatusServiceUnavailable, errServerError, "revoke token failed"))
+
+		return
+	}
+
+	log.L(c).Infof("%s token of %s revoked by %s", info.TokenUse, info.Username, c.GetString(middleware.UsernameKey))
+	c.Status(http.StatusOK)
+}
+
+func int64Claim(claims jwt.MapClaims, key string) int64 {
+	v, _ := claims[key].(float64)
+
+	return int64(v)
+}
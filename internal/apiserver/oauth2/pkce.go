@@ -0,0 +1,31 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+const (
+	codeChallengeS256  = "S256"
+	codeChallengePlain = "plain"
+)
+
+// RFC 7636规定code_verifier和code_challenge由43到128个非保留字符组成.
+var pkceValueRegexp = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// verifyCodeChallenge 校验code_verifier是否和授权请求中的code_challenge匹配.
+func verifyCodeChallenge(verifier, challenge, method string) bool {
+	if !pkceValueRegexp.MatchString(verifier) {
+		return false
+	}
+
+	expected := verifier
+	if method == codeChallengeS256 {
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
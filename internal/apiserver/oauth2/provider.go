@@ -0,0 +1,123 @@
+// Package oauth2 实现了OAuth2授权服务器和OpenID Connect提供者，支持授权码(PKCE)、客户端凭证和刷新令牌三种授权方式.
+package oauth2
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware/auth"
+)
+
+const (
+	// ScopeOpenID 申请ID token需要的scope.
+	ScopeOpenID = "openid"
+	// ScopeProfile 允许获取用户昵称等基本信息.
+	ScopeProfile = "profile"
+	// ScopeEmail 允许获取用户邮箱.
+	ScopeEmail = "email"
+	// ScopePhone 允许获取用户手机号.
+	ScopePhone = "phone"
+
+	// 区分access token和ID token的claim，两者使用相同的密钥签名.
+	tokenUseKey    = "token_use"
+	tokenUseAccess = "access"
+
+	// 保存会话ID的claim名称，和apiserver签发的jwt保持一致.
+	sessionIDKey = "sid"
+)
+
+// Identity 表示在授权端点完成登录的用户.
+type Identity struct {
+	Username  string
+	SessionID string
+	AuthTime  time.Time
+}
+
+// Config 定义了OAuth2提供者的配置.
+type Config struct {
+	// Issuer 为空时根据请求的scheme和host生成.
+	Issuer string
+	// LoginURL 未登录用户访问授权端点时跳转的登录页面，为空时直接返回401.
+	LoginURL string
+
+	CodeTimeout        time.Duration
+	AccessTokenTimeout time.Duration
+	IDTokenTimeout     time.Duration
+	// MaxRefresh 从用户登录开始，刷新令牌可以使用的最长时间.
+	MaxRefresh time.Duration
+
+	// Signer 用于签发和校验access token和ID token.
+	Signer auth.JWTStrategy
+	// Authenticator 返回授权请求中已经登录的用户.
+	Authenticator func(c *gin.Context) (*Identity, error)
+	// SessionValidator 校验用户的登录会话没有被吊销，会话吊销后刷新令牌和access token随之失效.
+	SessionValidator func(c *gin.Context, username, sid string) error
+	// SessionRevoker 吊销用户的登录会话，用于吊销登录时签发的jwt.
+	SessionRevoker func(c *gin.Context, username, sid string) error
+}
+
+// Provider 处理OAuth2和OpenID Connect相关的请求.
+type Provider struct {
+	cfg    Config
+	grants *grantStore
+}
+
+// NewProvider 创建OAuth2提供者.
+func NewProvider(cfg Config) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		grants: newGrantStore(),
+	}
+}
+
+// issuer 返回签发者地址.
+func (p *Provider) issuer(c *gin.Context) string {
+	if p.cfg.Issuer != "" {
+		return strings.TrimSuffix(p.cfg.Issuer, "/")
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return scheme + "://" + c.Request.Host
+}
+
+// Discovery 返回OpenID Connect的提供者元数据.
+func (p *Provider) Discovery(c *gin.Context) {
+	issuer := p.issuer(c)
+
+	algorithms := []string{p.cfg.Signer.SigningAlgorithm}
+	if p.cfg.Signer.KeySet != nil {
+		algorithms = p.cfg.Signer.KeySet.Algorithms()
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oauth2/authorize",
+		"token_endpoint":                        issuer + "/oauth2/token",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"response_modes_supported":              []string{"query"},
+		"grant_types_supported":                 []string{"authorization_code", "client_credentials", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": algorithms,
+		"scopes_supported":                      []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{codeChallengeS256, codeChallengePlain},
+		"claims_supported": []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "azp",
+			"name", "nickname", "preferred_username", "email", "email_verified", "phone_number", "updated_at",
+		},
+	})
+}
@@ -0,0 +1,313 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	uuid "github.com/satori/go.uuid"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+// tokenResponse 是令牌端点的响应，格式由RFC 6749第5.1节定义.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Token 处理令牌请求，支持authorization_code、client_credentials和refresh_token三种授权方式.
+func (p *Provider) Token(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		writeError(c, newError(http.StatusBadRequest, errInvalidRequest, err.Error()))
+
+		return
+	}
+
+	client, oerr := p.authenticateClient(c)
+	if oerr != nil {
+		writeError(c, oerr)
+
+		return
+	}
+
+	grantType := c.PostForm("grant_type")
+	switch grantType {
+	case modelv1.GrantTypeAuthorizationCode, modelv1.GrantTypeClientCredentials, modelv1.GrantTypeRefreshToken:
+	default:
+		writeError(c, newError(http.StatusBadRequest, errUnsupportedGrantType, "unsupported grant_type"))
+
+		return
+	}
+
+	if !client.HasGrantType(grantType) {
+		writeError(c, newError(http.StatusBadRequest, errUnauthorizedClient,
+			"client can not use the "+grantType+" grant"))
+
+		return
+	}
+
+	var (
+		resp *tokenResponse
+		err  error
+	)
+
+	switch grantType {
+	case modelv1.GrantTypeAuthorizationCode:
+		resp, err = p.exchangeCode(c, client)
+	case modelv1.GrantTypeClientCredentials:
+		resp, err = p.clientCredentials(c, client)
+	case modelv1.GrantTypeRefreshToken:
+		resp, err = p.refresh(c, client)
+	}
+
+	if err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			log.L(c).Errorf("issue token to client %s failed: %s", client.ClientID, err.Error())
+			e = newError(http.StatusInternalServerError, errServerError, "")
+		}
+
+		writeError(c, e)
+
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+// authenticateClient 支持client_secret_basic、client_secret_post和公开客户端(none)三种认证方式.
+func (p *Provider) authenticateClient(c *gin.Context) (*modelv1.OAuthClient, *Error) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// RFC 6749第2.3.1节要求先对client_id和client_secret做表单编码
+		var err1, err2 error
+		clientID, err1 = url.QueryUnescape(clientID)
+		secret, err2 = url.QueryUnescape(secret)
+
+		if err1 != nil || err2 != nil {
+			return nil, newError(http.StatusBadRequest, errInvalidRequest, "malformed client credentials")
+		}
+	} else {
+		clientID = c.PostForm("client_id")
+		secret = c.PostForm("client_secret")
+	}
+
+	if clientID == "" {
+		return nil, newError(http.StatusUnauthorized, errInvalidClient, "client authentication is required")
+	}
+
+	client, err := srvv1.NewService(store.Client()).OAuthClients().Authenticate(c, clientID, secret)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrOAuthClientInvalid) {
+			log.L(c).Errorf("authenticate oauth client %s failed: %s", clientID, err.Error())
+
+			return nil, newError(http.StatusInternalServerError, errServerError, "")
+		}
+
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+
+		return nil, newError(http.StatusUnauthorized, errInvalidClient, "client authentication failed")
+	}
+
+	return client, nil
+}
+
+func (p *Provider) exchangeCode(c *gin.Context, client *modelv1.OAuthClient) (*tokenResponse, error) {
+	g, err := p.grants.consume(c, p.grants.codes, c.PostForm("code"))
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, newError(http.StatusBadRequest, errInvalidGrant, "authorization code is invalid or expired")
+		}
+
+		return nil, err
+	}
+
+	if g.ClientID != client.ClientID || g.RedirectURI != c.PostForm("redirect_uri") {
+		return nil, newError(http.StatusBadRequest, errInvalidGrant, "authorization code was issued to another client")
+	}
+
+	verifier := c.PostForm("code_verifier")
+	if g.CodeChallenge != "" || verifier != "" {
+		if !verifyCodeChallenge(verifier, g.CodeChallenge, g.CodeChallengeMethod) {
+			return nil, newError(http.StatusBadRequest, errInvalidGrant, "code_verifier does not match")
+		}
+	}
+
+	return p.issueUserTokens(c, client, g)
+}
+
+func (p *Provider) clientCredentials(c *gin.Context, client *modelv1.OAuthClient) (*tokenResponse, error) {
+	scopes := splitScope(c.PostForm("scope"))
+	for _, scope := range scopes {
+		if scope == ScopeOpenID || !client.AllowScope(scope) {
+			return nil, newError(http.StatusBadRequest, errInvalidScope, "scope `"+scope+"` is not allowed")
+		}
+	}
+
+	accessToken, expire, err := p.signAccessToken(c, client.ClientID, client.ClientID, "", scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(expire).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// refresh 使用刷新令牌签发新的令牌，旧的刷新令牌随即失效. 新的刷新令牌不会延长授权的最长刷新时间.
+func (p *Provider) refresh(c *gin.Context, client *modelv1.OAuthClient) (*tokenResponse, error) {
+	g, err := p.grants.consume(c, p.grants.refresh, c.PostForm("refresh_token"))
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, newError(http.StatusBadRequest, errInvalidGrant, "refresh token is invalid or expired")
+		}
+
+		return nil, err
+	}
+
+	if g.ClientID != client.ClientID {
+		return nil, newError(http.StatusBadRequest, errInvalidGrant, "refresh token was issued to another client")
+	}
+
+	// 可以申请比原来更小的scope
+	if requested := splitScope(c.PostForm("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !hasScope(g.Scopes, scope) {
+				return nil, newError(http.StatusBadRequest, errInvalidScope, "scope `"+scope+"` was not granted")
+			}
+		}
+
+		g.Scopes = requested
+	}
+
+	g.Nonce = ""
+
+	return p.issueUserTokens(c, client, g)
+}
+
+// issueUserTokens 为用户签发access token，申请了openid时签发ID token，客户端允许时签发刷新令牌.
+func (p *Provider) issueUserTokens(c *gin.Context, client *modelv1.OAuthClient, g *grant) (*tokenResponse, error) {
+	if p.cfg.SessionValidator != nil {
+		if err := p.cfg.SessionValidator(c, g.Username, g.SessionID); err != nil {
+			return nil, newError(http.StatusBadRequest, errInvalidGrant, "login session has been revoked")
+		}
+	}
+
+	user, err := store.Client().Users().Get(c, g.Username, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, newError(http.StatusBadRequest, errInvalidGrant, "user does not exist")
+		}
+
+		return nil, err
+	}
+
+	accessToken, expire, err := p.signAccessToken(c, client.ClientID, user.Name, g.SessionID, g.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(expire).Seconds()),
+		Scope:       strings.Join(g.Scopes, " "),
+	}
+
+	if hasScope(g.Scopes, ScopeOpenID) {
+		resp.IDToken, err = p.signIDToken(c, client.ClientID, user, g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 刷新令牌的有效期不超过用户登录时间加上MaxRefresh
+	if ttl := time.Until(g.AuthTime.Add(p.cfg.MaxRefresh)); client.HasGrantType(modelv1.GrantTypeRefreshToken) && ttl > 0 {
+		resp.RefreshToken, err = p.grants.issue(c, p.grants.refresh, g, ttl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (p *Provider) signAccessToken(c *gin.Context, clientID, subject, sid string, scopes []string) (string, time.Time, error) {
+	claims := jwt.MapClaims{
+		"iss":       p.issuer(c),
+		"sub":       subject,
+		"aud":       clientID,
+		"client_id": clientID,
+		"scope":     strings.Join(scopes, " "),
+		"jti":       uuid.Must(uuid.NewV4()).String(),
+		tokenUseKey: tokenUseAccess,
+	}
+
+	if sid != "" {
+		claims[sessionIDKey] = sid
+	}
+
+	return p.cfg.Signer.SignClaims(claims, time.Now().Add(p.cfg.AccessTokenTimeout))
+}
+
+func (p *Provider) signIDToken(c *gin.Context, clientID string, user *v1.User, g *grant) (string, error) {
+	claims := userClaims(user, g.Scopes)
+	claims["iss"] = p.issuer(c)
+	claims["aud"] = clientID
+	claims["azp"] = clientID
+	claims["auth_time"] = g.AuthTime.Unix()
+
+	if g.Nonce != "" {
+		claims["nonce"] = g.Nonce
+	}
+
+	token, _, err := p.cfg.Signer.SignClaims(claims, time.Now().Add(p.cfg.IDTokenTimeout))
+
+	return token, err
+}
+
+// userClaims 根据scope返回用户信息相关的claims.
+func userClaims(user *v1.User, scopes []string) jwt.MapClaims {
+	claims := jwt.MapClaims{"sub": user.Name}
+
+	if hasScope(scopes, ScopeProfile) {
+		claims["name"] = user.Nickname
+		claims["nickname"] = user.Nickname
+		claims["preferred_username"] = user.Name
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+
+	if hasScope(scopes, ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = false
+	}
+
+	if hasScope(scopes, ScopePhone) && user.Phone != "" {
+		claims["phone_number"] = user.Phone
+	}
+
+	return claims
+}
@@ -0,0 +1,81 @@
+package oauth2
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// UserInfo 返回access token对应用户的信息，返回的字段由token的scope决定.
+func (p *Provider) UserInfo(c *gin.Context) {
+	header := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(header) != 2 || !strings.EqualFold(header[0], "Bearer") {
+		c.Header("WWW-Authenticate", `Bearer realm="userinfo"`)
+		writeError(c, newError(http.StatusUnauthorized, errInvalidRequest, "missing bearer token"))
+
+		return
+	}
+
+	claims, err := p.cfg.Signer.ParseToken(header[1])
+	if err != nil || claims[tokenUseKey] != tokenUseAccess || p.grants.isRevoked(c, stringClaim(claims, "jti")) {
+		p.invalidToken(c, errInvalidToken, "access token is invalid or expired")
+
+		return
+	}
+
+	scopes := splitScope(stringClaim(claims, "scope"))
+	if !hasScope(scopes, ScopeOpenID) {
+		p.invalidToken(c, errInsufficientScope, "access token does not have the openid scope")
+
+		return
+	}
+
+	username := stringClaim(claims, "sub")
+	if p.cfg.SessionValidator != nil {
+		if err := p.cfg.SessionValidator(c, username, stringClaim(claims, sessionIDKey)); err != nil {
+			p.invalidToken(c, errInvalidToken, "login session has been revoked")
+
+			return
+		}
+	}
+
+	user, err := store.Client().Users().Get(c, username, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			p.invalidToken(c, errInvalidToken, "user does not exist")
+
+			return
+		}
+
+		log.L(c).Errorf("get user %s failed: %s", username, err.Error())
+		writeError(c, newError(http.StatusInternalServerError, errServerError, ""))
+
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, userClaims(user, scopes))
+}
+
+func (p *Provider) invalidToken(c *gin.Context, errCode, description string) {
+	status := http.StatusUnauthorized
+	if errCode == errInsufficientScope {
+		status = http.StatusForbidden
+	}
+
+	c.Header("WWW-Authenticate", `Bearer realm="userinfo", error="`+errCode+`"`)
+	writeError(c, newError(status, errCode, description))
+}
+
+func stringClaim(claims map[string]interface{}, key string) string {
+	v, _ := claims[key].(string)
+
+	return v
+}
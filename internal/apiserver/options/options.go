@@ -12,15 +12,23 @@ import (
 
 // Options 用于运行一个iam api服务
 type Options struct {
-	GenericServerRunOptions *genericoptions.ServerRunOptions       `json:"server"   mapstructure:"server"`
-	GRPCOptions             *genericoptions.GRPCOptions            `json:"grpc"     mapstructure:"grpc"`
-	InsecureServing         *genericoptions.InsecureServingOptions `json:"insecure" mapstructure:"insecure"`
-	SecureServing           *genericoptions.SecureServingOptions   `json:"secure"   mapstructure:"secure"`
-	MySQLOptions            *genericoptions.MySQLOptions           `json:"mysql"    mapstructure:"mysql"`
-	RedisOptions            *genericoptions.RedisOptions           `json:"redis"    mapstructure:"redis"`
-	JwtOptions              *genericoptions.JWTOptions             `json:"jwt"      mapstructure:"jwt"`
-	Log                     *log.Options                           `json:"log"      mapstructure:"log"`
-	FeatureOptions          *genericoptions.FeatureOptions         `json:"feature"  mapstructure:"feature"`
+	GenericServerRunOptions *genericoptions.ServerRunOptions       `json:"server"       mapstructure:"server"`
+	GRPCOptions             *genericoptions.GRPCOptions            `json:"grpc"         mapstructure:"grpc"`
+	InsecureServing         *genericoptions.InsecureServingOptions `json:"insecure"     mapstructure:"insecure"`
+	SecureServing           *genericoptions.SecureServingOptions   `json:"secure"       mapstructure:"secure"`
+	MySQLOptions            *genericoptions.MySQLOptions           `json:"mysql"        mapstructure:"mysql"`
+	RedisOptions            *genericoptions.RedisOptions           `json:"redis"        mapstructure:"redis"`
+	JwtOptions              *genericoptions.JWTOptions             `json:"jwt"          mapstructure:"jwt"`
+	PasswordOptions         *genericoptions.PasswordOptions        `json:"password"     mapstructure:"password"`
+	MFAOptions              *genericoptions.MFAOptions             `json:"mfa"          mapstructure:"mfa"`
+	OAuthOptions            *genericoptions.OAuthOptions           `json:"oauth"        mapstructure:"oauth"`
+	LDAPOptions             *genericoptions.LDAPOptions            `json:"ldap"         mapstructure:"ldap"`
+	NotificationOptions     *genericoptions.NotificationOptions    `json:"notification" mapstructure:"notification"`
+	TracingOptions          *genericoptions.TracingOptions         `json:"tracing"      mapstructure:"tracing"`
+	AuditOptions            *genericoptions.AuditOptions           `json:"audit"        mapstructure:"audit"`
+	DumpOptions             *genericoptions.DumpOptions            `json:"dump"         mapstructure:"dump"`
+	Log                     *log.Options                           `json:"log"          mapstructure:"log"`
+	FeatureOptions          *genericoptions.FeatureOptions         `json:"feature"      mapstructure:"feature"`
 }
 
 // NewOptions 创建一个带有默认值的Options对象.
@@ -33,6 +41,14 @@ func NewOptions() *Options {
 		MySQLOptions:            genericoptions.NewMySQLOptions(),
 		RedisOptions:            genericoptions.NewRedisOptions(),
 		JwtOptions:              genericoptions.NewJWTOptions(),
+		PasswordOptions:         genericoptions.NewPasswordOptions(),
+		MFAOptions:              genericoptions.NewMFAOptions(),
+		OAuthOptions:            genericoptions.NewOAuthOptions(),
+		LDAPOptions:             genericoptions.NewLDAPOptions(),
+		NotificationOptions:     genericoptions.NewNotificationOptions(),
+		TracingOptions:          genericoptions.NewTracingOptions(),
+		AuditOptions:            genericoptions.NewAuditOptions(),
+		DumpOptions:             genericoptions.NewDumpOptions(),
 		Log:                     log.NewOptions(),
 		FeatureOptions:          genericoptions.NewFeatureOptions(),
 	}
@@ -47,6 +63,14 @@ func (o *Options) Flags() (fss cliflag.NamedFlagSets) {
 	o.MySQLOptions.AddFlags(fss.FlagSet("mysql"))
 	o.RedisOptions.AddFlags(fss.FlagSet("redis"))
 	o.JwtOptions.AddFlags(fss.FlagSet("jwt"))
+	o.PasswordOptions.AddFlags(fss.FlagSet("password"))
+	o.MFAOptions.AddFlags(fss.FlagSet("mfa"))
+	o.OAuthOptions.AddFlags(fss.FlagSet("oauth"))
+	o.LDAPOptions.AddFlags(fss.FlagSet("ldap"))
+	o.NotificationOptions.AddFlags(fss.FlagSet("notification"))
+	o.TracingOptions.AddFlags(fss.FlagSet("tracing"))
+	o.AuditOptions.AddFlags(fss.FlagSet("audit"))
+	o.DumpOptions.AddFlags(fss.FlagSet("dump"))
 	o.Log.AddFlags(fss.FlagSet("logs"))
 	o.FeatureOptions.AddFlags(fss.FlagSet("features"))
 
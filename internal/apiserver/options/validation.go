@@ -11,6 +11,14 @@ func (o *Options) Validate() []error {
 	errs = append(errs, o.MySQLOptions.Validate()...)
 	errs = append(errs, o.RedisOptions.Validate()...)
 	errs = append(errs, o.JwtOptions.Validate()...)
+	errs = append(errs, o.PasswordOptions.Validate()...)
+	errs = append(errs, o.MFAOptions.Validate()...)
+	errs = append(errs, o.OAuthOptions.Validate()...)
+	errs = append(errs, o.LDAPOptions.Validate()...)
+	errs = append(errs, o.NotificationOptions.Validate()...)
+	errs = append(errs, o.TracingOptions.Validate()...)
+	errs = append(errs, o.AuditOptions.Validate()...)
+	errs = append(errs, o.DumpOptions.Validate()...)
 	errs = append(errs, o.Log.Validate()...)
 	errs = append(errs, o.FeatureOptions.Validate()...)
 
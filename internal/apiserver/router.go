@@ -1,17 +1,177 @@
 package apiserver
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
 
-func initRouter(g *gin.Engine) {
-	installMiddlewares(g) // 安装需要的中间件
-	installController(g)  // 安装控制器
+	"github.com/cuizhaoyue/iams/internal/apiserver/config"
+	auditctl "github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/audit"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/mfa"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/oauthclient"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/policy"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/secret"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/session"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/token"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/user"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store/mysql"
+	"github.com/cuizhaoyue/iams/internal/pkg/audit"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+func initRouter(g *gin.Engine, cfg *config.Config, auditor *audit.Auditor) {
+	installMiddlewares(g, cfg, auditor) // 安装需要的中间件
+	installController(g, cfg, auditor)  // 安装控制器
 }
 
-func installMiddlewares(g *gin.Engine) {
+func installMiddlewares(g *gin.Engine, cfg *config.Config, auditor *audit.Auditor) {
+	// 记录所有修改资源的请求
+	if auditor != nil {
+		g.Use(audit.Middleware(auditor, cfg.AuditOptions.ToMiddlewareConfig()))
+	}
 }
 
-func installController(g *gin.Engine) *gin.Engine {
-	// TODO: 执行安装中间件的操作
+func installController(g *gin.Engine, cfg *config.Config, auditor *audit.Auditor) *gin.Engine {
+	storeIns, _ := mysql.GetMySQLFactoryOr(nil)
+
+	validation := middleware.Validation(adminChecker(storeIns))
+
+	// 认证之后读取资源修改前的状态，审计记录中保存修改前后的差异
+	snapshot := func(c *gin.Context) { c.Next() }
+	if auditor != nil {
+		snapshot = audit.Snapshot(auditLoaders(storeIns))
+	}
+
+	// 登录、退出登录和刷新token的路由
+	preAuth := newPreAuthJWT(cfg)
+	jwtStrategy := newJWTAuth(cfg)
+	jwtStrategy.Challenger = mfaChallenger(preAuth)
+	g.POST("/login", jwtStrategy.LoginHandler)
+	g.POST("/logout", logoutHandler(jwtStrategy))
+	// 刷新时间可以比token的有效时间长
+	g.POST("/refresh", jwtStrategy.RefreshHandler)
+
+	// 开启MFA的用户登录时，使用预认证token完成MFA登记和验证
+	mfaController := mfa.NewMFAController(storeIns)
+	mfaLogin := g.Group("/login/mfa", preAuth.AuthFunc())
+	{
+		mfaLogin.POST("/enroll", mfaController.Enroll)
+		mfaLogin.POST("/verify", mfaLoginHandler(jwtStrategy))
+	}
+
+	// 发布校验jwt签名使用的公钥
+	g.GET("/.well-known/jwks.json", jwksHandler(cfg.JWTKeys))
+
+	// OAuth2/OIDC提供者
+	oauthProvider := newOAuthProvider(cfg, jwtStrategy)
+	g.GET("/.well-known/openid-configuration", oauthProvider.Discovery)
+	g.GET("/oauth2/authorize", oauthProvider.Authorize)
+	g.POST("/oauth2/authorize", oauthProvider.Authorize)
+	g.POST("/oauth2/token", oauthProvider.Token)
+	g.GET("/userinfo", oauthProvider.UserInfo)
+	g.POST("/userinfo", oauthProvider.UserInfo)
+
+	// 令牌内省和吊销，供其他服务使用密钥对调用
+	secretAuth := newSecretAuth()
+	g.POST("/v1/token/introspect", secretAuth.AuthFunc(), oauthProvider.Introspect)
+	g.POST("/v1/token/revoke", secretAuth.AuthFunc(), oauthProvider.Revoke)
+
+	auto := newAutoAuth(cfg)
+	g.NoRoute(auto.AuthFunc(), func(c *gin.Context) {
+		core.WriteResponse(c, errors.WithCode(code.ErrPageNotFound, "Page not found."), nil)
+	})
+
+	// v1版本的路由，需要认证
+	v1 := g.Group("/v1")
+	{
+		// user RESTful资源
+		userv1 := v1.Group("/users")
+		{
+			userController := user.NewUserController(storeIns)
+
+			userv1.POST("", userController.Create)
+			userv1.Use(auto.AuthFunc(), validation, snapshot)
+			userv1.DELETE("", userController.DeleteCollection) // admin api
+			userv1.DELETE(":name", userController.Delete)      // admin api
+			userv1.PUT(":name/change-password", userController.ChangePassword)
+			userv1.PUT(":name", userController.Update)
+			userv1.GET("", userController.List)
+			userv1.GET(":name", userController.Get) // admin api
+
+			// 用户的多因素认证
+			userv1.GET(":name/mfa", mfaController.Get)
+			userv1.POST(":name/mfa", mfaController.Enroll)
+			userv1.DELETE(":name/mfa", mfaController.Disable)
+			userv1.POST(":name/mfa/activate", mfaController.Activate)
+			userv1.POST(":name/mfa/recovery-codes", mfaController.RegenerateRecoveryCodes)
+			userv1.PUT(":name/mfa/required", mfaController.SetRequired) // admin api
+
+			// 用户的登录会话
+			sessionController := session.NewSessionController()
+			userv1.GET(":name/sessions", sessionController.List)
+			userv1.DELETE(":name/sessions", sessionController.DeleteCollection)
+			userv1.DELETE(":name/sessions/:sid", sessionController.Delete)
+		}
+
+		v1.Use(auto.AuthFunc(), snapshot)
+
+		// policy RESTful资源
+		policyv1 := v1.Group("/policies")
+		{
+			policyController := policy.NewPolicyController(storeIns)
+
+			policyv1.POST("", policyController.Create)
+			policyv1.DELETE("", policyController.DeleteCollection)
+			policyv1.DELETE(":name", policyController.Delete)
+			policyv1.PUT(":name", policyController.Update)
+			policyv1.GET("", policyController.List)
+			policyv1.GET(":name", policyController.Get)
+		}
+
+		// secret RESTful资源
+		secretv1 := v1.Group("/secrets")
+		{
+			secretController := secret.NewSecretController(storeIns)
+
+			secretv1.POST("", secretController.Create)
+			secretv1.DELETE(":name", secretController.Delete)
+			secretv1.PUT(":name", secretController.Update)
+			secretv1.GET("", secretController.List)
+			secretv1.GET(":name", secretController.Get)
+		}
+
+		// 个人访问令牌
+		tokenv1 := v1.Group("/tokens")
+		{
+			tokenController := token.NewTokenController(storeIns)
+
+			tokenv1.POST("", tokenController.Create)
+			tokenv1.DELETE(":name", tokenController.Delete)
+			tokenv1.GET("", tokenController.List)
+			tokenv1.GET(":name", tokenController.Get)
+		}
+
+		// OAuth2客户端注册，只有管理员可以操作
+		oauthClientv1 := v1.Group("/oauth2/clients", validation)
+		{
+			oauthClientController := oauthclient.NewOAuthClientController(storeIns)
+
+			oauthClientv1.POST("", oauthClientController.Create)
+			oauthClientv1.DELETE(":name", oauthClientController.Delete)
+			oauthClientv1.POST(":name/secret", oauthClientController.ResetSecret)
+			oauthClientv1.GET("", oauthClientController.List)
+			oauthClientv1.GET(":name", oauthClientController.Get)
+		}
+
+		// 审计记录，只有管理员可以查询
+		auditv1 := v1.Group("/audit", validation)
+		{
+			auditController := auditctl.NewAuditController(storeIns)
+
+			auditv1.GET("", auditController.List)
+		}
+	}
 
 	return g
 }
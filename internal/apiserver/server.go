@@ -2,12 +2,28 @@ package apiserver
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/marmotedu/errors"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+
+	apiv1 "github.com/cuizhaoyue/iams/api/proto/apiserver/v1"
 	pb "github.com/marmotedu/api/proto/apiserver/v1"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/cache"
+	"github.com/cuizhaoyue/iams/internal/apiserver/controller/v1/rpc"
+	srvv1 "github.com/cuizhaoyue/iams/internal/apiserver/service/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/session"
 
 	"github.com/cuizhaoyue/iams/internal/apiserver/store"
 	"github.com/cuizhaoyue/iams/internal/apiserver/store/mysql"
@@ -16,23 +32,34 @@ import (
 
 	"google.golang.org/grpc"
 
+	"github.com/cuizhaoyue/iams/pkg/certreloader"
+	"github.com/cuizhaoyue/iams/pkg/ldap"
 	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/password"
 
 	"google.golang.org/grpc/credentials"
 
 	"github.com/cuizhaoyue/iams/internal/apiserver/config"
+	"github.com/cuizhaoyue/iams/internal/pkg/audit"
+	"github.com/cuizhaoyue/iams/internal/pkg/interceptor"
 	genericoptions "github.com/cuizhaoyue/iams/internal/pkg/options"
 	genericapiserver "github.com/cuizhaoyue/iams/internal/pkg/server"
 	"github.com/cuizhaoyue/iams/pkg/shutdown"
 	"github.com/cuizhaoyue/iams/pkg/shutdown/shutdownmanagers/posixsignal"
+	"github.com/cuizhaoyue/iams/pkg/tracing"
 )
 
+// 关闭服务时关闭单个存储连接的最长时间.
+const storageCloseTimeout = 5 * time.Second
+
 // apiserver应用配置
 type apiServer struct {
 	genericAPIServer *genericapiserver.GenericAPIServer // 通用api服务
 	gRPCAPIServer    *grpcAPIServer                     // grpc服务
 	gs               *shutdown.GracefuleShutdown        // 负责服务优雅关闭
 	redisOptions     *genericoptions.RedisOptions       // redis配置选项
+	auditor          *audit.Auditor                     // 审计日志，未启用时为nil
+	cfg              *config.Config                     // apiserver应用配置
 }
 
 // 准备好的apiserver服务
@@ -43,7 +70,79 @@ type preparedAPIServer struct {
 func createAPIServer(cfg *config.Config) (*apiServer, error) {
 	// 设置优雅关停
 	gs := shutdown.New()
-	gs.AddShutdownManager(posixsignal.NewPosixSignalManager())
+	gs.AddShutdownManager(posixsignal.NewPosixSignalManager().EnableForceExit())
+	gs.SetTimeout(cfg.GenericServerRunOptions.ShutdownTimeout)
+	gs.SetErrorHandler(shutdown.ErrorFunc(func(err error) {
+		log.Errorf("shutdown: %s", err.Error())
+	}))
+	gs.SetCallbackObserver(observeShutdown)
+
+	// 初始化链路追踪，服务关闭的最后阶段导出剩余的span
+	if cfg.TracingOptions.Enabled {
+		shutdownTracing, err := tracing.Init(context.Background(), cfg.TracingOptions.ToTracingOptions())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to init tracing")
+		}
+
+		gs.AddPhaseCallback(shutdown.PhaseCloseStorage, "tracing", storageCloseTimeout,
+			shutdown.ShutdownFunc(func(string) error {
+				ctx, cancel := context.WithTimeout(context.Background(), storageCloseTimeout)
+				defer cancel()
+
+				return shutdownTracing(ctx)
+			}))
+	}
+
+	// 初始化密码哈希算法
+	if err := password.Init(cfg.PasswordOptions.ToPasswordOptions()); err != nil {
+		return nil, err
+	}
+
+	// 会话在token的最长刷新时间过后失效
+	session.SetDefault(session.NewRegistry(cfg.JwtOptions.Timeout + cfg.JwtOptions.MaxRefresh))
+
+	srvv1.SetMFAConfig(srvv1.MFAConfig{
+		Issuer:            cfg.MFAOptions.Issuer,
+		Skew:              cfg.MFAOptions.Skew,
+		RecoveryCodeCount: cfg.MFAOptions.RecoveryCodeCount,
+		MaxAttempts:       cfg.MFAOptions.MaxAttempts,
+		LockoutDuration:   cfg.MFAOptions.LockoutDuration,
+	})
+
+	// secret和policy变更时通过redis通知其他服务
+	stopNotifications := srvv1.StartNotifications(srvv1.NotificationConfig{
+		Enabled:       cfg.NotificationOptions.Enabled,
+		Channel:       cfg.NotificationOptions.Channel,
+		MaxRetries:    cfg.NotificationOptions.MaxRetries,
+		RetryInterval: cfg.NotificationOptions.RetryInterval,
+		QueueSize:     cfg.NotificationOptions.QueueSize,
+	})
+	gs.AddPhaseCallback(shutdown.PhaseStopBackground, "notification", storageCloseTimeout,
+		shutdown.ShutdownFunc(func(string) error {
+			stopNotifications()
+
+			return nil
+		}))
+
+	// 启用LDAP认证
+	if cfg.LDAPOptions.Enabled() {
+		ldapConfig, err := cfg.LDAPOptions.ToLDAPConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := ldap.New(ldapConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		srvv1.SetLDAP(client)
+	}
+
+	// 生成自签名证书需要在加载证书之前完成
+	if err := ensureServingCert(&cfg.SecureServing.ServerCert); err != nil {
+		return nil, err
+	}
 
 	genericConfig, err := buildGenericConfig(cfg)
 	if err != nil {
@@ -61,16 +160,26 @@ func createAPIServer(cfg *config.Config) (*apiServer, error) {
 		return nil, err
 	}
 
+	extraConfig.certificates = genericServer.SecureServingInfo.Certificates
+
 	extraServer, err := extraConfig.complete().New()
 	if err != nil {
 		return nil, err
 	}
 
+	// 审计日志的数据库sink依赖创建grpc服务时初始化的mysql连接
+	auditor, err := newAuditor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &apiServer{
 		genericAPIServer: genericServer,
 		gRPCAPIServer:    extraServer,
 		gs:               gs,
 		redisOptions:     cfg.RedisOptions,
+		auditor:          auditor,
+		cfg:              cfg,
 	}
 
 	return server, nil
@@ -79,21 +188,52 @@ func createAPIServer(cfg *config.Config) (*apiServer, error) {
 // PrepareRun 执行准备工作，包含初始化操作，如数据库初始化、安装业务相关的gin中间件、安装restful路由.
 func (s *apiServer) PrepareRun() preparedAPIServer {
 	// 初始化路由
-	initRouter(s.genericAPIServer.Engine)
+	initRouter(s.genericAPIServer.Engine, s.cfg, s.auditor)
 
 	// 初始化redis服务
 	s.initRedisStore()
 
-	// 添加服务结束时的关闭操作.
-	s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(string) error {
+	// 添加依赖服务的就绪检查
+	s.initHealthChecks()
+
+	// 定期同步LDAP用户
+	s.initLDAPSync()
+
+	// 定期检查生成的自签名证书
+	s.initCertRenewal()
+
+	// 定期清理过期的变更记录
+	s.initChangeCleanup()
+
+	// 添加服务结束时的关闭操作: 先设置为未就绪，再等待正在处理的请求完成，最后停止后台任务和关闭存储.
+	s.gs.AddPhaseCallback(shutdown.PhaseStopReadiness, "readiness", 0, shutdown.ShutdownFunc(func(string) error {
+		s.gRPCAPIServer.StopReadiness()
+		s.genericAPIServer.StopReadiness()
+
+		return nil
+	}))
+	s.gs.AddPhaseCallback(shutdown.PhaseDrain, "grpc", 0, shutdown.ShutdownFunc(func(string) error {
+		s.gRPCAPIServer.Close()
+
+		return nil
+	}))
+	s.gs.AddPhaseCallback(shutdown.PhaseDrain, "http", 0, shutdown.ShutdownFunc(func(string) error {
+		s.genericAPIServer.Close()
+
+		return nil
+	}))
+	if s.auditor != nil {
+		// 请求处理完成后写完剩余的审计记录，数据库sink需要在关闭mysql之前完成
+		s.gs.AddPhaseCallback(shutdown.PhaseStopBackground, "audit", storageCloseTimeout, shutdown.ShutdownFunc(func(string) error {
+			return s.auditor.Close()
+		}))
+	}
+	s.gs.AddPhaseCallback(shutdown.PhaseCloseStorage, "mysql", storageCloseTimeout, shutdown.ShutdownFunc(func(string) error {
 		mysqlStore, _ := mysql.GetMySQLFactoryOr(nil)
 		if mysqlStore != nil {
 			// 关闭mysql连接池
-			_ = mysqlStore.Close()
+			return mysqlStore.Close()
 		}
-		// 关闭grpc服务和通用apiserver服务.
-		s.gRPCAPIServer.Close()
-		s.genericAPIServer.Close()
 
 		return nil
 	}))
@@ -104,21 +244,31 @@ func (s *apiServer) PrepareRun() preparedAPIServer {
 // Run 运行通用服务
 func (s preparedAPIServer) Run() error {
 	// 运行grpc服务
-	go s.gRPCAPIServer.Run()
+	if s.gRPCAPIServer != nil {
+		if err := s.gRPCAPIServer.Run(); err != nil {
+			return err
+		}
+	}
 
 	// 启动shutdown manager
 	if err := s.gs.Start(); err != nil {
 		log.Fatalf("start shutdown manager failed: %s", err.Error())
 	}
 
-	// 启动通用api服务
-	return s.genericAPIServer.Run()
+	// 启动通用api服务，服务关闭后等待剩余的关闭操作完成
+	if err := s.genericAPIServer.Run(); err != nil {
+		return err
+	}
+
+	<-s.gs.Done()
+
+	return nil
 }
 
 // 初始化redis
 func (s *apiServer) initRedisStore() {
 	ctx, cancel := context.WithCancel(context.Background())
-	s.gs.AddShutdownCallback(shutdown.ShutdownFunc(func(s string) error {
+	s.gs.AddPhaseCallback(shutdown.PhaseCloseStorage, "redis", storageCloseTimeout, shutdown.ShutdownFunc(func(string) error {
 		cancel()
 
 		return nil
@@ -144,6 +294,83 @@ func (s *apiServer) initRedisStore() {
 	go storage.ConnectToRedis(ctx, cfg)
 }
 
+// 定期禁用已经从目录中删除的LDAP用户.
+func (s *apiServer) initLDAPSync() {
+	interval := s.cfg.LDAPOptions.SyncInterval
+	if !s.cfg.LDAPOptions.Enabled() || interval == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.gs.AddPhaseCallback(shutdown.PhaseStopBackground, "ldap-sync", 0, shutdown.ShutdownFunc(func(string) error {
+		cancel()
+
+		return nil
+	}))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				storeIns, _ := mysql.GetMySQLFactoryOr(nil)
+				if storeIns == nil {
+					continue
+				}
+
+				if err := srvv1.NewService(storeIns).Users().SyncLDAP(ctx); err != nil {
+					log.Errorf("sync ldap users failed: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// changeCleanupInterval 清理过期变更记录的间隔.
+const changeCleanupInterval = time.Hour
+
+// initChangeCleanup 定期删除超过保留天数的变更记录，避免resource_change表无限增长.
+func (s *apiServer) initChangeCleanup() {
+	days := s.cfg.GRPCOptions.WatchReserveDays
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.gs.AddPhaseCallback(shutdown.PhaseStopBackground, "change-cleanup", 0, shutdown.ShutdownFunc(func(string) error {
+		cancel()
+
+		return nil
+	}))
+
+	go func() {
+		ticker := time.NewTicker(changeCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				storeIns, _ := mysql.GetMySQLFactoryOr(nil)
+				if storeIns == nil {
+					continue
+				}
+
+				rows, err := storeIns.ResourceChanges().ClearOutdated(ctx, days)
+				if err != nil {
+					log.Errorf("clear outdated resource changes failed: %s", err.Error())
+
+					continue
+				}
+
+				log.Debugf("cleared %d resource changes older than %d days", rows, days)
+			}
+		}
+	}()
+}
+
 // 根据apiserver应用配置生成通用配置.
 func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Config, lastErr error) {
 	// 创建默认的通用配置
@@ -157,6 +384,14 @@ func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Con
 		return
 	}
 
+	if lastErr = cfg.TracingOptions.ApplyTo(genericConfig); lastErr != nil {
+		return
+	}
+
+	if lastErr = cfg.DumpOptions.ApplyTo(genericConfig); lastErr != nil {
+		return
+	}
+
 	if lastErr = cfg.SecureServing.ApplyTo(genericConfig); lastErr != nil {
 		return
 	}
@@ -170,10 +405,23 @@ func buildGenericConfig(cfg *config.Config) (genericConfig *genericapiserver.Con
 
 // ExtraConfig 定义了iam-apiserver的额外配置.
 type ExtraConfig struct {
-	Addr         string
-	MaxMsgSize   int
-	ServerCert   genericoptions.GeneratableKeyCert
-	mysqlOptions *genericoptions.MySQLOptions
+	Enabled              bool
+	Addr                 string
+	MaxMsgSize           int
+	Interceptors         []string
+	Timeout              time.Duration
+	Auth                 genericoptions.GRPCAuthOptions
+	Plaintext            bool
+	Reflection           bool
+	MaxConcurrentStreams uint32
+	HealthCheckInterval  time.Duration
+	WatchInterval        time.Duration
+	Keepalive            genericoptions.GRPCKeepaliveOptions
+	ShutdownTimeout      time.Duration
+	Tracing              bool
+	mysqlOptions         *genericoptions.MySQLOptions
+	tokenAuth            func(ctx context.Context, method, token string) (string, error)
+	certificates         *certreloader.Reloader
 }
 
 // 完整的ExtraConfig
@@ -184,10 +432,22 @@ type completedExtraConfig struct {
 // nolint: unparam
 func buildExtraConfig(cfg *config.Config) (*ExtraConfig, error) {
 	return &ExtraConfig{
-		Addr:         fmt.Sprintf("%s:%d", cfg.GRPCOptions.BindAddress, cfg.GRPCOptions.BindPort),
-		MaxMsgSize:   cfg.GRPCOptions.MaxMsgSize,
-		ServerCert:   cfg.SecureServing.ServerCert,
-		mysqlOptions: cfg.MySQLOptions,
+		Enabled:              cfg.GRPCOptions.Enabled(),
+		Addr:                 net.JoinHostPort(cfg.GRPCOptions.BindAddress, strconv.Itoa(cfg.GRPCOptions.BindPort)),
+		MaxMsgSize:           cfg.GRPCOptions.MaxMsgSize,
+		Interceptors:         cfg.GRPCOptions.Interceptors,
+		Timeout:              cfg.GRPCOptions.Timeout,
+		Auth:                 cfg.GRPCOptions.Auth,
+		Plaintext:            cfg.GRPCOptions.Plaintext,
+		Reflection:           cfg.GRPCOptions.Reflection,
+		MaxConcurrentStreams: cfg.GRPCOptions.MaxConcurrentStreams,
+		HealthCheckInterval:  cfg.GRPCOptions.HealthCheckInterval,
+		WatchInterval:        cfg.GRPCOptions.WatchInterval,
+		Keepalive:            cfg.GRPCOptions.Keepalive,
+		ShutdownTimeout:      cfg.GenericServerRunOptions.ShutdownTimeout,
+		Tracing:              cfg.TracingOptions.Enabled,
+		mysqlOptions:         cfg.MySQLOptions,
+		tokenAuth:            grpcTokenAuth(cfg),
 	}, nil
 }
 
@@ -200,28 +460,168 @@ func (c *ExtraConfig) complete() *completedExtraConfig {
 	return &completedExtraConfig{c}
 }
 
-// New 创建一个grpcAPIServer实例, 并向其中注册需要的服务.
+// New 创建一个grpcAPIServer实例, 并向其中注册需要的服务. 未启用grpc服务时返回nil.
 func (c *completedExtraConfig) New() (*grpcAPIServer, error) {
-	creds, err := credentials.NewServerTLSFromFile(c.ServerCert.CertKey.CertFile, c.ServerCert.CertKey.KeyFile)
-
+	mysqlStore, err := mysql.GetMySQLFactoryOr(c.mysqlOptions)
 	if err != nil {
-		log.Fatalf("Failed to generate credentials %s", err.Error())
+		return nil, err
+	}
+
+	store.SetClient(mysqlStore)
+
+	if !c.Enabled {
+		log.Info("grpc server is disabled")
+
+		return nil, nil
+	}
+
+	unary, stream := c.interceptors()
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxMsgSize),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+		grpc.MaxConcurrentStreams(c.MaxConcurrentStreams),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.Keepalive.MinTime,
+			PermitWithoutStream: c.Keepalive.PermitWithoutStream,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  c.Keepalive.Time,
+			Timeout:               c.Keepalive.Timeout,
+			MaxConnectionAge:      durationOrInfinity(c.Keepalive.MaxConnectionAge),
+			MaxConnectionAgeGrace: durationOrInfinity(c.Keepalive.MaxConnectionAgeGrace),
+		}),
+	}
+
+	if c.Plaintext {
+		log.Warn("grpc server is serving in plaintext mode, do not use it in production")
+	} else {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to generate grpc credentials")
+		}
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 
-	opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(c.MaxMsgSize), grpc.Creds(creds)}
 	grpcServer := grpc.NewServer(opts...)
 
 	// 注册缓存服务到grpc服务
-	mysqlStore, _ := mysql.GetMySQLFactoryOr(c.mysqlOptions)
-	store.SetClient(mysqlStore)
 	cacheIns, err := cache.GetCacheInsOr(mysqlStore)
 	if err != nil {
-		log.Fatalf("Failed to get cache instance: %s", err.Error())
+		return nil, errors.Wrap(err, "failed to get cache instance")
 	}
 
 	pb.RegisterCacheServer(grpcServer, cacheIns)
+	watcher := cache.NewWatcher(mysqlStore, c.WatchInterval)
+	apiv1.RegisterCacheWatcherServer(grpcServer, watcher)
+
+	// 注册用户、密钥和策略的管理服务
+	apiv1.RegisterUserServiceServer(grpcServer, rpc.NewUserServer(mysqlStore))
+	apiv1.RegisterSecretServiceServer(grpcServer, rpc.NewSecretServer(mysqlStore))
+	apiv1.RegisterPolicyServiceServer(grpcServer, rpc.NewPolicyServer(mysqlStore))
+
+	// 健康状态由grpcAPIServer根据MySQL和Redis的状态更新
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	if c.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	return &grpcAPIServer{
+		Server:          grpcServer,
+		address:         c.Addr,
+		health:          healthServer,
+		healthInterval:  c.HealthCheckInterval,
+		shutdownTimeout: c.ShutdownTimeout,
+		watcher:         watcher,
+	}, nil
+}
+
+// durationOrInfinity 把0转换为grpc中表示不限制的无穷大时间.
+func durationOrInfinity(d time.Duration) time.Duration {
+	if d == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return d
+}
+
+// interceptors 按配置的顺序返回通用拦截器，截止时间和认证拦截器总是放在最后.
+func (c *completedExtraConfig) interceptors() ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	var (
+		unary  []grpc.UnaryServerInterceptor
+		stream []grpc.StreamServerInterceptor
+	)
+
+	// 链路追踪拦截器放在最前面，其他拦截器输出的日志中带有trace id
+	if c.Tracing {
+		unary = append(unary, interceptor.UnaryServerTracing())
+		stream = append(stream, interceptor.StreamServerTracing())
+	}
+
+	for _, name := range c.Interceptors {
+		i, ok := interceptor.Interceptors[name]
+		if !ok {
+			log.Warnf("can not find interceptor: %s", name)
+
+			continue
+		}
+
+		log.Infof("install interceptor: %s", name)
+		unary = append(unary, i.Unary)
+		stream = append(stream, i.Stream)
+	}
+
+	// 缓存服务会返回密钥明文，只允许指定的身份调用. 管理服务和REST接口使用相同的认证方式，创建用户不需要认证
+	authConfig := interceptor.AuthConfig{
+		CertField:         c.Auth.IdentityField,
+		Cert:              grpcCertAuth,
+		Basic:             basicAuthenticate,
+		Token:             c.tokenAuth,
+		Allowed:           c.Auth.AllowedIdentities,
+		RestrictedMethods: grpcCacheServices,
+		PublicMethods: []string{
+			"/" + healthpb.Health_ServiceDesc.ServiceName + "/",
+			"/" + apiv1.UserService_ServiceDesc.ServiceName + "/CreateUser",
+		},
+	}
+
+	unary = append(unary, interceptor.UnaryServerTimeout(c.Timeout), interceptor.UnaryServerAuth(authConfig))
+	stream = append(stream, interceptor.StreamServerAuth(authConfig))
+
+	return unary, stream
+}
+
+// tlsConfig 生成grpc服务的TLS配置，配置了CA文件时校验客户端提供的证书.
+func (c *completedExtraConfig) tlsConfig() (*tls.Config, error) {
+	if c.certificates == nil {
+		return nil, fmt.Errorf("tls certificate is not configured, set --secure.tls.cert-key.cert-file or --grpc.plaintext")
+	}
+
+	// 和https服务共用证书，证书文件更新后新的连接使用新证书
+	config := &tls.Config{
+		GetCertificate: c.certificates.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	if c.Auth.CAFile == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(c.Auth.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read grpc client ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in grpc client ca file %s", c.Auth.CAFile)
+	}
 
-	reflection.Register(grpcServer)
+	config.ClientCAs = pool
+	config.ClientAuth = tls.VerifyClientCertIfGiven
 
-	return &grpcAPIServer{grpcServer, c.Addr}, nil
+	return config, nil
 }
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+const (
+	// AccessTokenPrefix 是个人访问令牌的前缀，用于和jwt区分.
+	AccessTokenPrefix = "iamp_"
+
+	// 最后使用时间的更新间隔，避免每个请求都写数据库.
+	lastUsedInterval = time.Minute
+)
+
+// AccessTokenSrv 定义处理个人访问令牌请求的函数
+type AccessTokenSrv interface {
+	Create(ctx context.Context, token *modelv1.AccessToken, opts metav1.CreateOptions) error
+	Delete(ctx context.Context, username, name string, opts metav1.DeleteOptions) error
+	Get(ctx context.Context, username, name string, opts metav1.GetOptions) (*modelv1.AccessToken, error)
+	List(ctx context.Context, username string, opts metav1.ListOptions) (*modelv1.AccessTokenList, error)
+	Authenticate(ctx context.Context, raw, ip string) (*modelv1.AccessToken, error)
+	Lookup(ctx context.Context, raw string) (*modelv1.AccessToken, error)
+}
+
+var _ AccessTokenSrv = &accessTokenService{}
+
+type accessTokenService struct {
+	store store.Factory
+}
+
+func newAccessTokens(srv *service) *accessTokenService {
+	return &accessTokenService{srv.store}
+}
+
+// Create 生成新的访问令牌，令牌明文保存在token.Token中，只返回这一次.
+func (a *accessTokenService) Create(ctx context.Context, token *modelv1.AccessToken, opts metav1.CreateOptions) error {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.Create")
+	defer span.End()
+
+	_, err := a.store.AccessTokens().Get(ctx, token.Username, token.Name, metav1.GetOptions{})
+	if err == nil {
+		return errors.WithCode(code.ErrAccessTokenAlreadyExist, "access token %s already exist", token.Name)
+	}
+
+	if !errors.IsCode(err, code.ErrAccessTokenNotFound) {
+		return err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	token.Token = AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	token.TokenHash = hashAccessToken(token.Token)
+
+	if err := a.store.AccessTokens().Create(ctx, token, opts); err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+func (a *accessTokenService) Delete(ctx context.Context, username, name string, opts metav1.DeleteOptions) error {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.Delete")
+	defer span.End()
+
+	return a.store.AccessTokens().Delete(ctx, username, name, opts)
+}
+
+func (a *accessTokenService) Get(
+	ctx context.Context,
+	username, name string,
+	opts metav1.GetOptions,
+) (*modelv1.AccessToken, error) {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.Get")
+	defer span.End()
+
+	return a.store.AccessTokens().Get(ctx, username, name, opts)
+}
+
+func (a *accessTokenService) List(
+	ctx context.Context,
+	username string,
+	opts metav1.ListOptions,
+) (*modelv1.AccessTokenList, error) {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.List")
+	defer span.End()
+
+	tokens, err := a.store.AccessTokens().List(ctx, username, opts)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return tokens, nil
+}
+
+// Authenticate 校验访问令牌是否存在、未过期、来源IP是否被允许以及所属用户是否可用，并记录最后使用信息.
+func (a *accessTokenService) Authenticate(ctx context.Context, raw, ip string) (*modelv1.AccessToken, error) {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.Authenticate")
+	defer span.End()
+
+	token, err := a.Lookup(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.AllowIP(ip) {
+		return nil, errors.WithCode(code.ErrPermissionDenied, "access token %s is not allowed from %s", token.Name, ip)
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedInterval || token.LastUsedIP != ip {
+		if err := a.store.AccessTokens().UpdateLastUsed(ctx, token.ID, now, ip); err != nil {
+			log.L(ctx).Warnf("update last used of access token %s failed: %s", token.Name, err.Error())
+		}
+	}
+
+	return token, nil
+}
+
+// Lookup 校验访问令牌是否存在、未过期以及所属用户是否可用，不检查来源IP，也不记录使用信息.
+func (a *accessTokenService) Lookup(ctx context.Context, raw string) (*modelv1.AccessToken, error) {
+	ctx, span := tracer.Start(ctx, "AccessTokenService.Lookup")
+	defer span.End()
+
+	if !strings.HasPrefix(raw, AccessTokenPrefix) {
+		return nil, errors.WithCode(code.ErrTokenInvalid, "not a personal access token")
+	}
+
+	token, err := a.store.AccessTokens().GetByHash(ctx, hashAccessToken(raw))
+	if err != nil {
+		if errors.IsCode(err, code.ErrAccessTokenNotFound) {
+			return nil, errors.WithCode(code.ErrTokenInvalid, "access token is invalid")
+		}
+
+		return nil, err
+	}
+
+	if token.Expired(time.Now()) {
+		return nil, errors.WithCode(code.ErrExpired, "access token %s is expired", token.Name)
+	}
+
+	// 被删除或者禁用的用户的令牌不再可用
+	user, err := a.store.Users().Get(ctx, token.Username, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.WithCode(code.ErrTokenInvalid, "owner of access token %s is not available", token.Name)
+	}
+
+	if user.Status != 1 {
+		return nil, errors.WithCode(code.ErrPermissionDenied, "user %s is disabled", user.Name)
+	}
+
+	return token, nil
+}
+
+func hashAccessToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"context"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+)
+
+// AuditSrv 定义查询审计记录的函数.
+type AuditSrv interface {
+	List(ctx context.Context, opts modelv1.AuditListOptions) (*modelv1.AuditEventList, error)
+}
+
+var _ AuditSrv = &auditService{}
+
+type auditService struct {
+	store store.Factory
+}
+
+func newAudits(srv *service) *auditService {
+	return &auditService{srv.store}
+}
+
+// List 按时间倒序返回满足条件的审计记录.
+func (a *auditService) List(ctx context.Context, opts modelv1.AuditListOptions) (*modelv1.AuditEventList, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.List")
+	defer span.End()
+
+	return a.store.Audits().List(ctx, opts)
+}
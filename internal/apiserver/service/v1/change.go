@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// change 描述一次secret或policy的变更.
+type change struct {
+	kind     string
+	typ      string
+	username string
+	name     string
+	obj      interface{}
+}
+
+// policyChanges 返回一组policy的变更.
+func policyChanges(typ string, policies []*v1.Policy) []change {
+	changes := make([]change, 0, len(policies))
+	for _, pol := range policies {
+		changes = append(changes, change{modelv1.ResourceKindPolicy, typ, pol.Username, pol.Name, pol})
+	}
+
+	return changes
+}
+
+// withChanges 在同一个事务中执行fn并记录fn返回的变更，变更记录失败时回滚，避免watch漏掉已经生效的修改.
+// 事务提交后发布变更通知.
+func withChanges(ctx context.Context, s store.Factory, fn func(tx store.Factory) ([]change, error)) error {
+	var changes []change
+
+	err := s.Transaction(ctx, func(tx store.Factory) error {
+		var err error
+		if changes, err = fn(tx); err != nil {
+			return err
+		}
+
+		for _, c := range changes {
+			if err := recordChange(ctx, tx, c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		notifyChange(ctx, c.kind, c.typ, c.username, c.name, c.obj)
+	}
+
+	return nil
+}
+
+// recordChange 记录secret或policy的变更供watch推送.
+func recordChange(ctx context.Context, s store.Factory, c change) error {
+	data, err := json.Marshal(c.obj)
+	if err != nil {
+		return errors.WithCode(code.ErrEncodingJSON, "marshal %s %s/%s: %s", c.kind, c.username, c.name, err.Error())
+	}
+
+	if err := s.ResourceChanges().Create(ctx, &modelv1.ResourceChange{
+		Kind:     c.kind,
+		Type:     c.typ,
+		Username: c.username,
+		Name:     c.name,
+		Object:   string(data),
+	}); err != nil {
+		log.L(ctx).Errorf("record %s change of %s %s/%s failed: %s", c.typ, c.kind, c.username, c.name, err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// userPolicies 返回用户的所有policy，用于删除用户前记录policy的删除.
+func userPolicies(ctx context.Context, s store.Factory, username string) ([]*v1.Policy, error) {
+	policies, err := s.Polices().List(ctx, username, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return policies.Items, nil
+}
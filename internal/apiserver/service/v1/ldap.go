@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/ldap"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// LDAPPassword 是LDAP用户在user表中的密码字段，表示密码由LDAP校验. 它不是合法的密码哈希，不能用于本地密码登录.
+const LDAPPassword = "{ldap}"
+
+// 同步时每次从数据库读取的用户数量.
+const ldapSyncPageSize = 500
+
+var (
+	ldapClient *ldap.Client
+	ldapMu     sync.RWMutex
+)
+
+// SetLDAP 设置LDAP认证客户端，为nil时不启用LDAP认证，在服务启动时调用.
+func SetLDAP(client *ldap.Client) {
+	ldapMu.Lock()
+	defer ldapMu.Unlock()
+
+	ldapClient = client
+}
+
+func getLDAP() *ldap.Client {
+	ldapMu.RLock()
+	defer ldapMu.RUnlock()
+
+	return ldapClient
+}
+
+// Authenticate 校验用户名和密码. 本地用户使用密码哈希校验，LDAP用户使用LDAP校验，
+// 启用LDAP认证时，第一次登录的LDAP用户会自动创建.
+func (u *userService) Authenticate(ctx context.Context, username, pwd string) (*v1.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Authenticate")
+	defer span.End()
+
+	client := getLDAP()
+
+	// 被禁用的用户也需要查询，否则被禁用的LDAP用户会被当作新用户重复创建
+	user, err := u.store.Users().GetIncludingDisabled(ctx, username)
+	if err != nil {
+		if client == nil || !errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, err
+		}
+
+		return u.provisionLDAPUser(ctx, client, username, pwd)
+	}
+
+	if user.Password != LDAPPassword {
+		if user.Status != 1 {
+			return nil, errors.WithCode(code.ErrPermissionDenied, "user %s is disabled", username)
+		}
+
+		if err := u.VerifyPassword(ctx, user, pwd); err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+
+	if client == nil {
+		return nil, errors.WithCode(code.ErrPasswordIncorrect, "ldap authentication is disabled")
+	}
+
+	entry, err := authenticateLDAP(ctx, client, username, pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	// 被同步禁用的用户回到目录中后重新启用，和SyncLDAP的处理一致
+	if user.Status != 1 {
+		user.Status = 1
+		applyLDAPEntry(user, entry)
+
+		if err := u.store.Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		log.L(ctx).Infof("re-enabled ldap user %s", username)
+
+		return user, nil
+	}
+
+	// 同步目录中修改过的属性
+	if applyLDAPEntry(user, entry) {
+		if err := u.store.Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+			log.L(ctx).Warnf("update ldap attributes of user %s failed: %s", username, err.Error())
+		}
+	}
+
+	return user, nil
+}
+
+// provisionLDAPUser 使用LDAP校验密码，校验成功后根据目录中的属性创建用户.
+func (u *userService) provisionLDAPUser(ctx context.Context, client *ldap.Client, username, pwd string) (*v1.User, error) {
+	entry, err := authenticateLDAP(ctx, client, username, pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: entry.Username},
+		Password:   LDAPPassword,
+		Status:     1,
+		IsAdmin:    0,
+		LoginedAt:  time.Now(),
+	}
+	applyLDAPEntry(user, entry)
+
+	if err := u.store.Users().Create(ctx, user, metav1.CreateOptions{}); err != nil {
+		// 同一个用户并发登录时可能已经被其他请求创建
+		if existing, gerr := u.store.Users().GetIncludingDisabled(ctx, entry.Username); gerr == nil &&
+			existing.Password == LDAPPassword {
+			return existing, nil
+		}
+
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	log.L(ctx).Infof("provisioned ldap user %s", user.Name)
+
+	return user, nil
+}
+
+// SyncLDAP 禁用已经从目录中删除的LDAP用户，并重新启用回到目录中的用户.
+func (u *userService) SyncLDAP(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "UserService.SyncLDAP")
+	defer span.End()
+
+	client := getLDAP()
+	if client == nil {
+		return nil
+	}
+
+	entries, err := client.Users()
+	if err != nil {
+		return errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		present[e.Username] = true
+	}
+
+	var disabled, enabled int
+
+	// 按id分页读取所有LDAP用户，包括被禁用的用户，修改状态不会影响后面的分页
+	for afterID := uint64(0); ; {
+		users, err := u.store.Users().ListByPassword(ctx, LDAPPassword, afterID, ldapSyncPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			afterID = user.ID
+
+			switch {
+			case !present[user.Name] && user.Status == 1:
+				user.Status = 0
+				disabled++
+			case present[user.Name] && user.Status != 1:
+				user.Status = 1
+				enabled++
+			default:
+				continue
+			}
+
+			// 禁用用户时同时吊销用户的会话
+			if err := u.Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+				log.L(ctx).Errorf("update status of ldap user %s failed: %s", user.Name, err.Error())
+			}
+		}
+
+		if len(users) < ldapSyncPageSize {
+			break
+		}
+	}
+
+	log.L(ctx).Infof("ldap sync finished, %d users in directory, %d disabled, %d enabled", len(entries), disabled, enabled)
+
+	return nil
+}
+
+func authenticateLDAP(ctx context.Context, client *ldap.Client, username, pwd string) (*ldap.Entry, error) {
+	entry, err := client.Authenticate(username, pwd)
+	if err == nil {
+		return entry, nil
+	}
+
+	if !errors.Is(err, ldap.ErrInvalidCredentials) && !errors.Is(err, ldap.ErrUserNotFound) {
+		log.L(ctx).Errorf("ldap authentication of user %s failed: %s", username, err.Error())
+	}
+
+	return nil, errors.WithCode(code.ErrPasswordIncorrect, err.Error())
+}
+
+// applyLDAPEntry 把目录中的属性写入用户，返回属性是否有变化.
+func applyLDAPEntry(user *v1.User, entry *ldap.Entry) bool {
+	nickname := entry.Nickname
+	if nickname == "" {
+		nickname = entry.Username
+	}
+
+	changed := user.Nickname != nickname || user.Email != entry.Email || user.Phone != entry.Phone
+
+	user.Nickname = nickname
+	user.Email = entry.Email
+	user.Phone = entry.Phone
+
+	return changed
+}
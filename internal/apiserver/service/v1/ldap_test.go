@@ -0,0 +1,244 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/ldap"
+	"github.com/cuizhaoyue/iams/pkg/ldap/ldaptest"
+)
+
+// fakeFactory 只实现了测试用到的存储.
+type fakeFactory struct {
+	store.Factory
+	users *fakeUsers
+}
+
+func (f *fakeFactory) Users() store.UserStore {
+	return f.users
+}
+
+// fakeUsers 和mysql的实现一样，Get和List只返回启用的用户.
+type fakeUsers struct {
+	store.UserStore
+	mu     sync.Mutex
+	nextID uint64
+	users  map[string]*v1.User
+}
+
+func newFakeUsers() *fakeUsers {
+	return &fakeUsers{users: make(map[string]*v1.User)}
+}
+
+func (f *fakeUsers) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.users[user.Name]; ok {
+		return fmt.Errorf("duplicate entry '%s' for key 'name'", user.Name)
+	}
+
+	f.nextID++
+	u := *user
+	u.ID = f.nextID
+	f.users[user.Name] = &u
+
+	return nil
+}
+
+func (f *fakeUsers) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u := *user
+	f.users[user.Name] = &u
+
+	return nil
+}
+
+func (f *fakeUsers) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	user, err := f.GetIncludingDisabled(ctx, username)
+	if err != nil || user.Status != 1 {
+		return nil, errors.WithCode(code.ErrUserNotFound, "record not found")
+	}
+
+	return user, nil
+}
+
+func (f *fakeUsers) GetIncludingDisabled(ctx context.Context, username string) (*v1.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[username]
+	if !ok {
+		return nil, errors.WithCode(code.ErrUserNotFound, "record not found")
+	}
+
+	u := *user
+
+	return &u, nil
+}
+
+func (f *fakeUsers) ListByPassword(ctx context.Context, password string, afterID uint64, limit int) ([]*v1.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ret []*v1.User
+	for _, user := range f.users {
+		if user.Password == password && user.ID > afterID {
+			u := *user
+			ret = append(ret, &u)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	if len(ret) > limit {
+		ret = ret[:limit]
+	}
+
+	return ret, nil
+}
+
+func (f *fakeUsers) status(username string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.users[username].Status
+}
+
+func ldapEntry(username string) ldaptest.Entry {
+	return ldaptest.Entry{
+		DN:       "uid=" + username + ",ou=people,dc=example,dc=com",
+		Password: username + "-secret",
+		Attrs:    map[string]string{"uid": username, "mail": username + "@example.com"},
+	}
+}
+
+func setupLDAP(t *testing.T, entries []ldaptest.Entry) *ldaptest.Server {
+	s := ldaptest.NewServer(t, entries)
+
+	client, err := ldap.New(ldap.Config{
+		URL:          s.URL(),
+		BindDN:       ldaptest.ServiceDN,
+		BindPassword: ldaptest.ServicePassword,
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(uid=%s))",
+		Timeout:      5 * time.Second,
+		Attributes:   ldap.Attributes{Username: "uid", Email: "mail"},
+	})
+	assert.Nil(t, err)
+
+	SetLDAP(client)
+	t.Cleanup(func() { SetLDAP(nil) })
+
+	return s
+}
+
+func TestUserService_SyncLDAP(t *testing.T) {
+	users := newFakeUsers()
+	srv := NewService(&fakeFactory{users: users}).Users()
+
+	// 超过一页的LDAP用户，目录中只保留编号为偶数的用户
+	total := ldapSyncPageSize*2 + 10
+
+	var entries []ldaptest.Entry
+	for i := 0; i < total; i++ {
+		name := fmt.Sprintf("user%04d", i)
+		status := 1
+		if i%4 == 2 {
+			// 之前被同步禁用，现在仍在目录中
+			status = 0
+		}
+
+		assert.Nil(t, users.Create(context.Background(), &v1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Password:   LDAPPassword,
+			Status:     status,
+		}, metav1.CreateOptions{}))
+
+		if i%2 == 0 {
+			entries = append(entries, ldapEntry(name))
+		}
+	}
+
+	// 本地用户不受同步影响
+	assert.Nil(t, users.Create(context.Background(), &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "local"},
+		Password:   "hashed",
+		Status:     1,
+	}, metav1.CreateOptions{}))
+
+	setupLDAP(t, entries)
+
+	assert.Nil(t, srv.SyncLDAP(context.Background()))
+
+	for i := 0; i < total; i++ {
+		name := fmt.Sprintf("user%04d", i)
+		if i%2 == 0 {
+			assert.Equal(t, 1, users.status(name), name)
+		} else {
+			assert.Equal(t, 0, users.status(name), name)
+		}
+	}
+
+	assert.Equal(t, 1, users.status("local"))
+}
+
+func TestUserService_AuthenticateReenablesLDAPUser(t *testing.T) {
+	users := newFakeUsers()
+	srv := NewService(&fakeFactory{users: users}).Users()
+	s := setupLDAP(t, []ldaptest.Entry{ldapEntry("alice")})
+
+	// 第一次登录时创建用户
+	user, err := srv.Authenticate(context.Background(), "alice", "alice-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, LDAPPassword, user.Password)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	// 从目录中删除后被同步禁用
+	s.SetEntries(nil)
+	assert.Nil(t, srv.SyncLDAP(context.Background()))
+	assert.Equal(t, 0, users.status("alice"))
+
+	_, err = srv.Authenticate(context.Background(), "alice", "alice-secret")
+	assert.NotNil(t, err)
+
+	// 回到目录中后可以重新登录，并且重新启用
+	s.SetEntries([]ldaptest.Entry{ldapEntry("alice")})
+
+	user, err = srv.Authenticate(context.Background(), "alice", "alice-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, user.Status)
+	assert.Equal(t, 1, users.status("alice"))
+
+	_, err = srv.Authenticate(context.Background(), "alice", "wrong")
+	assert.NotNil(t, err)
+}
+
+func TestUserService_AuthenticateDisabledLocalUser(t *testing.T) {
+	users := newFakeUsers()
+	srv := NewService(&fakeFactory{users: users}).Users()
+	setupLDAP(t, []ldaptest.Entry{ldapEntry("bob")})
+
+	assert.Nil(t, users.Create(context.Background(), &v1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "bob"},
+		Password:   "hashed",
+		Status:     0,
+	}, metav1.CreateOptions{}))
+
+	// 被禁用的本地用户不能通过LDAP登录，也不会被当作新的LDAP用户创建
+	_, err := srv.Authenticate(context.Background(), "bob", "bob-secret")
+	assert.True(t, errors.IsCode(err, code.ErrPermissionDenied))
+	assert.Equal(t, "hashed", users.users["bob"].Password)
+}
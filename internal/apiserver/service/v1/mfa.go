@@ -0,0 +1,423 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+	"github.com/cuizhaoyue/iams/pkg/totp"
+)
+
+// MFAConfig 定义了MFA服务使用的配置.
+type MFAConfig struct {
+	// Issuer 展示在认证器App中的发行方名称.
+	Issuer string
+	// Skew 允许的前后时间窗口数.
+	Skew uint
+	// RecoveryCodeCount 启用MFA时生成的恢复码数量.
+	RecoveryCodeCount int
+	// MaxAttempts LockoutDuration内允许失败的验证次数，达到后拒绝验证，为0时不限制.
+	MaxAttempts int
+	// LockoutDuration 从第一次失败开始统计失败次数的时间.
+	LockoutDuration time.Duration
+}
+
+var (
+	mfaConfig = MFAConfig{Issuer: "iam", Skew: 1, RecoveryCodeCount: 10, MaxAttempts: 5, LockoutDuration: 15 * time.Minute}
+	mfaMu     sync.RWMutex
+
+	// 保存每个用户验证失败的次数
+	mfaFailures = storage.RedisCluster{KeyPrefix: "iam-mfa-failures-"}
+)
+
+// SetMFAConfig 设置MFA服务使用的配置，在服务启动时调用.
+func SetMFAConfig(cfg MFAConfig) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+
+	mfaConfig = cfg
+}
+
+func getMFAConfig() MFAConfig {
+	mfaMu.RLock()
+	defer mfaMu.RUnlock()
+
+	return mfaConfig
+}
+
+// MFAEnrollment 是登记MFA时返回给用户的数据.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFASrv 定义处理多因素认证请求的函数
+type MFASrv interface {
+	Get(ctx context.Context, username string) (*modelv1.UserMFA, error)
+	Enroll(ctx context.Context, username string) (*MFAEnrollment, error)
+	Activate(ctx context.Context, username, passcode string) ([]string, error)
+	Verify(ctx context.Context, username, passcode string) error
+	Disable(ctx context.Context, username string) error
+	RegenerateRecoveryCodes(ctx context.Context, username string) ([]string, error)
+	SetRequired(ctx context.Context, username string, required bool) error
+	Challenge(ctx context.Context, username string) (bool, error)
+}
+
+var _ MFASrv = &mfaService{}
+
+type mfaService struct {
+	store store.Factory
+}
+
+func newMFA(srv *service) *mfaService {
+	return &mfaService{srv.store}
+}
+
+// Get 返回用户的MFA配置.
+func (m *mfaService) Get(ctx context.Context, username string) (*modelv1.UserMFA, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.Get")
+	defer span.End()
+
+	return m.store.MFA().Get(ctx, username)
+}
+
+// Enroll 为用户生成新的TOTP密钥，用户需要调用Activate提交验证码后才会启用.
+func (m *mfaService) Enroll(ctx context.Context, username string) (*MFAEnrollment, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.Enroll")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrMFANotEnrolled) {
+			return nil, err
+		}
+
+		mfa = &modelv1.UserMFA{Username: username}
+	}
+
+	if mfa.Enabled {
+		return nil, errors.WithCode(code.ErrMFAAlreadyEnabled, "mfa of user %s is already enabled", username)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	mfa.Secret = secret
+	mfa.RecoveryCodes = ""
+	mfa.LastUsedStep = 0
+
+	if err := m.store.MFA().Save(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(getMFAConfig().Issuer, username, secret),
+	}, nil
+}
+
+// Activate 校验登记时生成的密钥对应的验证码，成功后启用MFA并返回恢复码.
+func (m *mfaService) Activate(ctx context.Context, username, passcode string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.Activate")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa.Enabled {
+		return nil, errors.WithCode(code.ErrMFAAlreadyEnabled, "mfa of user %s is already enabled", username)
+	}
+
+	if mfa.Secret == "" {
+		return nil, errors.WithCode(code.ErrMFANotEnrolled, "user %s has not enrolled a totp secret", username)
+	}
+
+	if err := checkMFAAttempts(ctx, username); err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(passcode, mfa.Secret, time.Now(), getMFAConfig().Skew)
+	if !ok {
+		recordMFAFailure(ctx, username)
+
+		return nil, errors.WithCode(code.ErrMFACodeInvalid, "invalid totp code")
+	}
+
+	resetMFAFailures(ctx, username)
+
+	codes, hashed, err := generateRecoveryCodes(getMFAConfig().RecoveryCodeCount)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	mfa.Enabled = true
+	mfa.LastUsedStep = step
+	mfa.RecoveryCodes = hashed
+
+	if err := m.store.MFA().Save(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify 校验TOTP验证码或者恢复码，恢复码只能使用一次.
+func (m *mfaService) Verify(ctx context.Context, username, passcode string) error {
+	ctx, span := tracer.Start(ctx, "MFAService.Verify")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if !mfa.Enabled {
+		return errors.WithCode(code.ErrMFANotEnrolled, "mfa of user %s is not enabled", username)
+	}
+
+	if err := checkMFAAttempts(ctx, username); err != nil {
+		return err
+	}
+
+	if step, ok := totp.Validate(passcode, mfa.Secret, time.Now(), getMFAConfig().Skew); ok {
+		// 同一个时间窗口内的验证码不能重复使用，由条件更新保证并发请求中只有一个成功
+		used, err := m.store.MFA().UseStep(ctx, username, step)
+		if err != nil {
+			return err
+		}
+
+		if !used {
+			recordMFAFailure(ctx, username)
+
+			return errors.WithCode(code.ErrMFACodeInvalid, "totp code has already been used")
+		}
+
+		resetMFAFailures(ctx, username)
+
+		return nil
+	}
+
+	remaining, ok := consumeRecoveryCode(mfa.RecoveryCodes, passcode)
+	if !ok {
+		recordMFAFailure(ctx, username)
+
+		return errors.WithCode(code.ErrMFACodeInvalid, "invalid totp or recovery code")
+	}
+
+	// 恢复码已经被其他请求修改时，可能是同一个恢复码被并发使用
+	replaced, err := m.store.MFA().ReplaceRecoveryCodes(ctx, username, mfa.RecoveryCodes, remaining)
+	if err != nil {
+		return err
+	}
+
+	if !replaced {
+		recordMFAFailure(ctx, username)
+
+		return errors.WithCode(code.ErrMFACodeInvalid, "recovery code has already been used")
+	}
+
+	resetMFAFailures(ctx, username)
+
+	return nil
+}
+
+// Disable 关闭用户的MFA. 如果管理员要求该用户必须使用MFA，则保留该要求.
+func (m *mfaService) Disable(ctx context.Context, username string) error {
+	ctx, span := tracer.Start(ctx, "MFAService.Disable")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if !mfa.Required {
+		return m.store.MFA().Delete(ctx, username)
+	}
+
+	mfa.Enabled = false
+	mfa.Secret = ""
+	mfa.RecoveryCodes = ""
+	mfa.LastUsedStep = 0
+
+	return m.store.MFA().Save(ctx, mfa)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效.
+func (m *mfaService) RegenerateRecoveryCodes(ctx context.Context, username string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.RegenerateRecoveryCodes")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mfa.Enabled {
+		return nil, errors.WithCode(code.ErrMFANotEnrolled, "mfa of user %s is not enabled", username)
+	}
+
+	codes, hashed, err := generateRecoveryCodes(getMFAConfig().RecoveryCodeCount)
+	if err != nil {
+		return nil, errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	mfa.RecoveryCodes = hashed
+
+	if err := m.store.MFA().Save(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// SetRequired 设置用户是否必须使用MFA.
+func (m *mfaService) SetRequired(ctx context.Context, username string, required bool) error {
+	ctx, span := tracer.Start(ctx, "MFAService.SetRequired")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		if !errors.IsCode(err, code.ErrMFANotEnrolled) {
+			return err
+		}
+
+		mfa = &modelv1.UserMFA{Username: username}
+	}
+
+	mfa.Required = required
+
+	return m.store.MFA().Save(ctx, mfa)
+}
+
+// Challenge 判断用户登录时是否需要进行MFA验证.
+func (m *mfaService) Challenge(ctx context.Context, username string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "MFAService.Challenge")
+	defer span.End()
+
+	mfa, err := m.store.MFA().Get(ctx, username)
+	if err != nil {
+		if errors.IsCode(err, code.ErrMFANotEnrolled) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return mfa.Enabled || mfa.Required, nil
+}
+
+// checkMFAAttempts 在失败次数达到上限时拒绝验证. redis不可用时无法统计失败次数，同样拒绝验证.
+func checkMFAAttempts(ctx context.Context, username string) error {
+	cfg := getMFAConfig()
+	if cfg.MaxAttempts <= 0 {
+		return nil
+	}
+
+	value, err := mfaFailures.GetKey(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return nil
+		}
+
+		return errors.WithCode(code.ErrUnknown, "check mfa attempts of user %s: %s", username, err.Error())
+	}
+
+	if n, _ := strconv.Atoi(value); n >= cfg.MaxAttempts {
+		return errors.WithCode(code.ErrMFALocked, "user %s has failed mfa verification %d times", username, n)
+	}
+
+	return nil
+}
+
+// recordMFAFailure 记录一次验证失败，达到上限后LockoutDuration内拒绝验证.
+func recordMFAFailure(ctx context.Context, username string) {
+	cfg := getMFAConfig()
+	if cfg.MaxAttempts <= 0 {
+		return
+	}
+
+	n, err := mfaFailures.IncrementWithExpire(ctx, username, cfg.LockoutDuration)
+	if err != nil {
+		log.L(ctx).Warnf("record mfa failure of user %s failed: %s", username, err.Error())
+
+		return
+	}
+
+	if int(n) == cfg.MaxAttempts {
+		log.L(ctx).Warnf("mfa of user %s is locked after %d failed attempts", username, n)
+	}
+}
+
+// resetMFAFailures 验证成功后清除失败次数.
+func resetMFAFailures(ctx context.Context, username string) {
+	if getMFAConfig().MaxAttempts > 0 {
+		mfaFailures.DeleteKey(ctx, username)
+	}
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes 生成n个恢复码，同时返回以逗号分隔的sha256哈希值.
+func generateRecoveryCodes(n int) ([]string, string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, "", err
+		}
+
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(buf))
+		c := raw[:8] + "-" + raw[8:16]
+		codes = append(codes, c)
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+
+	return codes, strings.Join(hashes, ","), nil
+}
+
+func hashRecoveryCode(c string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(c))))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// consumeRecoveryCode 查找匹配的恢复码，返回去掉该恢复码后剩余的哈希值.
+func consumeRecoveryCode(hashed, c string) (string, bool) {
+	if hashed == "" {
+		return hashed, false
+	}
+
+	target := hashRecoveryCode(c)
+	hashes := strings.Split(hashed, ",")
+
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(target)) == 1 {
+			hashes = append(hashes[:i], hashes[i+1:]...)
+
+			return strings.Join(hashes, ","), true
+		}
+	}
+
+	return hashed, false
+}
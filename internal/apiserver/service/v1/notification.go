@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+// NotificationConfig 定义了发布资源变更通知的配置.
+type NotificationConfig struct {
+	Enabled       bool
+	Channel       string
+	MaxRetries    int
+	RetryInterval time.Duration
+	// QueueSize 等待发布的通知的最大数量，队列满时新的通知被丢弃.
+	QueueSize int
+}
+
+// notification 是等待发布的通知.
+type notification struct {
+	event  storage.Event
+	target string
+	logger log.Logger
+}
+
+// notifier 在一个后台goroutine中按顺序发布队列中的通知.
+type notifier struct {
+	cfg      NotificationConfig
+	queue    chan notification
+	stopping chan struct{}
+	done     chan struct{}
+}
+
+var (
+	notifierIns *notifier
+	notifierMu  sync.RWMutex
+)
+
+// StartNotifications 启动发布资源变更通知的后台任务，在服务启动时调用. 返回的函数停止后台任务，
+// 停止前发布队列中剩余的通知，不再重试.
+func StartNotifications(cfg NotificationConfig) (stop func()) {
+	if !cfg.Enabled {
+		return func() {}
+	}
+
+	n := &notifier{
+		cfg:      cfg,
+		queue:    make(chan notification, cfg.QueueSize),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	notifierMu.Lock()
+	notifierIns = n
+	notifierMu.Unlock()
+
+	go n.run()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(n.stopping)
+			<-n.done
+		})
+	}
+}
+
+func getNotifier() *notifier {
+	notifierMu.RLock()
+	defer notifierMu.RUnlock()
+
+	return notifierIns
+}
+
+// notifyChange 把secret或policy的变更通知放入发布队列. 队列满或者发布最终失败时只记录日志，不影响请求结果.
+func notifyChange(ctx context.Context, kind, typ, username, name string, obj interface{}) {
+	n := getNotifier()
+	if n == nil {
+		return
+	}
+
+	var event storage.Event
+
+	switch kind {
+	case modelv1.ResourceKindSecret:
+		e := &storage.SecretChanged{Type: typ, Username: username, Name: name}
+		if secret, ok := obj.(*v1.Secret); ok {
+			e.SecretID = secret.SecretID
+		}
+
+		event = e
+	case modelv1.ResourceKindPolicy:
+		event = &storage.PolicyChanged{Type: typ, Username: username, Name: name}
+	default:
+		return
+	}
+
+	// 请求结束后ctx会被取消，只保留日志字段
+	logger := log.L(ctx)
+
+	select {
+	case n.queue <- notification{event: event, target: username + "/" + name, logger: logger}:
+	default:
+		logger.Warnf("notification queue is full, drop %s notification of %s/%s", event.Command(), username, name)
+	}
+}
+
+func (n *notifier) run() {
+	defer close(n.done)
+
+	for {
+		select {
+		case item := <-n.queue:
+			n.publish(item, true)
+		case <-n.stopping:
+			for {
+				select {
+				case item := <-n.queue:
+					n.publish(item, false)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish 发布一条通知，失败时按指数退避重试，服务停止时不再重试.
+func (n *notifier) publish(item notification, retry bool) {
+	r := storage.RedisCluster{}
+	interval := n.cfg.RetryInterval
+
+	for attempt := 0; ; attempt++ {
+		err := r.PublishEvent(context.Background(), n.cfg.Channel, item.event)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= n.cfg.MaxRetries {
+			item.logger.Errorf("publish %s notification of %s failed: %s", item.event.Command(), item.target, err.Error())
+
+			return
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-n.stopping:
+			timer.Stop()
+			item.logger.Errorf("publish %s notification of %s failed: %s", item.event.Command(), item.target, err.Error())
+
+			return
+		}
+
+		interval *= 2
+	}
+}
@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+)
+
+// OAuthClientSrv 定义处理OAuth2客户端请求的函数
+type OAuthClientSrv interface {
+	Create(ctx context.Context, client *modelv1.OAuthClient, opts metav1.CreateOptions) error
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*modelv1.OAuthClient, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*modelv1.OAuthClientList, error)
+	ResetSecret(ctx context.Context, name string) (*modelv1.OAuthClient, error)
+	Authenticate(ctx context.Context, clientID, secret string) (*modelv1.OAuthClient, error)
+}
+
+var _ OAuthClientSrv = &oauthClientService{}
+
+type oauthClientService struct {
+	store store.Factory
+}
+
+func newOAuthClients(srv *service) *oauthClientService {
+	return &oauthClientService{srv.store}
+}
+
+// Create 注册OAuth2客户端并生成client_id，非公开客户端同时生成密钥，密钥明文只返回这一次.
+func (o *oauthClientService) Create(ctx context.Context, client *modelv1.OAuthClient, opts metav1.CreateOptions) error {
+	ctx, span := tracer.Start(ctx, "OAuthClientService.Create")
+	defer span.End()
+
+	_, err := o.store.OAuthClients().Get(ctx, client.Name, metav1.GetOptions{})
+	if err == nil {
+		return errors.WithCode(code.ErrOAuthClientAlreadyExist, "oauth client %s already exist", client.Name)
+	}
+
+	if !errors.IsCode(err, code.ErrOAuthClientNotFound) {
+		return err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	client.ClientID = hex.EncodeToString(id)
+
+	if err := o.generateSecret(client); err != nil {
+		return err
+	}
+
+	if err := o.store.OAuthClients().Create(ctx, client, opts); err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+func (o *oauthClientService) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	ctx, span := tracer.Start(ctx, "OAuthClientService.Delete")
+	defer span.End()
+
+	return o.store.OAuthClients().Delete(ctx, name, opts)
+}
+
+func (o *oauthClientService) Get(ctx context.Context, name string, opts metav1.GetOptions) (*modelv1.OAuthClient, error) {
+	ctx, span := tracer.Start(ctx, "OAuthClientService.Get")
+	defer span.End()
+
+	return o.store.OAuthClients().Get(ctx, name, opts)
+}
+
+func (o *oauthClientService) List(ctx context.Context, opts metav1.ListOptions) (*modelv1.OAuthClientList, error) {
+	ctx, span := tracer.Start(ctx, "OAuthClientService.List")
+	defer span.End()
+
+	return o.store.OAuthClients().List(ctx, opts)
+}
+
+// ResetSecret 重新生成客户端密钥，旧密钥立即失效.
+func (o *oauthClientService) ResetSecret(ctx context.Context, name string) (*modelv1.OAuthClient, error) {
+	ctx, span := tracer.Start(ctx, "OAuthClientService.ResetSecret")
+	defer span.End()
+
+	client, err := o.store.OAuthClients().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		return nil, errors.WithCode(code.ErrValidation, "public client %s has no secret", name)
+	}
+
+	if err := o.generateSecret(client); err != nil {
+		return nil, err
+	}
+
+	if err := o.store.OAuthClients().Update(ctx, client, metav1.UpdateOptions{}); err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return client, nil
+}
+
+// Authenticate 校验客户端身份. 公开客户端没有密钥，secret必须为空.
+func (o *oauthClientService) Authenticate(ctx context.Context, clientID, secret string) (*modelv1.OAuthClient, error) {
+	ctx, span := tracer.Start(ctx, "OAuthClientService.Authenticate")
+	defer span.End()
+
+	client, err := o.store.OAuthClients().GetByClientID(ctx, clientID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrOAuthClientNotFound) {
+			return nil, errors.WithCode(code.ErrOAuthClientInvalid, "unknown client %s", clientID)
+		}
+
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, errors.WithCode(code.ErrOAuthClientInvalid, "public client %s must not use a secret", clientID)
+		}
+
+		return client, nil
+	}
+
+	if secret == "" ||
+		subtle.ConstantTimeCompare([]byte(hashAccessToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, errors.WithCode(code.ErrOAuthClientInvalid, "invalid secret of client %s", clientID)
+	}
+
+	return client, nil
+}
+
+func (o *oauthClientService) generateSecret(client *modelv1.OAuthClient) error {
+	client.ClientSecret = ""
+	client.SecretHash = ""
+
+	if client.Public {
+		return nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return errors.WithCode(code.ErrUnknown, err.Error())
+	}
+
+	client.ClientSecret = base64.RawURLEncoding.EncodeToString(buf)
+	client.SecretHash = hashAccessToken(client.ClientSecret)
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/cuizhaoyue/iams/internal/pkg/code"
 	"github.com/marmotedu/errors"
 
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
 	"github.com/cuizhaoyue/iams/internal/apiserver/store"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
@@ -33,27 +34,51 @@ func newPolicies(srv *service) *policyService {
 }
 
 func (s *policyService) Create(ctx context.Context, policy *v1.Policy, opts metav1.CreateOptions) error {
-	if err := s.store.Polices().Create(ctx, policy, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
-	}
+	ctx, span := tracer.Start(ctx, "PolicyService.Create")
+	defer span.End()
+
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		if err := tx.Polices().Create(ctx, policy, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
 
-	return nil
+		return policyChanges(modelv1.ChangeTypeCreated, []*v1.Policy{policy}), nil
+	})
 }
 
 func (s *policyService) Update(ctx context.Context, policy *v1.Policy, opts metav1.UpdateOptions) error {
-	if err := s.store.Polices().Update(ctx, policy, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
-	}
+	ctx, span := tracer.Start(ctx, "PolicyService.Update")
+	defer span.End()
 
-	return nil
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		if err := tx.Polices().Update(ctx, policy, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		return policyChanges(modelv1.ChangeTypeUpdated, []*v1.Policy{policy}), nil
+	})
 }
 
 func (s *policyService) Delete(ctx context.Context, username string, name string, opts metav1.DeleteOptions) error {
-	if err := s.store.Polices().Delete(ctx, username, name, opts); err != nil {
-		return err
-	}
+	ctx, span := tracer.Start(ctx, "PolicyService.Delete")
+	defer span.End()
+
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		policy, err := tx.Polices().Get(ctx, username, name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsCode(err, code.ErrPolicyNotFound) {
+				return nil, nil
+			}
 
-	return nil
+			return nil, err
+		}
+
+		if err := tx.Polices().Delete(ctx, username, name, opts); err != nil {
+			return nil, err
+		}
+
+		return policyChanges(modelv1.ChangeTypeDeleted, []*v1.Policy{policy}), nil
+	})
 }
 
 func (s *policyService) DeleteCollection(
@@ -62,14 +87,36 @@ func (s *policyService) DeleteCollection(
 	names []string,
 	opts metav1.DeleteOptions,
 ) error {
-	if err := s.store.Polices().DeleteCollection(ctx, username, names, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
-	}
-
-	return nil
+	ctx, span := tracer.Start(ctx, "PolicyService.DeleteCollection")
+	defer span.End()
+
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		var policies []*v1.Policy
+		for _, name := range names {
+			policy, err := tx.Polices().Get(ctx, username, name, metav1.GetOptions{})
+			if err != nil {
+				if errors.IsCode(err, code.ErrPolicyNotFound) {
+					continue
+				}
+
+				return nil, err
+			}
+
+			policies = append(policies, policy)
+		}
+
+		if err := tx.Polices().DeleteCollection(ctx, username, names, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		return policyChanges(modelv1.ChangeTypeDeleted, policies), nil
+	})
 }
 
 func (s *policyService) Get(ctx context.Context, username string, name string, opts metav1.GetOptions) (*v1.Policy, error) {
+	ctx, span := tracer.Start(ctx, "PolicyService.Get")
+	defer span.End()
+
 	policy, err := s.store.Polices().Get(ctx, username, name, opts)
 	if err != nil {
 		return nil, err
@@ -79,6 +126,9 @@ func (s *policyService) Get(ctx context.Context, username string, name string, o
 }
 
 func (s *policyService) List(ctx context.Context, username string, opts metav1.ListOptions) (*v1.PolicyList, error) {
+	ctx, span := tracer.Start(ctx, "PolicyService.List")
+	defer span.End()
+
 	policies, err := s.store.Polices().List(ctx, username, opts)
 	if err != nil {
 		return nil, errors.WithCode(code.ErrDatabase, err.Error())
@@ -6,6 +6,7 @@ import (
 	"github.com/cuizhaoyue/iams/internal/pkg/code"
 	"github.com/marmotedu/errors"
 
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
 	"github.com/cuizhaoyue/iams/internal/apiserver/store"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
@@ -32,27 +33,57 @@ func newSecrets(srv *service) *secretService {
 	return &secretService{srv.store}
 }
 func (s *secretService) Create(ctx context.Context, secret *v1.Secret, opts metav1.CreateOptions) error {
-	if err := s.store.Secrets().Create(ctx, secret, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
-	}
+	ctx, span := tracer.Start(ctx, "SecretService.Create")
+	defer span.End()
+
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		if err := tx.Secrets().Create(ctx, secret, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
 
-	return nil
+		return []change{{modelv1.ResourceKindSecret, modelv1.ChangeTypeCreated, secret.Username, secret.Name, secret}}, nil
+	})
 }
 
 func (s *secretService) Update(ctx context.Context, secret *v1.Secret, opts metav1.UpdateOptions) error {
-	if err := s.store.Secrets().Update(ctx, secret, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
-	}
+	ctx, span := tracer.Start(ctx, "SecretService.Update")
+	defer span.End()
 
-	return nil
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		if err := tx.Secrets().Update(ctx, secret, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		return []change{{modelv1.ResourceKindSecret, modelv1.ChangeTypeUpdated, secret.Username, secret.Name, secret}}, nil
+	})
 }
 
 func (s *secretService) Delete(ctx context.Context, username, secretID string, opts metav1.DeleteOptions) error {
-	if err := s.store.Secrets().Delete(ctx, username, secretID, opts); err != nil {
-		return err
-	}
-
-	return nil
+	ctx, span := tracer.Start(ctx, "SecretService.Delete")
+	defer span.End()
+
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		// 删除前获取secret，删除事件中需要secretID. store在记录不存在时返回空的secret，
+		// 这时没有需要删除的内容，不记录变更
+		secret, err := tx.Secrets().Get(ctx, username, secretID, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsCode(err, code.ErrSecretNotFound) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		if secret.ID == 0 {
+			return nil, nil
+		}
+
+		if err := tx.Secrets().Delete(ctx, username, secretID, opts); err != nil {
+			return nil, err
+		}
+
+		return []change{{modelv1.ResourceKindSecret, modelv1.ChangeTypeDeleted, username, secretID, secret}}, nil
+	})
 }
 
 func (s *secretService) DeleteCollection(
@@ -61,14 +92,35 @@ func (s *secretService) DeleteCollection(
 	secretIDs []string,
 	opts metav1.DeleteOptions,
 ) error {
-	if err := s.store.Secrets().DeleteCollection(ctx, username, secretIDs, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
-	}
-
-	return nil
+	ctx, span := tracer.Start(ctx, "SecretService.DeleteCollection")
+	defer span.End()
+
+	return withChanges(ctx, s.store, func(tx store.Factory) ([]change, error) {
+		var changes []change
+		for _, secretID := range secretIDs {
+			// 跳过不存在的secret，避免记录没有发生的删除
+			secret, err := tx.Secrets().Get(ctx, username, secretID, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			if secret.ID != 0 {
+				changes = append(changes, change{modelv1.ResourceKindSecret, modelv1.ChangeTypeDeleted, username, secret.Name, secret})
+			}
+		}
+
+		if err := tx.Secrets().DeleteCollection(ctx, username, secretIDs, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		return changes, nil
+	})
 }
 
 func (s *secretService) Get(ctx context.Context, username, secretID string, opts metav1.GetOptions) (*v1.Secret, error) {
+	ctx, span := tracer.Start(ctx, "SecretService.Get")
+	defer span.End()
+
 	secret, err := s.store.Secrets().Get(ctx, username, secretID, opts)
 	if err != nil {
 		return nil, err
@@ -78,6 +130,9 @@ func (s *secretService) Get(ctx context.Context, username, secretID string, opts
 }
 
 func (s *secretService) List(ctx context.Context, username string, opts metav1.ListOptions) (*v1.SecretList, error) {
+	ctx, span := tracer.Start(ctx, "SecretService.List")
+	defer span.End()
+
 	secrets, err := s.store.Secrets().List(ctx, username, opts)
 	if err != nil {
 		return nil, errors.WithCode(code.ErrDatabase, err.Error())
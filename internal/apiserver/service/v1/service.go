@@ -7,6 +7,10 @@ type Service interface {
 	Users() UserSrv
 	Secrets() SecretSrv
 	Policies() PolicySrv
+	MFA() MFASrv
+	AccessTokens() AccessTokenSrv
+	OAuthClients() OAuthClientSrv
+	Audits() AuditSrv
 }
 
 var _ Service = &service{}
@@ -30,3 +34,19 @@ func (s *service) Secrets() SecretSrv {
 func (s *service) Policies() PolicySrv {
 	return newPolicies(s)
 }
+
+func (s *service) MFA() MFASrv {
+	return newMFA(s)
+}
+
+func (s *service) AccessTokens() AccessTokenSrv {
+	return newAccessTokens(s)
+}
+
+func (s *service) OAuthClients() OAuthClientSrv {
+	return newOAuthClients(s)
+}
+
+func (s *service) Audits() AuditSrv {
+	return newAudits(s)
+}
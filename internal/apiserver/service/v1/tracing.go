@@ -0,0 +1,6 @@
+package v1
+
+import "github.com/cuizhaoyue/iams/pkg/tracing"
+
+// tracer 为每个服务方法创建span，gorm和redis的span作为它的子span.
+var tracer = tracing.Tracer("github.com/cuizhaoyue/iams/internal/apiserver/service/v1")
@@ -6,10 +6,13 @@ import (
 	"sync"
 
 	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/password"
 
 	"github.com/cuizhaoyue/iams/internal/pkg/code"
 	"github.com/marmotedu/errors"
 
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/session"
 	"github.com/cuizhaoyue/iams/internal/apiserver/store"
 
 	v1 "github.com/marmotedu/api/apiserver/v1"
@@ -26,6 +29,9 @@ type UserSrv interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
 	ListWithBadPerformance(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
 	ChangePassword(ctx context.Context, user *v1.User) error
+	VerifyPassword(ctx context.Context, user *v1.User, password string) error
+	Authenticate(ctx context.Context, username, password string) (*v1.User, error)
+	SyncLDAP(ctx context.Context) error
 }
 
 var _ UserSrv = &userService{}
@@ -39,6 +45,9 @@ func newUser(srv *service) *userService {
 }
 
 func (u *userService) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
+	ctx, span := tracer.Start(ctx, "UserService.Create")
+	defer span.End()
+
 	if err := u.store.Users().Create(ctx, user, opts); err != nil {
 		if match, _ := regexp.MatchString("Duplicate entry '*' for key 'idx_name'", err.Error()); match {
 			return errors.WithCode(code.ErrUserAlreadyExist, err.Error())
@@ -51,30 +60,83 @@ func (u *userService) Create(ctx context.Context, user *v1.User, opts metav1.Cre
 }
 
 func (u *userService) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
+	ctx, span := tracer.Start(ctx, "UserService.Update")
+	defer span.End()
+
 	if err := u.store.Users().Update(ctx, user, opts); err != nil {
 		return errors.WithCode(code.ErrDatabase, err.Error())
 	}
 
+	// 用户被禁用后吊销所有会话
+	if user.Status != 1 {
+		return revokeSessions(ctx, user.Name)
+	}
+
 	return nil
 }
 
 func (u *userService) Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error {
-	if err := u.store.Users().Delete(ctx, username, opts); err != nil {
+	ctx, span := tracer.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	// 删除用户时会同时删除用户的policy，在同一个事务中记录policy的删除
+	err := withChanges(ctx, u.store, func(tx store.Factory) ([]change, error) {
+		policies, err := userPolicies(ctx, tx, username)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tx.Users().Delete(ctx, username, opts); err != nil {
+			return nil, err
+		}
+
+		return policyChanges(modelv1.ChangeTypeDeleted, policies), nil
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return revokeSessions(ctx, username)
 }
 
 func (u *userService) DeleteCollection(ctx context.Context, usernames []string, opts metav1.DeleteOptions) error {
-	if err := u.store.Users().DeleteCollection(ctx, usernames, opts); err != nil {
-		return errors.WithCode(code.ErrDatabase, err.Error())
+	ctx, span := tracer.Start(ctx, "UserService.DeleteCollection")
+	defer span.End()
+
+	err := withChanges(ctx, u.store, func(tx store.Factory) ([]change, error) {
+		var policies []*v1.Policy
+		for _, username := range usernames {
+			items, err := userPolicies(ctx, tx, username)
+			if err != nil {
+				return nil, err
+			}
+
+			policies = append(policies, items...)
+		}
+
+		if err := tx.Users().DeleteCollection(ctx, usernames, opts); err != nil {
+			return nil, errors.WithCode(code.ErrDatabase, err.Error())
+		}
+
+		return policyChanges(modelv1.ChangeTypeDeleted, policies), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, username := range usernames {
+		if err := revokeSessions(ctx, username); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (u *userService) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Get")
+	defer span.End()
+
 	user, err := u.store.Users().Get(ctx, username, opts)
 	if err != nil {
 		return nil, err
@@ -84,6 +146,9 @@ func (u *userService) Get(ctx context.Context, username string, opts metav1.GetO
 }
 
 func (u *userService) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+	ctx, span := tracer.Start(ctx, "UserService.List")
+	defer span.End()
+
 	users, err := u.store.Users().List(ctx, opts)
 	if err != nil {
 		log.L(ctx).Errorf("list users from storage failed: %s", err.Error())
@@ -152,6 +217,9 @@ func (u *userService) List(ctx context.Context, opts metav1.ListOptions) (*v1.Us
 }
 
 func (u *userService) ListWithBadPerformance(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ListWithBadPerformance")
+	defer span.End()
+
 	users, err := u.store.Users().List(ctx, opts)
 	if err != nil {
 		return nil, errors.WithCode(code.ErrDatabase, err.Error())
@@ -182,9 +250,58 @@ func (u *userService) ListWithBadPerformance(ctx context.Context, opts metav1.Li
 }
 
 func (u *userService) ChangePassword(ctx context.Context, user *v1.User) error {
+	ctx, span := tracer.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+
 	if err := u.store.Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
 		return errors.WithCode(code.ErrDatabase, err.Error())
 	}
 
+	// 修改密码后之前签发的token全部失效
+	return revokeSessions(ctx, user.Name)
+}
+
+// revokeSessions 吊销用户的所有登录会话. 吊销失败时返回错误，调用方可以重试，避免会话在修改密码、
+// 禁用或删除用户后仍然有效.
+func revokeSessions(ctx context.Context, username string) error {
+	registry := session.Default()
+	if registry == nil {
+		return nil
+	}
+
+	if err := registry.RevokeAll(ctx, username); err != nil {
+		return errors.WithCode(code.ErrUnknown, "revoke sessions of user %s: %s", username, err.Error())
+	}
+
+	return nil
+}
+
+// VerifyPassword 校验用户密码，校验成功后如果密码哈希使用的算法或参数已经过时，则使用当前算法重新哈希并保存.
+func (u *userService) VerifyPassword(ctx context.Context, user *v1.User, pwd string) error {
+	ctx, span := tracer.Start(ctx, "UserService.VerifyPassword")
+	defer span.End()
+
+	rehash, err := password.Compare(user.Password, pwd)
+	if err != nil {
+		return errors.WithCode(code.ErrPasswordIncorrect, err.Error())
+	}
+
+	if !rehash {
+		return nil
+	}
+
+	hashed, err := password.Hash(pwd)
+	if err != nil {
+		log.L(ctx).Warnf("rehash password of user `%s` failed: %s", user.Name, err.Error())
+
+		return nil
+	}
+
+	user.Password = hashed
+	// 重新哈希失败不影响本次认证
+	if err := u.store.Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+		log.L(ctx).Warnf("save rehashed password of user `%s` failed: %s", user.Name, err.Error())
+	}
+
 	return nil
 }
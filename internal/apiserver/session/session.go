@@ -0,0 +1,216 @@
+// Package session 在redis中维护登录会话，用于吊销已经签发的jwt.
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/storage"
+)
+
+const (
+	keyPrefix  = "iam-session-"
+	userPrefix = "user-"
+
+	// 最后活跃时间的更新间隔，避免每个请求都写redis.
+	touchInterval = time.Minute
+)
+
+// Session 表示一次登录会话.
+type Session struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	ClientIP  string    `json:"clientIP"`
+	UserAgent string    `json:"userAgent"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Registry 保存所有未过期的会话. 每个会话保存在单独的key中，同时在用户维度的集合中记录会话ID，
+// 便于列出和批量吊销用户的会话.
+type Registry struct {
+	store storage.RedisCluster
+	ttl   time.Duration
+}
+
+// NewRegistry 创建会话注册表，ttl为会话的最长存活时间，一般为token有效期加上最长刷新时间.
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{
+		store: storage.RedisCluster{KeyPrefix: keyPrefix},
+		ttl:   ttl,
+	}
+}
+
+var (
+	std *Registry
+	mu  sync.RWMutex
+)
+
+// Default 返回全局的会话注册表，未设置时返回nil.
+func Default() *Registry {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return std
+}
+
+// SetDefault 设置全局的会话注册表.
+func SetDefault(r *Registry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	std = r
+}
+
+// Create 为用户创建一个新的会话.
+func (r *Registry) Create(ctx context.Context, username, clientIP, userAgent string) (*Session, error) {
+	now := time.Now()
+	s := &Session{
+		ID:        uuid.Must(uuid.NewV4()).String(),
+		Username:  username,
+		ClientIP:  clientIP,
+		UserAgent: userAgent,
+		IssuedAt:  now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(r.ttl),
+	}
+
+	if err := r.save(ctx, s, r.ttl); err != nil {
+		return nil, err
+	}
+
+	if err := r.store.AddToSet(ctx, userPrefix+username, s.ID); err != nil {
+		return nil, err
+	}
+
+	// 用户集合的过期时间随最新的会话延长
+	_ = r.store.SetExp(ctx, userPrefix+username, r.ttl)
+
+	return s, nil
+}
+
+// Get 返回会话详情，会话不存在或已被吊销时返回storage.ErrKeyNotFound.
+func (r *Registry) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := r.store.GetKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Touch 校验会话仍然有效并且属于指定用户，同时更新最后活跃时间和IP.
+func (r *Registry) Touch(ctx context.Context, id, username, clientIP string) error {
+	s, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if s.Username != username {
+		return storage.ErrKeyNotFound
+	}
+
+	now := time.Now()
+	if now.Sub(s.LastSeen) < touchInterval && s.ClientIP == clientIP {
+		return nil
+	}
+
+	s.LastSeen = now
+	s.ClientIP = clientIP
+
+	ttl := s.ExpiresAt.Sub(now)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// 读取之后会话可能已经被吊销，只在会话仍然存在时更新，避免恢复被吊销的会话
+	ok, err := r.store.SetKeyIfExists(ctx, s.ID, string(data), ttl)
+	if err != nil {
+		log.L(ctx).Warnf("update last seen of session %s failed: %s", id, err.Error())
+
+		return nil
+	}
+
+	if !ok {
+		return storage.ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// List 返回用户所有未过期的会话.
+func (r *Registry) List(ctx context.Context, username string) ([]*Session, error) {
+	ids, err := r.store.GetSet(ctx, userPrefix+username)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		s, err := r.Get(ctx, id)
+		if err != nil {
+			// 已经过期的会话从集合中清除
+			_ = r.store.RemoveFromSet(ctx, userPrefix+username, id)
+
+			continue
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+// Revoke 吊销用户的一个会话.
+func (r *Registry) Revoke(ctx context.Context, username, id string) error {
+	s, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if s.Username != username {
+		return storage.ErrKeyNotFound
+	}
+
+	if err := r.store.DeleteKeys(ctx, []string{id}); err != nil {
+		return err
+	}
+
+	return r.store.RemoveFromSet(ctx, userPrefix+username, id)
+}
+
+// RevokeAll 吊销用户的所有会话. 删除失败时返回错误，用户的会话集合被保留，调用方可以重试.
+func (r *Registry) RevokeAll(ctx context.Context, username string) error {
+	ids, err := r.store.GetSet(ctx, userPrefix+username)
+	if err != nil {
+		return err
+	}
+
+	// 最后删除会话集合，前面的会话删除失败时仍然可以找到剩余的会话
+	return r.store.DeleteKeys(ctx, append(ids, userPrefix+username))
+}
+
+func (r *Registry) save(ctx context.Context, s *Session, ttl time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return r.store.SetKey(ctx, s.ID, string(data), ttl)
+}
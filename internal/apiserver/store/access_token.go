@@ -0,0 +1,20 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+)
+
+// AccessTokenStore 定义了个人访问令牌的存储接口.
+type AccessTokenStore interface {
+	Create(ctx context.Context, token *modelv1.AccessToken, opts metav1.CreateOptions) error
+	Delete(ctx context.Context, username, name string, opts metav1.DeleteOptions) error
+	Get(ctx context.Context, username, name string, opts metav1.GetOptions) (*modelv1.AccessToken, error)
+	GetByHash(ctx context.Context, hash string) (*modelv1.AccessToken, error)
+	List(ctx context.Context, username string, opts metav1.ListOptions) (*modelv1.AccessTokenList, error)
+	UpdateLastUsed(ctx context.Context, id uint64, at time.Time, ip string) error
+}
@@ -0,0 +1,14 @@
+package store
+
+import (
+	"context"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+)
+
+// AuditStore 定义了审计记录的存储接口.
+type AuditStore interface {
+	Create(ctx context.Context, event *modelv1.AuditEvent) error
+	// List 按时间倒序返回满足条件的审计记录.
+	List(ctx context.Context, opts modelv1.AuditListOptions) (*modelv1.AuditEventList, error)
+}
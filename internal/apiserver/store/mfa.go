@@ -0,0 +1,17 @@
+package store
+
+import (
+	"context"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+)
+
+// MFAStore 定义了用户多因素认证配置的存储接口.
+type MFAStore interface {
+	Get(ctx context.Context, username string) (*modelv1.UserMFA, error)
+	Save(ctx context.Context, mfa *modelv1.UserMFA) error
+	Delete(ctx context.Context, username string) error
+	DeleteCollection(ctx context.Context, usernames []string) error
+	UseStep(ctx context.Context, username string, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, username, old, codes string) (bool, error)
+}
@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/marmotedu/component-base/pkg/fields"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/util/gormutil"
+)
+
+type accessTokens struct {
+	db *gorm.DB
+}
+
+var _ store.AccessTokenStore = &accessTokens{}
+
+func newAccessTokens(ds *datastore) *accessTokens {
+	return &accessTokens{ds.db}
+}
+
+// Create 创建一个新的访问令牌
+func (a *accessTokens) Create(ctx context.Context, token *modelv1.AccessToken, opts metav1.CreateOptions) error {
+	return a.db.WithContext(ctx).Create(token).Error
+}
+
+// Delete 删除用户的访问令牌
+func (a *accessTokens) Delete(ctx context.Context, username, name string, opts metav1.DeleteOptions) error {
+	err := a.db.WithContext(ctx).Where("username = ? and name = ?", username, name).Delete(&modelv1.AccessToken{}).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// Get 返回用户指定名称的访问令牌
+func (a *accessTokens) Get(
+	ctx context.Context,
+	username, name string,
+	opts metav1.GetOptions,
+) (*modelv1.AccessToken, error) {
+	token := modelv1.AccessToken{}
+	err := a.db.WithContext(ctx).Where("username = ? and name = ?", username, name).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithCode(code.ErrAccessTokenNotFound, err.Error())
+		}
+
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return &token, nil
+}
+
+// GetByHash 根据令牌哈希值查找访问令牌
+func (a *accessTokens) GetByHash(ctx context.Context, hash string) (*modelv1.AccessToken, error) {
+	token := modelv1.AccessToken{}
+	err := a.db.WithContext(ctx).Where("tokenHash = ?", hash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithCode(code.ErrAccessTokenNotFound, err.Error())
+		}
+
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return &token, nil
+}
+
+// List 返回用户所有的访问令牌
+func (a *accessTokens) List(
+	ctx context.Context,
+	username string,
+	opts metav1.ListOptions,
+) (*modelv1.AccessTokenList, error) {
+	ret := &modelv1.AccessTokenList{}
+	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
+
+	selector, _ := fields.ParseSelector(opts.FieldSelector)
+	name, _ := selector.RequiresExactMatch("name")
+
+	d := a.db.WithContext(ctx).Where("username = ? and name like ?", username, "%"+name+"%").
+		Offset(ol.Offset).
+		Limit(ol.Limit).
+		Order("id desc").
+		Find(&ret.Items).
+		Offset(-1).
+		Limit(-1).
+		Count(&ret.TotalCount)
+
+	return ret, d.Error
+}
+
+// UpdateLastUsed 记录令牌最后一次使用的时间和IP
+func (a *accessTokens) UpdateLastUsed(ctx context.Context, id uint64, at time.Time, ip string) error {
+	return a.db.WithContext(ctx).Model(&modelv1.AccessToken{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"lastUsedAt": at, "lastUsedIP": ip}).Error
+}
@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/pkg/util/gormutil"
+)
+
+type audits struct {
+	db *gorm.DB
+}
+
+func newAudits(ds *datastore) *audits {
+	return &audits{ds.db}
+}
+
+// Create 保存一条审计记录
+func (a *audits) Create(ctx context.Context, event *modelv1.AuditEvent) error {
+	return a.db.WithContext(ctx).Create(event).Error
+}
+
+// List 按条件查询审计记录
+func (a *audits) List(ctx context.Context, opts modelv1.AuditListOptions) (*modelv1.AuditEventList, error) {
+	ret := &modelv1.AuditEventList{}
+	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
+
+	d := a.db.WithContext(ctx).Model(&modelv1.AuditEvent{})
+	if opts.Username != "" {
+		d = d.Where("username = ?", opts.Username)
+	}
+
+	if opts.Resource != "" {
+		d = d.Where("resource = ?", opts.Resource)
+	}
+
+	if opts.ResourceName != "" {
+		d = d.Where("resourceName = ?", opts.ResourceName)
+	}
+
+	if opts.Since != nil {
+		d = d.Where("createdAt >= ?", *opts.Since)
+	}
+
+	if opts.Until != nil {
+		d = d.Where("createdAt < ?", *opts.Until)
+	}
+
+	d = d.Offset(ol.Offset).
+		Limit(ol.Limit).
+		Order("id desc").
+		Find(&ret.Items).
+		Offset(-1).
+		Limit(-1).
+		Count(&ret.TotalCount)
+
+	return ret, d.Error
+}
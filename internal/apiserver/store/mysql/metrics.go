@@ -0,0 +1,141 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/marmotedu/api/apiserver/v1"
+	"github.com/marmotedu/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 采集资源数量时单次查询的最长时间.
+const countTimeout = 3 * time.Second
+
+// gorm回调中保存开始时间的key.
+const startTimeKey = "metrics:start_time"
+
+var gormQuerySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "iam_gorm_query_duration_seconds",
+	Help:    "Histogram of gorm operation latency (seconds), by operation and table.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table"})
+
+// resourceCollector 在采集时查询用户、密钥和策略的数量.
+type resourceCollector struct {
+	db   *gorm.DB
+	desc *prometheus.Desc
+}
+
+var _ prometheus.Collector = &resourceCollector{}
+
+func (r *resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.desc
+}
+
+func (r *resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts := []struct {
+		kind  string
+		query *gorm.DB
+	}{
+		{"user", r.db.WithContext(ctx).Model(&v1.User{}).Where("status = 1")},
+		{"secret", r.db.WithContext(ctx).Model(&v1.Secret{})},
+		{"policy", r.db.WithContext(ctx).Model(&v1.Policy{})},
+	}
+
+	for _, c := range counts {
+		var count int64
+		if err := c.query.Count(&count).Error; err != nil {
+			log.Warnf("count %s for metrics failed: %s", c.kind, err.Error())
+
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(r.desc, prometheus.GaugeValue, float64(count), c.kind)
+	}
+}
+
+// registerMetrics 注册连接池、资源数量和gorm操作耗时的指标.
+func registerMetrics(db *gorm.DB, database string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	resources := &resourceCollector{
+		// 定期采集的查询不输出到sql日志中
+		db: db.Session(&gorm.Session{NewDB: true, Logger: db.Logger.LogMode(logger.Silent)}),
+		desc: prometheus.NewDesc("iam_resources", "Number of users, secrets and policies.",
+			[]string{"kind"}, nil),
+	}
+
+	for _, c := range []prometheus.Collector{
+		collectors.NewDBStatsCollector(sqlDB, database),
+		resources,
+		gormQuerySeconds,
+	} {
+		if err := prometheus.Register(c); err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			return err
+		}
+	}
+
+	return registerCallbacks(db)
+}
+
+// registerCallbacks 在gorm的每种操作前后记录耗时.
+func registerCallbacks(db *gorm.DB) error {
+	before := func(db *gorm.DB) {
+		db.InstanceSet(startTimeKey, time.Now())
+	}
+
+	after := func(operation string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			v, ok := db.InstanceGet(startTimeKey)
+			if !ok {
+				return
+			}
+
+			start, ok := v.(time.Time)
+			if !ok {
+				return
+			}
+
+			table := db.Statement.Table
+			if table == "" {
+				table = "unknown"
+			}
+
+			gormQuerySeconds.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
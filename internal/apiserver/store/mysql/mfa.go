@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+)
+
+type mfa struct {
+	db *gorm.DB
+}
+
+var _ store.MFAStore = &mfa{}
+
+func newMFA(ds *datastore) *mfa {
+	return &mfa{ds.db}
+}
+
+// Get 返回用户的MFA配置，用户没有登记过MFA时返回ErrMFANotEnrolled.
+func (m *mfa) Get(ctx context.Context, username string) (*modelv1.UserMFA, error) {
+	ret := modelv1.UserMFA{}
+	err := m.db.WithContext(ctx).Where("username = ?", username).First(&ret).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithCode(code.ErrMFANotEnrolled, err.Error())
+		}
+
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return &ret, nil
+}
+
+// Save 创建或者更新用户的MFA配置.
+func (m *mfa) Save(ctx context.Context, mfa *modelv1.UserMFA) error {
+	if err := m.db.WithContext(ctx).Save(mfa).Error; err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// Delete 删除用户的MFA配置.
+func (m *mfa) Delete(ctx context.Context, username string) error {
+	err := m.db.WithContext(ctx).Where("username = ?", username).Delete(&modelv1.UserMFA{}).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// DeleteCollection 批量删除用户的MFA配置.
+func (m *mfa) DeleteCollection(ctx context.Context, usernames []string) error {
+	err := m.db.WithContext(ctx).Where("username in (?)", usernames).Delete(&modelv1.UserMFA{}).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// UseStep 在step大于最后一次使用的时间窗口时更新lastUsedStep，返回false表示该时间窗口已经被使用过.
+// 使用条件更新，并发校验同一个验证码时只有一个能够成功.
+func (m *mfa) UseStep(ctx context.Context, username string, step int64) (bool, error) {
+	d := m.db.WithContext(ctx).Model(&modelv1.UserMFA{}).
+		Where("username = ? and lastUsedStep < ?", username, step).
+		Update("lastUsedStep", step)
+	if d.Error != nil {
+		return false, errors.WithCode(code.ErrDatabase, d.Error.Error())
+	}
+
+	return d.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes 在恢复码仍然是old时替换为codes，返回false表示恢复码已经被其他请求修改.
+func (m *mfa) ReplaceRecoveryCodes(ctx context.Context, username, old, codes string) (bool, error) {
+	d := m.db.WithContext(ctx).Model(&modelv1.UserMFA{}).
+		Where("username = ? and recoveryCodes = ?", username, old).
+		Update("recoveryCodes", codes)
+	if d.Error != nil {
+		return false, errors.WithCode(code.ErrDatabase, d.Error.Error())
+	}
+
+	return d.RowsAffected == 1, nil
+}
@@ -1,9 +1,11 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
+	"github.com/cuizhaoyue/iams/internal/pkg/gormtracing"
 	"github.com/cuizhaoyue/iams/internal/pkg/logger"
 	"github.com/cuizhaoyue/iams/pkg/db"
 
@@ -35,6 +37,43 @@ func (ds *datastore) PolicyAudit() store.PolicyAuditStore {
 	return newPolicyAudit(ds)
 }
 
+func (ds *datastore) MFA() store.MFAStore {
+	return newMFA(ds)
+}
+
+func (ds *datastore) AccessTokens() store.AccessTokenStore {
+	return newAccessTokens(ds)
+}
+
+func (ds *datastore) OAuthClients() store.OAuthClientStore {
+	return newOAuthClients(ds)
+}
+
+func (ds *datastore) ResourceChanges() store.ResourceChangeStore {
+	return newResourceChanges(ds)
+}
+
+func (ds *datastore) Audits() store.AuditStore {
+	return newAudits(ds)
+}
+
+// Transaction 在一个事务中执行fn，fn返回错误时回滚. fn中只能使用传入的Factory.
+func (ds *datastore) Transaction(ctx context.Context, fn func(tx store.Factory) error) error {
+	return ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&datastore{tx})
+	})
+}
+
+// Ping 检查数据库连接是否可用.
+func (ds *datastore) Ping(ctx context.Context) error {
+	db, err := ds.db.DB()
+	if err != nil {
+		return errors.Wrap(err, "get gorm db instance failed")
+	}
+
+	return db.PingContext(ctx)
+}
+
 func (ds *datastore) Close() error {
 	db, err := ds.db.DB()
 	if err != nil {
@@ -74,6 +113,17 @@ func GetMySQLFactoryOr(opts *genericoptions.MySQLOptions) (store.Factory, error)
 		}
 
 		dbIns, err = db.New(options)
+		if err != nil {
+			return
+		}
+
+		if err = registerMetrics(dbIns, opts.Database); err != nil {
+			return
+		}
+
+		if err = dbIns.Use(gormtracing.New(opts.Database)); err != nil {
+			return
+		}
 
 		mysqlFactory = &datastore{db: dbIns}
 	})
@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/marmotedu/component-base/pkg/fields"
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/util/gormutil"
+)
+
+type oauthClients struct {
+	db *gorm.DB
+}
+
+var _ store.OAuthClientStore = &oauthClients{}
+
+func newOAuthClients(ds *datastore) *oauthClients {
+	return &oauthClients{ds.db}
+}
+
+// Create 注册一个新的OAuth2客户端
+func (o *oauthClients) Create(ctx context.Context, client *modelv1.OAuthClient, opts metav1.CreateOptions) error {
+	return o.db.WithContext(ctx).Create(client).Error
+}
+
+// Update 更新OAuth2客户端信息
+func (o *oauthClients) Update(ctx context.Context, client *modelv1.OAuthClient, opts metav1.UpdateOptions) error {
+	return o.db.WithContext(ctx).Save(client).Error
+}
+
+// Delete 删除OAuth2客户端
+func (o *oauthClients) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	err := o.db.WithContext(ctx).Where("name = ?", name).Delete(&modelv1.OAuthClient{}).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// Get 根据名称返回OAuth2客户端
+func (o *oauthClients) Get(ctx context.Context, name string, opts metav1.GetOptions) (*modelv1.OAuthClient, error) {
+	return o.first(ctx, "name = ?", name)
+}
+
+// GetByClientID 根据client_id返回OAuth2客户端
+func (o *oauthClients) GetByClientID(ctx context.Context, clientID string) (*modelv1.OAuthClient, error) {
+	return o.first(ctx, "clientID = ?", clientID)
+}
+
+// List 返回所有的OAuth2客户端
+func (o *oauthClients) List(ctx context.Context, opts metav1.ListOptions) (*modelv1.OAuthClientList, error) {
+	ret := &modelv1.OAuthClientList{}
+	ol := gormutil.Unpointer(opts.Offset, opts.Limit)
+
+	selector, _ := fields.ParseSelector(opts.FieldSelector)
+	name, _ := selector.RequiresExactMatch("name")
+
+	d := o.db.WithContext(ctx).Where("name like ?", "%"+name+"%").
+		Offset(ol.Offset).
+		Limit(ol.Limit).
+		Order("id desc").
+		Find(&ret.Items).
+		Offset(-1).
+		Limit(-1).
+		Count(&ret.TotalCount)
+
+	return ret, d.Error
+}
+
+func (o *oauthClients) first(ctx context.Context, query string, args ...interface{}) (*modelv1.OAuthClient, error) {
+	client := modelv1.OAuthClient{}
+
+	err := o.db.WithContext(ctx).Where(query, args...).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithCode(code.ErrOAuthClientNotFound, err.Error())
+		}
+
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return &client, nil
+}
@@ -22,7 +22,7 @@ func newPolicyAudit(ds *datastore) *policyAudit {
 func (p *policyAudit) ClearOutdated(ctx context.Context, maxReserveDays int) (int64, error) {
 	date := time.Now().AddDate(0, 0, -maxReserveDays).Format(time.DateTime)
 
-	d := p.db.Exec("delete from policy_audit where deletedAt < ?", date)
+	d := p.db.WithContext(ctx).Exec("delete from policy_audit where deletedAt < ?", date)
 
 	return d.RowsAffected, d.Error
 }
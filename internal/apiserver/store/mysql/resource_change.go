@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+)
+
+type resourceChanges struct {
+	db *gorm.DB
+}
+
+var _ store.ResourceChangeStore = &resourceChanges{}
+
+func newResourceChanges(ds *datastore) *resourceChanges {
+	return &resourceChanges{ds.db}
+}
+
+// Create 记录一次资源变更，revision由数据库生成.
+func (r *resourceChanges) Create(ctx context.Context, change *modelv1.ResourceChange) error {
+	if err := r.db.WithContext(ctx).Create(change).Error; err != nil {
+		return errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return nil
+}
+
+// List 按revision升序返回大于revision的变更.
+func (r *resourceChanges) List(ctx context.Context, revision int64, limit int) ([]*modelv1.ResourceChange, error) {
+	var changes []*modelv1.ResourceChange
+
+	err := r.db.WithContext(ctx).Where("id > ?", revision).Order("id").Limit(limit).Find(&changes).Error
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return changes, nil
+}
+
+// ListRevisions 按revision升序返回指定revision的变更.
+func (r *resourceChanges) ListRevisions(ctx context.Context, revisions []int64) ([]*modelv1.ResourceChange, error) {
+	var changes []*modelv1.ResourceChange
+	if len(revisions) == 0 {
+		return changes, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("id IN ?", revisions).Order("id").Find(&changes).Error
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return changes, nil
+}
+
+// Bounds 返回保留的最小和最大revision.
+func (r *resourceChanges) Bounds(ctx context.Context) (int64, int64, error) {
+	var bounds struct {
+		Min int64
+		Max int64
+	}
+
+	err := r.db.WithContext(ctx).Model(&modelv1.ResourceChange{}).
+		Select("COALESCE(MIN(id), 0) AS min, COALESCE(MAX(id), 0) AS max").Scan(&bounds).Error
+	if err != nil {
+		return 0, 0, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return bounds.Min, bounds.Max, nil
+}
+
+// ClearOutdated 清理超过保留天数的变更记录.
+func (r *resourceChanges) ClearOutdated(ctx context.Context, maxReserveDays int) (int64, error) {
+	date := time.Now().AddDate(0, 0, -maxReserveDays).Format(time.DateTime)
+
+	d := r.db.WithContext(ctx).Exec("delete from resource_change where createdAt < ?", date)
+
+	return d.RowsAffected, d.Error
+}
@@ -27,14 +27,14 @@ func newUsers(ds *datastore) *users {
 }
 
 func (u *users) Create(ctx context.Context, user *v1.User, opts metav1.CreateOptions) error {
-	return u.db.Create(user).Error
+	return u.db.WithContext(ctx).Create(user).Error
 }
 
 func (u *users) Update(ctx context.Context, user *v1.User, opts metav1.UpdateOptions) error {
-	return u.db.Save(user).Error
+	return u.db.WithContext(ctx).Save(user).Error
 }
 
-// Delete 删除用户以及对应的策略
+// Delete 删除用户以及对应的策略和MFA配置
 func (u *users) Delete(ctx context.Context, username string, opts metav1.DeleteOptions) error {
 	// 	先删除用户对应的policy
 	pol := newPolicies(&datastore{u.db})
@@ -42,13 +42,18 @@ func (u *users) Delete(ctx context.Context, username string, opts metav1.DeleteO
 		return err
 	}
 
+	// 删除用户的MFA配置，避免之后创建的同名用户继承原来的密钥和恢复码
+	if err := newMFA(&datastore{u.db}).Delete(ctx, username); err != nil {
+		return err
+	}
+
 	// 检测是否永久删除
 	if opts.Unscoped {
 		u.db = u.db.Unscoped()
 	}
 
 	// 删除用户
-	err := u.db.Where("name = ?", username).Delete(&v1.User{}).Error
+	err := u.db.WithContext(ctx).Where("name = ?", username).Delete(&v1.User{}).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return errors.WithCode(code.ErrDatabase, err.Error())
 	}
@@ -64,17 +69,21 @@ func (u *users) DeleteCollection(ctx context.Context, usernames []string, opts m
 		return err
 	}
 
+	if err := newMFA(&datastore{u.db}).DeleteCollection(ctx, usernames); err != nil {
+		return err
+	}
+
 	if opts.Unscoped {
 		u.db = u.db.Unscoped()
 	}
 
-	return u.db.Where("name in (?)", usernames).Delete(&v1.User{}).Error
+	return u.db.WithContext(ctx).Where("name in (?)", usernames).Delete(&v1.User{}).Error
 }
 
 // Get 返回用户详情
 func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error) {
 	user := v1.User{}
-	err := u.db.Where("name = ? and status = 1", username).First(&user).Error
+	err := u.db.WithContext(ctx).Where("name = ? and status = 1", username).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.WithCode(code.ErrUserNotFound, err.Error())
@@ -86,6 +95,37 @@ func (u *users) Get(ctx context.Context, username string, opts metav1.GetOptions
 	return &user, nil
 }
 
+// GetIncludingDisabled 返回用户详情，和Get不同，被禁用的用户也会返回.
+func (u *users) GetIncludingDisabled(ctx context.Context, username string) (*v1.User, error) {
+	user := v1.User{}
+	err := u.db.WithContext(ctx).Where("name = ?", username).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithCode(code.ErrUserNotFound, err.Error())
+		}
+
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return &user, nil
+}
+
+// ListByPassword 按id升序返回password字段等于password并且id大于afterID的用户，包括被禁用的用户.
+// 按id分页，分页过程中修改用户状态不会导致跳过用户.
+func (u *users) ListByPassword(ctx context.Context, password string, afterID uint64, limit int) ([]*v1.User, error) {
+	var ret []*v1.User
+
+	err := u.db.WithContext(ctx).Where("password = ? and id > ?", password, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&ret).Error
+	if err != nil {
+		return nil, errors.WithCode(code.ErrDatabase, err.Error())
+	}
+
+	return ret, nil
+}
+
 // List 返回用户列表
 func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error) {
 	ret := &v1.UserList{}
@@ -94,7 +134,7 @@ func (u *users) List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList
 	selector, _ := fields.ParseSelector(opts.FieldSelector)
 	username, _ := selector.RequiresExactMatch("name")
 
-	d := u.db.Where("name like ? and status = 1", "%"+username+"%").
+	d := u.db.WithContext(ctx).Where("name like ? and status = 1", "%"+username+"%").
 		Offset(ol.Offset).
 		Limit(ol.Limit).
 		Order("id desc").
@@ -121,7 +161,7 @@ func (u *users) ListOptional(ctx context.Context, opts metav1.ListOptions) (*v1.
 		where.Name = username
 	}
 
-	d := u.db.Where(where).
+	d := u.db.WithContext(ctx).Where(where).
 		Not(whereNot).
 		Offset(ol.Offset).
 		Limit(ol.Limit).
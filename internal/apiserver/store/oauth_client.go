@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+
+	metav1 "github.com/marmotedu/component-base/pkg/meta/v1"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+)
+
+// OAuthClientStore 定义了OAuth2客户端的存储接口.
+type OAuthClientStore interface {
+	Create(ctx context.Context, client *modelv1.OAuthClient, opts metav1.CreateOptions) error
+	Update(ctx context.Context, client *modelv1.OAuthClient, opts metav1.UpdateOptions) error
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*modelv1.OAuthClient, error)
+	GetByClientID(ctx context.Context, clientID string) (*modelv1.OAuthClient, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*modelv1.OAuthClientList, error)
+}
@@ -0,0 +1,19 @@
+package store
+
+import (
+	"context"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+)
+
+// ResourceChangeStore 定义了资源变更记录的存储接口.
+type ResourceChangeStore interface {
+	Create(ctx context.Context, change *modelv1.ResourceChange) error
+	// List 按revision升序返回大于revision的最多limit条变更.
+	List(ctx context.Context, revision int64, limit int) ([]*modelv1.ResourceChange, error)
+	// ListRevisions 按revision升序返回指定revision的变更，不存在的revision被忽略.
+	ListRevisions(ctx context.Context, revisions []int64) ([]*modelv1.ResourceChange, error)
+	// Bounds 返回保留的最小和最大revision，没有记录时都为0.
+	Bounds(ctx context.Context) (int64, int64, error)
+	ClearOutdated(ctx context.Context, maxReserveDays int) (int64, error)
+}
@@ -14,5 +14,6 @@ type SecretStore interface {
 	Delete(ctx context.Context, username, secretID string, opts metav1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, username string, secretIDs []string, opts metav1.DeleteOptions) error
 	Get(ctx context.Context, username, secretID string, opts metav1.GetOptions) (*v1.Secret, error)
+	GetBySecretID(ctx context.Context, secretID string) (*v1.Secret, error)
 	List(ctx context.Context, username string, opts metav1.ListOptions) (*v1.SecretList, error)
 }
@@ -1,5 +1,7 @@
 package store
 
+import "context"
+
 var client Factory
 
 type Factory interface {
@@ -7,6 +9,14 @@ type Factory interface {
 	Secrets() SecretStore
 	Polices() PolicyStore
 	PolicyAudit() PolicyAuditStore
+	MFA() MFAStore
+	AccessTokens() AccessTokenStore
+	OAuthClients() OAuthClientStore
+	ResourceChanges() ResourceChangeStore
+	Audits() AuditStore
+	// Transaction 在一个事务中执行fn，fn返回错误时回滚.
+	Transaction(ctx context.Context, fn func(tx Factory) error) error
+	Ping(ctx context.Context) error
 	Close() error
 }
 
@@ -15,4 +15,6 @@ type UserStore interface {
 	DeleteCollection(ctx context.Context, usernames []string, opts metav1.DeleteOptions) error
 	Get(ctx context.Context, username string, opts metav1.GetOptions) (*v1.User, error)
 	List(ctx context.Context, opts metav1.ListOptions) (*v1.UserList, error)
+	GetIncludingDisabled(ctx context.Context, username string) (*v1.User, error)
+	ListByPassword(ctx context.Context, password string, afterID uint64, limit int) ([]*v1.User, error)
 }
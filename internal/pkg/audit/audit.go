@@ -0,0 +1,121 @@
+// Package audit 记录修改资源的请求，并异步写入文件、数据库或者webhook.
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 单个sink写入一条记录的最长时间.
+const writeTimeout = 10 * time.Second
+
+var (
+	eventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "iam_audit_events_dropped_total",
+		Help: "Total number of audit events dropped because the buffer is full.",
+	})
+
+	sinkErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "iam_audit_sink_errors_total",
+		Help: "Total number of audit events that failed to be written, by sink.",
+	}, []string{"sink"})
+)
+
+func init() {
+	prometheus.MustRegister(eventsDropped, sinkErrors)
+}
+
+// Sink 保存审计记录.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, event *modelv1.AuditEvent) error
+	Close() error
+}
+
+// Auditor 在后台把审计记录依次写入所有的sink，不阻塞请求的处理.
+type Auditor struct {
+	sinks  []Sink
+	events chan *modelv1.AuditEvent
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New 创建Auditor并启动后台写入，缓冲区满时丢弃新的记录.
+func New(bufferSize int, sinks ...Sink) *Auditor {
+	a := &Auditor{
+		sinks:  sinks,
+		events: make(chan *modelv1.AuditEvent, bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	go a.run()
+
+	return a
+}
+
+// Record 提交一条审计记录，Close之后提交的记录会被丢弃.
+func (a *Auditor) Record(event *modelv1.AuditEvent) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		eventsDropped.Inc()
+
+		return
+	}
+
+	select {
+	case a.events <- event:
+	default:
+		eventsDropped.Inc()
+		log.Warnf("audit buffer is full, drop event of %s %s", event.Method, event.Path)
+	}
+}
+
+// Close 等待缓冲区中的记录写完后关闭所有的sink.
+func (a *Auditor) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+
+		return nil
+	}
+
+	a.closed = true
+	close(a.events)
+	a.mu.Unlock()
+
+	<-a.done
+
+	var lastErr error
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+func (a *Auditor) run() {
+	defer close(a.done)
+
+	for event := range a.events {
+		for _, sink := range a.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+			if err := sink.Write(ctx, event); err != nil {
+				sinkErrors.WithLabelValues(sink.Name()).Inc()
+				log.Errorf("write audit event to %s failed: %s", sink.Name(), err.Error())
+			}
+			cancel()
+		}
+	}
+}
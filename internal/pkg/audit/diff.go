@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cuizhaoyue/iams/pkg/redact"
+)
+
+// change 是一个字段修改前后的值，不存在的一方为nil.
+type change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Diff 比较资源修改前后的json表示，返回{"字段路径": {"old": 旧值, "new": 新值}}，数组作为整体比较.
+// 敏感字段的值被隐藏，但仍然记录它们被修改过. 没有差异时返回nil.
+func Diff(before, after interface{}, fields redact.Fields) json.RawMessage {
+	old, err := flatten(before)
+	if err != nil {
+		return nil
+	}
+
+	cur, err := flatten(after)
+	if err != nil {
+		return nil
+	}
+
+	paths := make(map[string]struct{}, len(old)+len(cur))
+	for path := range old {
+		paths[path] = struct{}{}
+	}
+
+	for path := range cur {
+		paths[path] = struct{}{}
+	}
+
+	changes := make(map[string]change)
+	for path := range paths {
+		o, n := old[path], cur[path]
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+
+		if sensitive(path, fields) {
+			o, n = mask(o), mask(n)
+		} else {
+			o, n = redact.Value(o, fields), redact.Value(n, fields)
+		}
+
+		changes[path] = change{Old: o, New: n}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// flatten 把对象转换为以点分隔的字段路径到值的映射.
+func flatten(v interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return out, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	walk("", generic, out)
+
+	return out, nil
+}
+
+func walk(prefix string, v interface{}, out map[string]interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		out[prefix] = v
+
+		return
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		walk(path, m[k], out)
+	}
+}
+
+func sensitive(path string, fields redact.Fields) bool {
+	for _, name := range strings.Split(path, ".") {
+		if fields.Has(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mask(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return redact.Mask
+}
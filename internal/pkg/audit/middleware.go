@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/redact"
+)
+
+const snapshotKey = "auditSnapshot"
+
+// MaxBodyBytes为0时读取的请求体的最大长度，超过时只记录长度.
+const maxCaptureBytes = 1 << 20
+
+var versionSegment = regexp.MustCompile(`^v\d+$`)
+
+// Loader 读取请求所修改的资源的当前状态，资源不存在时返回nil.
+type Loader func(c *gin.Context) (interface{}, error)
+
+// Config 是审计中间件的配置.
+type Config struct {
+	// RedactFields 记录请求体和差异时需要隐藏的字段.
+	RedactFields redact.Fields
+	// MaxBodyBytes 记录的请求体的最大长度，超过时只记录长度，为0时使用1MiB.
+	MaxBodyBytes int
+	// ExcludeRoutes 不记录的路由，例如: /v1/token/introspect.
+	ExcludeRoutes []string
+}
+
+type snapshot struct {
+	before interface{}
+	load   Loader
+}
+
+// Middleware 记录所有POST、PUT、PATCH和DELETE请求，请求处理完成之后提交给Auditor.
+func Middleware(a *Auditor, cfg Config) gin.HandlerFunc {
+	excluded := make(map[string]struct{}, len(cfg.ExcludeRoutes))
+	for _, route := range cfg.ExcludeRoutes {
+		excluded[route] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if !mutating(c.Request.Method) {
+			c.Next()
+
+			return
+		}
+
+		route := c.FullPath()
+		if _, ok := excluded[route]; ok || route == "" {
+			c.Next()
+
+			return
+		}
+
+		body, size := readBody(c, captureLimit(cfg.MaxBodyBytes))
+
+		c.Next()
+
+		event := &modelv1.AuditEvent{
+			Username:     c.GetString(middleware.UsernameKey),
+			AuthMethod:   c.GetString(middleware.AuthMethodKey),
+			ClientIP:     c.ClientIP(),
+			RemoteIP:     c.RemoteIP(),
+			RequestID:    c.Writer.Header().Get(middleware.XRequestIDKey),
+			Method:       c.Request.Method,
+			Route:        route,
+			Path:         c.Request.URL.Path,
+			Resource:     resource(route),
+			ResourceName: resourceName(c, body),
+			StatusCode:   c.Writer.Status(),
+			Request:      requestBody(c, body, size, cfg),
+			CreatedAt:    time.Now(),
+		}
+
+		if v, ok := c.Get(snapshotKey); ok {
+			s, _ := v.(*snapshot)
+			after, err := s.load(c)
+			if err != nil {
+				log.L(c).Warnf("load resource after %s %s failed: %s", c.Request.Method, route, err.Error())
+			} else {
+				event.Diff = Diff(s.before, after, cfg.RedactFields)
+			}
+		}
+
+		a.Record(event)
+	}
+}
+
+// Snapshot 在请求处理之前读取资源的状态，用于计算请求前后的差异. 需要在认证中间件之后使用，
+// 以便Loader获取当前用户. loaders的键为路由，没有Loader的路由不记录差异.
+func Snapshot(loaders map[string]Loader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		load, ok := loaders[c.FullPath()]
+		if !ok || !mutating(c.Request.Method) {
+			c.Next()
+
+			return
+		}
+
+		before, err := load(c)
+		if err != nil {
+			log.L(c).Warnf("load resource before %s %s failed: %s", c.Request.Method, c.FullPath(), err.Error())
+		} else {
+			c.Set(snapshotKey, &snapshot{before: before, load: load})
+		}
+
+		c.Next()
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// captureLimit 返回读取请求体的最大长度，MaxBodyBytes为0时使用maxCaptureBytes.
+func captureLimit(maxBodyBytes int) int {
+	if maxBodyBytes > 0 && maxBodyBytes < maxCaptureBytes {
+		return maxBodyBytes
+	}
+
+	return maxCaptureBytes
+}
+
+// readBody 最多读取limit+1字节的请求体，并和没有读取的部分一起重新放回请求中. 返回读取的内容和请求体的长度，
+// 超过limit时返回的内容不完整，长度未知时为读取的长度. 读取失败时返回nil.
+func readBody(c *gin.Context, limit int) ([]byte, int) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, 0
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	if err != nil {
+		return nil, 0
+	}
+
+	size := len(body)
+	if size > limit && c.Request.ContentLength > int64(size) {
+		size = int(c.Request.ContentLength)
+	}
+
+	return body, size
+}
+
+// requestBody 返回隐藏了敏感字段的请求体，表单转换为json对象，超过长度限制或者其他格式只记录长度.
+func requestBody(c *gin.Context, body []byte, size int, cfg Config) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+
+	if len(body) > captureLimit(cfg.MaxBodyBytes) {
+		return omitted(size)
+	}
+
+	if data, ok := redact.JSON(body, cfg.RedactFields); ok {
+		return data
+	}
+
+	if strings.HasPrefix(c.ContentType(), gin.MIMEPOSTForm) {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			if data, err := json.Marshal(redact.Form(values, cfg.RedactFields)); err == nil {
+				return data
+			}
+		}
+	}
+
+	return omitted(len(body))
+}
+
+func omitted(n int) json.RawMessage {
+	data, _ := json.Marshal(fmt.Sprintf("<%d bytes omitted>", n))
+
+	return data
+}
+
+// resource 返回路由中版本号之后、第一个参数之前的部分，例如:
+// /v1/users/:name -> users，/v1/oauth2/clients/:name/secret -> oauth2/clients.
+func resource(route string) string {
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	if len(segments) > 0 && versionSegment.MatchString(segments[0]) {
+		segments = segments[1:]
+	}
+
+	var names []string
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			break
+		}
+
+		names = append(names, segment)
+	}
+
+	return strings.Join(names, "/")
+}
+
+// resourceName 返回路由中的资源名，创建资源时从请求体的metadata.name中获取.
+func resourceName(c *gin.Context, body []byte) string {
+	if name := c.Param("name"); name != "" {
+		return name
+	}
+
+	var obj struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &obj) == nil {
+		return obj.Metadata.Name
+	}
+
+	return ""
+}
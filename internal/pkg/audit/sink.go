@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	modelv1 "github.com/cuizhaoyue/iams/internal/apiserver/model/v1"
+	"github.com/cuizhaoyue/iams/internal/apiserver/store"
+)
+
+// 支持的sink.
+const (
+	SinkFile    = "file"
+	SinkDB      = "db"
+	SinkWebhook = "webhook"
+)
+
+// Sinks 返回支持的sink.
+func Sinks() []string {
+	return []string{SinkFile, SinkDB, SinkWebhook}
+}
+
+// FileConfig 是文件sink的配置，文件达到MaxSize后轮转.
+type FileConfig struct {
+	Path string
+	// MaxSize 单个文件的最大大小，单位为MB.
+	MaxSize int
+	// MaxBackups 保留的轮转文件数量，为0时不限制.
+	MaxBackups int
+	// MaxAge 轮转文件的保留天数，为0时不限制.
+	MaxAge   int
+	Compress bool
+}
+
+type fileSink struct {
+	mu     sync.Mutex
+	logger *lumberjack.Logger
+}
+
+// NewFileSink 创建按行写入json格式记录的文件sink.
+func NewFileSink(cfg FileConfig) Sink {
+	return &fileSink{
+		logger: &lumberjack.Logger{
+			Filename:   cfg.Path,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		},
+	}
+}
+
+func (s *fileSink) Name() string {
+	return SinkFile
+}
+
+func (s *fileSink) Write(_ context.Context, event *modelv1.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.logger.Write(append(data, '\n'))
+
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.logger.Close()
+}
+
+type dbSink struct {
+	store store.AuditStore
+}
+
+// NewDBSink 创建保存到audit_event表的sink，/v1/audit从这张表中查询.
+func NewDBSink(store store.AuditStore) Sink {
+	return &dbSink{store: store}
+}
+
+func (s *dbSink) Name() string {
+	return SinkDB
+}
+
+func (s *dbSink) Write(ctx context.Context, event *modelv1.AuditEvent) error {
+	// 写入数据库会设置ID，不修改其他sink共用的记录
+	e := *event
+
+	return s.store.Create(ctx, &e)
+}
+
+func (s *dbSink) Close() error {
+	return nil
+}
+
+// WebhookConfig 是webhook sink的配置.
+type WebhookConfig struct {
+	URL     string
+	Timeout time.Duration
+	// Headers 请求附带的头，一般用于认证.
+	Headers map[string]string
+	// MaxRetries 发送失败后的最大重试次数.
+	MaxRetries int
+	// RetryInterval 第一次重试前的等待时间，之后每次重试加倍.
+	RetryInterval time.Duration
+}
+
+type webhookSink struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookSink 创建把每条记录POST到指定地址的sink，响应状态码不是2xx时重试.
+func NewWebhookSink(cfg WebhookConfig) Sink {
+	return &webhookSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (s *webhookSink) Name() string {
+	return SinkWebhook
+}
+
+func (s *webhookSink) Write(ctx context.Context, event *modelv1.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	interval := s.cfg.RetryInterval
+	for attempt := 0; ; attempt++ {
+		err = s.post(ctx, data)
+		if err == nil || attempt >= s.cfg.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+	}
+}
+
+func (s *webhookSink) post(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+
+	return nil
+}
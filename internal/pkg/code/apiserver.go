@@ -25,3 +25,51 @@ const (
 	// ErrPolicyNotFound - 404: Policy not found.
 	ErrPolicyNotFound int = iota + 110201
 )
+
+// iam-apiserver: mfa errors.
+const (
+	// ErrMFANotEnrolled - 404: MFA not enrolled.
+	ErrMFANotEnrolled int = iota + 110301
+
+	// ErrMFAAlreadyEnabled - 400: MFA already enabled.
+	ErrMFAAlreadyEnabled
+
+	// ErrMFACodeInvalid - 401: MFA code is invalid.
+	ErrMFACodeInvalid
+
+	// ErrMFARequired - 401: MFA verification required.
+	ErrMFARequired
+
+	// ErrMFALocked - 403: Too many failed MFA attempts.
+	ErrMFALocked
+)
+
+// iam-apiserver: access token errors.
+const (
+	// ErrAccessTokenNotFound - 404: Access token not found.
+	ErrAccessTokenNotFound int = iota + 110401
+
+	// ErrAccessTokenAlreadyExist - 400: Access token already exist.
+	ErrAccessTokenAlreadyExist
+
+	// ErrInsufficientScope - 403: Access token scope is insufficient.
+	ErrInsufficientScope
+)
+
+// iam-apiserver: session errors.
+const (
+	// ErrSessionNotFound - 404: Session not found.
+	ErrSessionNotFound int = iota + 110501
+)
+
+// iam-apiserver: oauth client errors.
+const (
+	// ErrOAuthClientNotFound - 404: OAuth client not found.
+	ErrOAuthClientNotFound int = iota + 110601
+
+	// ErrOAuthClientAlreadyExist - 400: OAuth client already exist.
+	ErrOAuthClientAlreadyExist
+
+	// ErrOAuthClientInvalid - 401: OAuth client authentication failed.
+	ErrOAuthClientInvalid
+)
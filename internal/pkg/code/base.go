@@ -23,6 +23,9 @@ const (
 
 	// ErrPageNotFound - 404: Page not found.
 	ErrPageNotFound
+
+	// ErrRequestTooLarge - 400: Request body too large.
+	ErrRequestTooLarge
 )
 
 // common: database errors.
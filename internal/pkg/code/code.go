@@ -79,12 +79,25 @@ func init() {
 	register(ErrReachMaxCount, 400, "Secrets reach the max count")
 	register(ErrSecretNotFound, 404, "Secrets not found")
 	register(ErrPolicyNotFound, 404, "Policy not found")
+	register(ErrMFANotEnrolled, 404, "MFA not enrolled")
+	register(ErrMFAAlreadyEnabled, 400, "MFA already enabled")
+	register(ErrMFACodeInvalid, 401, "MFA code is invalid")
+	register(ErrMFARequired, 401, "MFA verification required")
+	register(ErrMFALocked, 403, "Too many failed MFA attempts")
+	register(ErrAccessTokenNotFound, 404, "Access token not found")
+	register(ErrAccessTokenAlreadyExist, 400, "Access token already exist")
+	register(ErrInsufficientScope, 403, "Access token scope is insufficient")
+	register(ErrSessionNotFound, 404, "Session not found")
+	register(ErrOAuthClientNotFound, 404, "OAuth client not found")
+	register(ErrOAuthClientAlreadyExist, 400, "OAuth client already exist")
+	register(ErrOAuthClientInvalid, 401, "OAuth client authentication failed")
 	register(ErrSuccess, 200, "OK")
 	register(ErrUnknown, 500, "Internal server error")
 	register(ErrBind, 400, "Error occurred while binding the request body to the struct")
 	register(ErrValidation, 400, "Validation failed")
 	register(ErrTokenInvalid, 401, "Token invalid")
 	register(ErrPageNotFound, 404, "Page not found")
+	register(ErrRequestTooLarge, 400, "Request body too large")
 	register(ErrDatabase, 500, "Database error")
 	register(ErrEncrypt, 401, "Error occurred while encrypting the user password")
 	register(ErrSignatureInvalid, 401, "Signature is invalid")
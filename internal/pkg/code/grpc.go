@@ -0,0 +1,88 @@
+package code
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/marmotedu/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCErrorDomain 是grpc错误详情ErrorInfo中的domain.
+const GRPCErrorDomain = "iam.apiserver"
+
+// 部分错误码有比HTTP状态码更准确的grpc状态码.
+var grpcCodes = map[int]codes.Code{
+	ErrUserAlreadyExist: codes.AlreadyExists,
+	ErrReachMaxCount:    codes.ResourceExhausted,
+	ErrDatabase:         codes.Internal,
+}
+
+// GRPCStatus 把错误转换为grpc状态. 和REST接口一样只返回对外的错误信息，错误码和参考文档放在状态详情中.
+func GRPCStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+
+	coder := errors.ParseCoder(err)
+
+	s := status.New(grpcCode(coder), coder.String())
+
+	// Reason是业务错误码，和REST接口返回的code相同
+	info := &errdetails.ErrorInfo{Reason: strconv.Itoa(coder.Code()), Domain: GRPCErrorDomain}
+
+	detailed, err := s.WithDetails(info)
+	if coder.Reference() != "" {
+		detailed, err = s.WithDetails(info, &errdetails.Help{
+			Links: []*errdetails.Help_Link{{Description: coder.String(), Url: coder.Reference()}},
+		})
+	}
+
+	if err != nil {
+		return s
+	}
+
+	return detailed
+}
+
+// GRPCError 返回错误对应的grpc状态错误，err为nil时返回nil.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return GRPCStatus(err).Err()
+}
+
+// grpcCode 返回错误码对应的grpc状态码，没有单独指定时根据HTTP状态码转换.
+func grpcCode(coder errors.Coder) codes.Code {
+	if c, ok := grpcCodes[coder.Code()]; ok {
+		return c
+	}
+
+	switch coder.HTTPStatus() {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusInternalServerError:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
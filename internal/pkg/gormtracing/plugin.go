@@ -0,0 +1,104 @@
+// Package gormtracing defines gorm tracing plugin
+package gormtracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/cuizhaoyue/iams/pkg/tracing"
+)
+
+const spanKey = "tracing:span"
+
+var tracer = tracing.Tracer("github.com/cuizhaoyue/iams/internal/pkg/gormtracing")
+
+// Plugin 为每条sql创建span，父span来自db.WithContext传入的上下文，上下文中没有span时不创建，
+// 避免定期执行的查询产生大量单独的trace. span中只记录带占位符的sql，不记录参数.
+type Plugin struct {
+	// DBName 数据库名称，记录在span的db.name属性中.
+	DBName string
+}
+
+var _ gorm.Plugin = &Plugin{}
+
+// New 创建gorm链路追踪插件.
+func New(dbName string) *Plugin {
+	return &Plugin{DBName: dbName}
+}
+
+// Name 返回插件名称.
+func (p *Plugin) Name() string {
+	return "tracing"
+}
+
+// Initialize 注册创建和结束span的回调.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Plugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil ||
+			!trace.SpanContextFromContext(db.Statement.Context).IsValid() {
+			return
+		}
+
+		_, span := tracer.Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "mysql"),
+				attribute.String("db.name", p.DBName),
+				attribute.String("db.operation", operation),
+			),
+		)
+
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func after(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+
+	// 记录不存在是正常的业务结果
+	if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		tracing.RecordError(span, db.Error)
+	}
+}
@@ -0,0 +1,213 @@
+package interceptor
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware/auth"
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 认证方式.
+const (
+	AuthMethodBasic = "basic"
+	AuthMethodCert  = "cert"
+	AuthMethodToken = "token"
+)
+
+// Identity 是通过认证的调用方身份.
+type Identity struct {
+	Name   string
+	Method string
+}
+
+type identityKey struct{}
+
+// IdentityFrom 返回上下文中通过认证的调用方身份.
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+
+	return id, ok
+}
+
+// AuthConfig 定义grpc服务的认证配置.
+type AuthConfig struct {
+	// CertField 从客户端证书中获取身份的字段，取值同auth.CertUsername.
+	CertField string
+	// Cert 校验客户端证书中的身份是否可以调用指定方法，为nil时不做额外校验.
+	Cert func(ctx context.Context, method, name string) error
+	// Basic 校验用户名和密码，为nil时不接受basic认证.
+	Basic func(ctx context.Context, username, password string) error
+	// Token 校验调用指定方法时携带的bearer token并返回调用方身份，为nil时不接受token认证.
+	Token func(ctx context.Context, method, token string) (string, error)
+	// Allowed 允许访问RestrictedMethods的身份，为空时拒绝所有对这些方法的调用.
+	Allowed []string
+	// RestrictedMethods 只允许Allowed中的身份调用的方法前缀，其他方法只要求通过认证.
+	RestrictedMethods []string
+	// PublicMethods 不需要认证的方法前缀，例如健康检查服务.
+	PublicMethods []string
+}
+
+type authenticator struct {
+	certField  string
+	cert       func(ctx context.Context, method, name string) error
+	basic      func(ctx context.Context, username, password string) error
+	token      func(ctx context.Context, method, token string) (string, error)
+	allowed    map[string]struct{}
+	restricted []string
+	public     []string
+}
+
+func newAuthenticator(cfg AuthConfig) *authenticator {
+	allowed := make(map[string]struct{}, len(cfg.Allowed))
+	for _, name := range cfg.Allowed {
+		allowed[name] = struct{}{}
+	}
+
+	return &authenticator{
+		certField:  cfg.CertField,
+		cert:       cfg.Cert,
+		basic:      cfg.Basic,
+		token:      cfg.Token,
+		allowed:    allowed,
+		restricted: cfg.RestrictedMethods,
+		public:     cfg.PublicMethods,
+	}
+}
+
+// UnaryServerAuth 返回校验调用方身份的一元拦截器.
+func UnaryServerAuth(cfg AuthConfig) grpc.UnaryServerInterceptor {
+	a := newAuthenticator(cfg)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerAuth 返回校验调用方身份的流拦截器.
+func StreamServerAuth(cfg AuthConfig) grpc.StreamServerInterceptor {
+	a := newAuthenticator(cfg)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize 认证调用方，受限方法还要检查调用方是否在允许访问的身份中，成功时把身份保存到上下文.
+func (a *authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	if hasPrefix(method, a.public) {
+		return ctx, nil
+	}
+
+	id, err := a.authenticate(ctx, method)
+	if err != nil {
+		log.L(ctx).Warnf("grpc call %s unauthenticated: %s", method, err.Error())
+
+		return nil, err
+	}
+
+	if _, ok := a.allowed[id.Name]; !ok && hasPrefix(method, a.restricted) {
+		log.L(ctx).Warnf("grpc call %s from %s(%s) is not allowed", method, id.Name, id.Method)
+
+		return nil, status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s", id.Name, method)
+	}
+
+	ctx = context.WithValue(ctx, identityKey{}, id)
+
+	// log.L(ctx)使用字符串作为key读取用户名
+	return context.WithValue(ctx, log.KeyUsername, id.Name), nil //nolint:staticcheck
+}
+
+// authenticate 优先使用authorization metadata中的bearer token或者basic认证，没有时使用校验通过的客户端证书.
+func (a *authenticator) authenticate(ctx context.Context, method string) (Identity, error) {
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		parts := strings.SplitN(values[0], " ", 2)
+		if len(parts) != 2 {
+			return Identity{}, status.Error(codes.Unauthenticated, "authorization metadata format is wrong")
+		}
+
+		switch {
+		case strings.EqualFold(parts[0], "Bearer") && a.token != nil:
+			name, err := a.token(ctx, method, parts[1])
+			if err != nil {
+				return Identity{}, status.Errorf(codes.Unauthenticated, "invalid bearer token: %s", err.Error())
+			}
+
+			return Identity{Name: name, Method: AuthMethodToken}, nil
+		case strings.EqualFold(parts[0], "Basic") && a.basic != nil:
+			return a.authenticateBasic(ctx, parts[1])
+		default:
+			return Identity{}, status.Error(codes.Unauthenticated, "authorization metadata format is wrong")
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok &&
+			len(info.State.VerifiedChains) > 0 && len(info.State.VerifiedChains[0]) > 0 {
+			name, err := auth.CertUsername(info.State.VerifiedChains[0][0], a.certField)
+			if err != nil {
+				return Identity{}, status.Error(codes.Unauthenticated, err.Error())
+			}
+
+			if a.cert != nil {
+				if err := a.cert(ctx, method, name); err != nil {
+					return Identity{}, status.Error(codes.Unauthenticated, err.Error())
+				}
+			}
+
+			return Identity{Name: name, Method: AuthMethodCert}, nil
+		}
+	}
+
+	return Identity{}, status.Error(codes.Unauthenticated, "client certificate or authorization metadata is required")
+}
+
+// authenticateBasic 校验base64编码的用户名和密码.
+func (a *authenticator) authenticateBasic(ctx context.Context, encoded string) (Identity, error) {
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Identity{}, status.Error(codes.Unauthenticated, "authorization metadata format is wrong")
+	}
+
+	pair := strings.SplitN(string(payload), ":", 2)
+	if len(pair) != 2 {
+		return Identity{}, status.Error(codes.Unauthenticated, "authorization metadata format is wrong")
+	}
+
+	if err := a.basic(ctx, pair[0], pair[1]); err != nil {
+		log.L(ctx).Debugf("basic auth of %s failed: %s", pair[0], err.Error())
+
+		return Identity{}, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+
+	return Identity{Name: pair[0], Method: AuthMethodBasic}, nil
+}
+
+func hasPrefix(method string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
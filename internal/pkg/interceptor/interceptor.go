@@ -0,0 +1,35 @@
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Interceptor 包含同一功能的一元拦截器和流拦截器.
+type Interceptor struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// Interceptors 是可以通过名称启用的通用拦截器.
+var Interceptors = defaultInterceptors()
+
+func defaultInterceptors() map[string]Interceptor {
+	return map[string]Interceptor{
+		"recovery":  {UnaryServerRecovery(), StreamServerRecovery()},
+		"requestid": {UnaryServerRequestID(), StreamServerRequestID()},
+		"logger":    {UnaryServerLogger(), StreamServerLogger()},
+		"metrics":   {UnaryServerMetrics(), StreamServerMetrics()},
+	}
+}
+
+// wrappedStream 用于替换流的上下文.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
@@ -0,0 +1,57 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// UnaryServerLogger 返回记录访问日志的一元拦截器.
+func UnaryServerLogger() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		accessLog(ctx, info.FullMethod, "unary", start, err)
+
+		return resp, err
+	}
+}
+
+// StreamServerLogger 返回记录访问日志的流拦截器，在流结束时记录.
+func StreamServerLogger() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		accessLog(ss.Context(), info.FullMethod, "stream", start, err)
+
+		return err
+	}
+}
+
+func accessLog(ctx context.Context, method, kind string, start time.Time, err error) {
+	st := status.Convert(err)
+
+	fields := []interface{}{
+		"method", method,
+		"type", kind,
+		"code", st.Code().String(),
+		"latency", time.Since(start).String(),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, "peer", p.Addr.String())
+	}
+
+	if err != nil {
+		log.L(ctx).Warnw(st.Message(), fields...)
+
+		return
+	}
+
+	log.L(ctx).Infow("grpc call finished", fields...)
+}
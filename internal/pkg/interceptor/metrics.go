@@ -0,0 +1,85 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_started_total",
+		Help: "Total number of RPCs started on the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	rpcHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	rpcHandlingSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Histogram of response latency (seconds) of gRPC that had been application-level handled by the server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+)
+
+func init() {
+	prometheus.MustRegister(rpcStarted, rpcHandled, rpcHandlingSeconds)
+}
+
+// UnaryServerMetrics 返回记录调用次数、状态码和耗时的一元拦截器.
+func UnaryServerMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := observe("unary", info.FullMethod)
+		resp, err := handler(ctx, req)
+		done(err)
+
+		return resp, err
+	}
+}
+
+// StreamServerMetrics 返回记录调用次数、状态码和耗时的流拦截器.
+func StreamServerMetrics() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		kind := "bidi_stream"
+		switch {
+		case info.IsServerStream && !info.IsClientStream:
+			kind = "server_stream"
+		case info.IsClientStream && !info.IsServerStream:
+			kind = "client_stream"
+		}
+
+		done := observe(kind, info.FullMethod)
+		err := handler(srv, ss)
+		done(err)
+
+		return err
+	}
+}
+
+func observe(kind, fullMethod string) func(err error) {
+	service, method := splitMethodName(fullMethod)
+	start := time.Now()
+
+	rpcStarted.WithLabelValues(kind, service, method).Inc()
+
+	return func(err error) {
+		rpcHandled.WithLabelValues(kind, service, method, status.Code(err).String()).Inc()
+		rpcHandlingSeconds.WithLabelValues(kind, service, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// splitMethodName 把/package.service/method格式的方法名拆分为服务名和方法名.
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+
+	return "unknown", "unknown"
+}
@@ -0,0 +1,49 @@
+package interceptor
+
+import (
+	"context"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// UnaryServerRecovery 返回从panic中恢复的一元拦截器，panic会记录日志并返回codes.Internal.
+func UnaryServerRecovery() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverFrom(ctx, info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecovery 返回从panic中恢复的流拦截器.
+func StreamServerRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverFrom(ss.Context(), info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func recoverFrom(ctx context.Context, method string, r interface{}) error {
+	log.L(ctx).Errorw("grpc call panic recovered", "method", method, "panic", r, "stack", string(debug.Stack()))
+
+	return status.Errorf(codes.Internal, "internal server error")
+}
@@ -0,0 +1,52 @@
+package interceptor
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// XRequestIDKey 是保存请求id的metadata key，和http的X-Request-ID header对应.
+const XRequestIDKey = "x-request-id"
+
+// UnaryServerRequestID 返回处理请求id的一元拦截器. 请求中没有请求id时生成一个，
+// 请求id会保存到上下文供log.L(ctx)使用，并通过response header返回.
+func UnaryServerRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+// StreamServerRequestID 返回处理请求id的流拦截器.
+func StreamServerRequestID() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+// RequestIDFrom 返回上下文中的请求id.
+func RequestIDFrom(ctx context.Context) string {
+	rid, _ := ctx.Value(log.KeyRequestID).(string)
+
+	return rid
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var rid string
+	if values := metadata.ValueFromIncomingContext(ctx, XRequestIDKey); len(values) > 0 {
+		rid = values[0]
+	}
+
+	if rid == "" {
+		rid = uuid.Must(uuid.NewV4()).String()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(XRequestIDKey, rid))
+
+	// log.L(ctx)使用字符串作为key读取请求id
+	return context.WithValue(ctx, log.KeyRequestID, rid) //nolint:staticcheck
+}
@@ -0,0 +1,23 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerTimeout 返回为一元调用设置截止时间的拦截器. 调用方设置了更早的截止时间时使用调用方的，
+// timeout不大于0时不做处理. 流式调用通常是长连接，不设置截止时间.
+func UnaryServerTimeout(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
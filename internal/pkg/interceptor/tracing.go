@@ -0,0 +1,22 @@
+package interceptor
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// 健康检查调用频繁，不创建span.
+var tracingFilter = otelgrpc.WithInterceptorFilter(filters.Not(filters.ServiceName(healthpb.Health_ServiceDesc.ServiceName)))
+
+// UnaryServerTracing 返回创建span的一元拦截器，从metadata中的W3C trace context继续上游的trace.
+// 需要放在其他拦截器之前，让日志中带有trace id.
+func UnaryServerTracing() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor(tracingFilter)
+}
+
+// StreamServerTracing 返回创建span的流拦截器.
+func StreamServerTracing() grpc.StreamServerInterceptor {
+	return otelgrpc.StreamServerInterceptor(tracingFilter)
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// AuthStrategy 定义了资源认证需要实现的方法.
+type AuthStrategy interface {
+	AuthFunc() gin.HandlerFunc
+}
+
+// AuthOperator 用来在不同的认证策略之间切换.
+type AuthOperator struct {
+	strategy AuthStrategy
+}
+
+// SetStrategy 设置认证策略.
+func (operator *AuthOperator) SetStrategy(strategy AuthStrategy) {
+	operator.strategy = strategy
+}
+
+// AuthFunc 执行资源认证.
+func (operator *AuthOperator) AuthFunc() gin.HandlerFunc {
+	return operator.strategy.AuthFunc()
+}
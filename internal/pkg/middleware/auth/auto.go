@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+const authHeaderCount = 2
+
+// AutoStrategy 定义了根据`Authorization` header自动选择Basic或Bearer认证的策略.
+// Bearer token匹配token策略的前缀时使用token策略，否则按jwt处理. 没有`Authorization` header
+// 但是携带了校验通过的客户端证书时使用证书认证.
+type AutoStrategy struct {
+	basic middleware.AuthStrategy
+	jwt   middleware.AuthStrategy
+	token TokenStrategy
+	cert  CertStrategy
+}
+
+var _ middleware.AuthStrategy = &AutoStrategy{}
+
+// NewAutoStrategy 使用Basic策略、JWT策略、不透明token策略和客户端证书策略创建自动认证策略.
+func NewAutoStrategy(basic, jwt middleware.AuthStrategy, token TokenStrategy, cert CertStrategy) AutoStrategy {
+	return AutoStrategy{
+		basic: basic,
+		jwt:   jwt,
+		token: token,
+		cert:  cert,
+	}
+}
+
+// AuthFunc 把自动认证策略作为gin的认证中间件.
+func (a AutoStrategy) AuthFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operator := middleware.AuthOperator{}
+
+		if c.Request.Header.Get("Authorization") == "" && a.cert.Match(c) {
+			operator.SetStrategy(a.cert)
+			c.Set(middleware.AuthMethodKey, "cert")
+			operator.AuthFunc()(c)
+			observeAuth(c, "cert")
+
+			return
+		}
+
+		authHeader := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+
+		if len(authHeader) != authHeaderCount {
+			core.WriteResponse(
+				c,
+				errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong."),
+				nil,
+			)
+			c.Abort()
+			observeAuth(c, "unknown")
+
+			return
+		}
+
+		var strategy string
+
+		switch authHeader[0] {
+		case "Basic":
+			operator.SetStrategy(a.basic)
+			strategy = "basic"
+		case "Bearer":
+			if a.token.Match(authHeader[1]) {
+				operator.SetStrategy(a.token)
+				strategy = "token"
+
+				break
+			}
+
+			operator.SetStrategy(a.jwt)
+			strategy = "jwt"
+		default:
+			core.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, "unrecognized Authorization header."), nil)
+			c.Abort()
+			observeAuth(c, "unknown")
+
+			return
+		}
+
+		c.Set(middleware.AuthMethodKey, strategy)
+		operator.AuthFunc()(c)
+		observeAuth(c, strategy)
+	}
+}
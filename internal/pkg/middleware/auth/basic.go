@@ -0,0 +1,64 @@
+// Package auth 定义了Basic、Bearer等认证策略.
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+// BasicStrategy 定义了Basic认证策略.
+type BasicStrategy struct {
+	compare func(username string, password string) bool
+}
+
+var _ middleware.AuthStrategy = &BasicStrategy{}
+
+// NewBasicStrategy 使用给定的比较函数创建Basic认证策略.
+func NewBasicStrategy(compare func(username string, password string) bool) BasicStrategy {
+	return BasicStrategy{
+		compare: compare,
+	}
+}
+
+// AuthFunc 把Basic认证策略作为gin的认证中间件.
+func (b BasicStrategy) AuthFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+
+		if len(auth) != 2 || auth[0] != "Basic" {
+			core.WriteResponse(
+				c,
+				errors.WithCode(code.ErrSignatureInvalid, "Authorization header format is wrong."),
+				nil,
+			)
+			c.Abort()
+
+			return
+		}
+
+		payload, _ := base64.StdEncoding.DecodeString(auth[1])
+		pair := strings.SplitN(string(payload), ":", 2)
+
+		if len(pair) != 2 || !b.compare(pair[0], pair[1]) {
+			core.WriteResponse(
+				c,
+				errors.WithCode(code.ErrSignatureInvalid, "Authorization header format is wrong."),
+				nil,
+			)
+			c.Abort()
+
+			return
+		}
+
+		c.Set(middleware.UsernameKey, pair[0])
+
+		c.Next()
+	}
+}
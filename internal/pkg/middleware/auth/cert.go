@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+// 从客户端证书中获取用户名使用的字段.
+const (
+	CertUserFieldCN       = "cn"
+	CertUserFieldSANDNS   = "san-dns"
+	CertUserFieldSANEmail = "san-email"
+	CertUserFieldSANURI   = "san-uri"
+)
+
+// CertStrategy 定义了TLS客户端证书认证策略，只使用已经通过客户端CA校验的证书.
+type CertStrategy struct {
+	field    string
+	validate func(c *gin.Context, username string) error
+}
+
+var _ middleware.AuthStrategy = &CertStrategy{}
+
+// NewCertStrategy 创建客户端证书认证策略，field指定用户名来自证书的哪个字段，validate校验用户名对应的用户是否可用.
+func NewCertStrategy(field string, validate func(c *gin.Context, username string) error) CertStrategy {
+	return CertStrategy{
+		field:    field,
+		validate: validate,
+	}
+}
+
+// Match 判断请求是否携带了已经校验通过的客户端证书.
+func (s CertStrategy) Match(c *gin.Context) bool {
+	return s.validate != nil && VerifiedClientCert(c) != nil
+}
+
+// AuthFunc 把客户端证书认证策略作为gin的认证中间件.
+func (s CertStrategy) AuthFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cert := VerifiedClientCert(c)
+		if cert == nil {
+			core.WriteResponse(c, errors.WithCode(code.ErrMissingHeader, "client certificate is required."), nil)
+			c.Abort()
+
+			return
+		}
+
+		username, err := CertUsername(cert, s.field)
+		if err == nil {
+			err = s.validate(c, username)
+		}
+
+		if err != nil {
+			core.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, err.Error()), nil)
+			c.Abort()
+
+			return
+		}
+
+		c.Set(middleware.UsernameKey, username)
+		c.Next()
+	}
+}
+
+// VerifiedClientCert 返回通过客户端CA校验的证书，没有时返回nil.
+// 服务端使用VerifyClientCertIfGiven时，未通过校验的证书会直接导致握手失败.
+func VerifiedClientCert(c *gin.Context) *x509.Certificate {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return state.VerifiedChains[0][0]
+}
+
+// CertUsername 根据field从证书中获取用户名. SAN字段有多个值时使用第一个，san-email使用@之前的部分，
+// san-uri使用路径的最后一段，例如spiffe://example.com/user/admin对应admin.
+func CertUsername(cert *x509.Certificate, field string) (string, error) {
+	var username string
+
+	switch field {
+	case CertUserFieldCN, "":
+		username = cert.Subject.CommonName
+	case CertUserFieldSANDNS:
+		if len(cert.DNSNames) > 0 {
+			username = cert.DNSNames[0]
+		}
+	case CertUserFieldSANEmail:
+		if len(cert.EmailAddresses) > 0 {
+			username = strings.SplitN(cert.EmailAddresses[0], "@", 2)[0]
+		}
+	case CertUserFieldSANURI:
+		if len(cert.URIs) > 0 {
+			path := strings.TrimSuffix(cert.URIs[0].Path, "/")
+			username = path[strings.LastIndex(path, "/")+1:]
+		}
+	default:
+		return "", fmt.Errorf("unsupported certificate user field `%s`", field)
+	}
+
+	if username == "" {
+		return "", fmt.Errorf("no username found in the %s of the client certificate", field)
+	}
+
+	return username, nil
+}
@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/jwks"
+)
+
+const (
+	// JWTPayloadKey 是jwt claims保存在gin.Context中的key.
+	JWTPayloadKey = "JWT_PAYLOAD"
+
+	// 记录token首次签发时间的claim，用于判断token能否被刷新.
+	origIatKey = "orig_iat"
+)
+
+// JWTConfig 定义了创建JWT认证策略需要的配置.
+type JWTConfig struct {
+	// Realm 展示给用户的名称.
+	Realm string
+	// SigningAlgorithm 签名算法，默认HS256.
+	SigningAlgorithm string
+	// Key 签名使用的密钥.
+	Key []byte
+	// KeySet 非对称签名密钥集合，不为空时忽略SigningAlgorithm和Key，签名时在header中写入kid.
+	KeySet *jwks.KeySet
+	// Leeway 校验exp、nbf和iat时允许的时钟偏差.
+	Leeway time.Duration
+	// Timeout token的有效期.
+	Timeout time.Duration
+	// MaxRefresh token首次签发后可以刷新的最长时间.
+	MaxRefresh time.Duration
+	// IdentityKey 保存用户标识的claim名称.
+	IdentityKey string
+	// Audience 不为空时，只接受aud claim包含该值的token.
+	Audience string
+	// Authenticator 校验登录请求，返回认证成功的用户数据.
+	Authenticator func(c *gin.Context) (interface{}, error)
+	// Challenger 在Authenticator成功后判断是否需要进一步验证(例如MFA)，
+	// 返回true表示已经写入了响应，不再签发token.
+	Challenger func(c *gin.Context, data interface{}) bool
+	// PayloadFunc 根据用户数据生成额外的claims.
+	PayloadFunc func(data interface{}) jwt.MapClaims
+	// Authorizator 在token校验通过后执行额外的授权判断.
+	Authorizator func(claims jwt.MapClaims, c *gin.Context) bool
+	// LoginClaims 在登录签发token前调用，返回的claims会写入token，例如会话ID.
+	LoginClaims func(c *gin.Context, data interface{}) (jwt.MapClaims, error)
+	// TokenValidator 在签名和有效期校验通过后执行额外的校验，例如检查会话是否已被吊销，认证和刷新token时都会调用.
+	TokenValidator func(c *gin.Context, claims jwt.MapClaims) error
+	// LoginResponse 自定义登录成功后的响应.
+	LoginResponse func(c *gin.Context, token string, expire time.Time)
+	// RefreshResponse 自定义刷新token成功后的响应.
+	RefreshResponse func(c *gin.Context, token string, expire time.Time)
+	// LogoutResponse 自定义退出登录后的响应.
+	LogoutResponse func(c *gin.Context)
+	// SendCookie 登录成功后是否把token写入cookie.
+	SendCookie bool
+	// CookieName 保存token的cookie名称.
+	CookieName string
+	// TimeFunc 返回当前时间，便于测试.
+	TimeFunc func() time.Time
+}
+
+// JWTStrategy 定义了jwt bearer认证策略.
+type JWTStrategy struct {
+	JWTConfig
+}
+
+var _ middleware.AuthStrategy = &JWTStrategy{}
+
+// NewJWTStrategy 使用给定配置创建jwt bearer认证策略.
+func NewJWTStrategy(cfg JWTConfig) JWTStrategy {
+	if cfg.SigningAlgorithm == "" {
+		cfg.SigningAlgorithm = "HS256"
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Hour
+	}
+
+	if cfg.IdentityKey == "" {
+		cfg.IdentityKey = middleware.UsernameKey
+	}
+
+	if cfg.CookieName == "" {
+		cfg.CookieName = "jwt"
+	}
+
+	if cfg.TimeFunc == nil {
+		cfg.TimeFunc = time.Now
+	}
+
+	if cfg.LoginResponse == nil {
+		cfg.LoginResponse = defaultTokenResponse
+	}
+
+	if cfg.RefreshResponse == nil {
+		cfg.RefreshResponse = defaultTokenResponse
+	}
+
+	if cfg.LogoutResponse == nil {
+		cfg.LogoutResponse = func(c *gin.Context) {
+			c.JSON(http.StatusOK, nil)
+		}
+	}
+
+	return JWTStrategy{cfg}
+}
+
+func defaultTokenResponse(c *gin.Context, token string, expire time.Time) {
+	c.JSON(http.StatusOK, gin.H{
+		"token":  token,
+		"expire": expire.Format(time.RFC3339),
+	})
+}
+
+// AuthFunc 把jwt认证策略作为gin的认证中间件.
+func (j JWTStrategy) AuthFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := j.GetClaimsFromJWT(c)
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+			c.Abort()
+
+			return
+		}
+
+		if j.Authorizator != nil && !j.Authorizator(claims, c) {
+			core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied, "you don't have permission to access."), nil)
+			c.Abort()
+
+			return
+		}
+
+		c.Set(JWTPayloadKey, claims)
+		c.Set(middleware.UsernameKey, claims[j.IdentityKey])
+
+		c.Next()
+	}
+}
+
+// LoginHandler 处理登录请求，认证成功后签发token.
+func (j JWTStrategy) LoginHandler(c *gin.Context) {
+	if j.Authenticator == nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrUnknown, "missing authenticator func"), nil)
+
+		return
+	}
+
+	data, err := j.Authenticator(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	if j.Challenger != nil && j.Challenger(c, data) {
+		return
+	}
+
+	j.LoginWith(c, data)
+}
+
+// LoginWith 为已经认证通过的用户签发token并写入登录响应.
+func (j JWTStrategy) LoginWith(c *gin.Context, data interface{}) {
+	var extra jwt.MapClaims
+	if j.LoginClaims != nil {
+		var err error
+		if extra, err = j.LoginClaims(c, data); err != nil {
+			core.WriteResponse(c, err, nil)
+
+			return
+		}
+	}
+
+	token, expire, err := j.generate(data, extra)
+	if err != nil {
+		core.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, err.Error()), nil)
+
+		return
+	}
+
+	j.setCookie(c, token, expire)
+	j.LoginResponse(c, token, expire)
+}
+
+// RefreshHandler 在MaxRefresh时间内使用旧token换取新token.
+func (j JWTStrategy) RefreshHandler(c *gin.Context) {
+	token, expire, err := j.RefreshToken(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+
+		return
+	}
+
+	j.setCookie(c, token, expire)
+	j.RefreshResponse(c, token, expire)
+}
+
+// LogoutHandler 退出登录，清除cookie中的token.
+func (j JWTStrategy) LogoutHandler(c *gin.Context) {
+	if j.SendCookie {
+		c.SetCookie(j.CookieName, "", -1, "/", "", false, true)
+	}
+
+	j.LogoutResponse(c)
+}
+
+// TokenGenerator 根据用户数据签发一个新的token.
+func (j JWTStrategy) TokenGenerator(data interface{}) (string, time.Time, error) {
+	return j.generate(data, nil)
+}
+
+func (j JWTStrategy) generate(data interface{}, extra jwt.MapClaims) (string, time.Time, error) {
+	claims := jwt.MapClaims{}
+	if j.PayloadFunc != nil {
+		for k, v := range j.PayloadFunc(data) {
+			claims[k] = v
+		}
+	}
+
+	for k, v := range extra {
+		claims[k] = v
+	}
+
+	now := j.TimeFunc()
+	claims[origIatKey] = now.Unix()
+
+	return j.SignClaims(claims, now.Add(j.Timeout))
+}
+
+// SignClaims 使用指定的过期时间对claims签名.
+func (j JWTStrategy) SignClaims(claims jwt.MapClaims, expire time.Time) (string, time.Time, error) {
+	now := j.TimeFunc()
+	claims["exp"] = expire.Unix()
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+
+	var (
+		signed string
+		err    error
+	)
+
+	if j.KeySet != nil {
+		key, kerr := j.KeySet.SigningKey(now)
+		if kerr != nil {
+			return "", time.Time{}, kerr
+		}
+
+		token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+		token.Header["kid"] = key.ID
+		signed, err = token.SignedString(key.Signer)
+	} else {
+		signed, err = jwt.NewWithClaims(jwt.GetSigningMethod(j.SigningAlgorithm), claims).SignedString(j.Key)
+	}
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expire, nil
+}
+
+// RefreshToken 校验旧token是否仍在刷新时间内，并签发新token.
+func (j JWTStrategy) RefreshToken(c *gin.Context) (string, time.Time, error) {
+	raw, err := j.lookupToken(c)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims, err := j.parse(raw, false)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	origIat, ok := claims[origIatKey].(float64)
+	if !ok || j.TimeFunc().After(time.Unix(int64(origIat), 0).Add(j.MaxRefresh)) {
+		return "", time.Time{}, errors.WithCode(code.ErrExpired, "token is expired and can not be refreshed")
+	}
+
+	if j.TokenValidator != nil {
+		if err := j.TokenValidator(c, claims); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	return j.SignClaims(claims, j.TimeFunc().Add(j.Timeout))
+}
+
+// GetClaimsFromJWT 从请求中获取并校验token，返回token中的claims.
+func (j JWTStrategy) GetClaimsFromJWT(c *gin.Context) (jwt.MapClaims, error) {
+	raw, err := j.lookupToken(c)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := j.ParseToken(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if j.TokenValidator != nil {
+		if err := j.TokenValidator(c, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// ParseToken 校验token的签名和有效期，返回token中的claims.
+func (j JWTStrategy) ParseToken(raw string) (jwt.MapClaims, error) {
+	return j.parse(raw, true)
+}
+
+func (j JWTStrategy) parse(raw string, validate bool) (jwt.MapClaims, error) {
+	methods := []string{j.SigningAlgorithm}
+	if j.KeySet != nil {
+		methods = j.KeySet.Algorithms()
+	}
+
+	// 时间相关的claims在下面根据Leeway单独校验
+	parser := jwt.NewParser(jwt.WithValidMethods(methods), jwt.WithoutClaimsValidation())
+
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(raw, claims, j.keyFunc); err != nil {
+		return nil, errors.WithCode(code.ErrTokenInvalid, err.Error())
+	}
+
+	if validate {
+		now := j.TimeFunc().Unix()
+		leeway := int64(j.Leeway.Seconds())
+
+		if !claims.VerifyExpiresAt(now-leeway, false) {
+			return nil, errors.WithCode(code.ErrExpired, "token is expired")
+		}
+
+		if !claims.VerifyNotBefore(now+leeway, false) || !claims.VerifyIssuedAt(now+leeway, false) {
+			return nil, errors.WithCode(code.ErrTokenInvalid, "token is not valid yet")
+		}
+	}
+
+	if j.Audience != "" && !claims.VerifyAudience(j.Audience, true) {
+		return nil, errors.WithCode(code.ErrTokenInvalid, "token audience is invalid")
+	}
+
+	return claims, nil
+}
+
+// 返回校验签名使用的密钥，使用非对称密钥时根据header中的kid查找.
+func (j JWTStrategy) keyFunc(token *jwt.Token) (interface{}, error) {
+	if j.KeySet == nil {
+		return j.Key, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := j.KeySet.Lookup(kid, j.TimeFunc())
+	if !ok {
+		return nil, fmt.Errorf("unknown or retired signing key `%s`", kid)
+	}
+
+	if key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("signing key `%s` can not be used with %s", kid, token.Method.Alg())
+	}
+
+	return key.Public(), nil
+}
+
+// 依次从header、query和cookie中查找token.
+func (j JWTStrategy) lookupToken(c *gin.Context) (string, error) {
+	if header := c.Request.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != authHeaderCount || parts[0] != "Bearer" {
+			return "", errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong.")
+		}
+
+		return parts[1], nil
+	}
+
+	if token := c.Query("token"); token != "" {
+		return token, nil
+	}
+
+	if cookie, err := c.Cookie(j.CookieName); err == nil && cookie != "" {
+		return cookie, nil
+	}
+
+	return "", errors.WithCode(code.ErrMissingHeader, "Authorization header cannot be empty.")
+}
+
+func (j JWTStrategy) setCookie(c *gin.Context, token string, expire time.Time) {
+	if !j.SendCookie {
+		return
+	}
+
+	maxAge := int(expire.Unix() - j.TimeFunc().Unix())
+	c.SetCookie(j.CookieName, token, maxAge, "/", "", c.Request.TLS != nil, true)
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+var authAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "iam_auth_attempts_total",
+	Help: "Total number of request authentications, by strategy and result.",
+}, []string{"strategy", "result"})
+
+func init() {
+	prometheus.MustRegister(authAttempts)
+}
+
+// observeAuth 根据是否设置了用户名记录认证结果. 认证中间件在认证成功后会继续执行后面的处理函数，
+// 所以不能根据请求是否被中止来判断.
+func observeAuth(c *gin.Context, strategy string) {
+	result := "failure"
+	if _, ok := c.Get(middleware.UsernameKey); ok {
+		result = "success"
+	}
+
+	authAttempts.WithLabelValues(strategy, result).Inc()
+}
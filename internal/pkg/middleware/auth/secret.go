@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+// AuthzAudience 是使用密钥对签发的jwt中audience字段的值，和iam-authz-server保持一致.
+const AuthzAudience = "iam.authz.marmotedu.com"
+
+// Secret 包含密钥对的基本信息.
+type Secret struct {
+	Username string
+	ID       string
+	Key      string
+	Expires  int64
+}
+
+// SecretStrategy 定义了使用密钥对(secretID/secretKey)的认证策略，供其他服务调用apiserver.
+// 支持两种方式：Basic认证直接携带secretID和secretKey，或者bearer jwt，header中的kid为secretID，使用secretKey签名.
+type SecretStrategy struct {
+	get func(ctx context.Context, secretID string) (Secret, error)
+}
+
+var _ middleware.AuthStrategy = &SecretStrategy{}
+
+// NewSecretStrategy 创建密钥对认证策略，get根据secretID返回密钥对.
+func NewSecretStrategy(get func(ctx context.Context, secretID string) (Secret, error)) SecretStrategy {
+	return SecretStrategy{get}
+}
+
+// AuthFunc 把密钥对认证策略作为gin的认证中间件.
+func (s SecretStrategy) AuthFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+		if len(header) != authHeaderCount {
+			c.Header("WWW-Authenticate", `Basic realm="secret"`)
+			core.WriteResponse(c, errors.WithCode(code.ErrMissingHeader, "Authorization header cannot be empty."), nil)
+			c.Abort()
+
+			return
+		}
+
+		var (
+			secret Secret
+			err    error
+		)
+
+		switch header[0] {
+		case "Basic":
+			secret, err = s.basic(c)
+		case "Bearer":
+			secret, err = s.Verify(c, header[1])
+		default:
+			err = errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong.")
+		}
+
+		if err == nil {
+			err = checkExpires(secret)
+		}
+
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+			c.Abort()
+
+			return
+		}
+
+		c.Set(middleware.UsernameKey, secret.Username)
+		c.Next()
+	}
+}
+
+func (s SecretStrategy) basic(c *gin.Context) (Secret, error) {
+	id, key, ok := c.Request.BasicAuth()
+	if !ok {
+		return Secret{}, errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong.")
+	}
+
+	secret, err := s.get(c, id)
+	if err != nil || subtle.ConstantTimeCompare([]byte(secret.Key), []byte(key)) != 1 {
+		return Secret{}, errors.WithCode(code.ErrSignatureInvalid, "invalid secret pair")
+	}
+
+	return secret, nil
+}
+
+// Verify 校验使用密钥对签名的jwt，返回签名使用的密钥对.
+func (s SecretStrategy) Verify(ctx context.Context, raw string) (Secret, error) {
+	var secret Secret
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid token format: missing kid field in header")
+		}
+
+		var err error
+		if secret, err = s.get(ctx, kid); err != nil {
+			return nil, fmt.Errorf("can not obtain secret %s", kid)
+		}
+
+		return []byte(secret.Key), nil
+	}); err != nil {
+		return Secret{}, errors.WithCode(code.ErrSignatureInvalid, err.Error())
+	}
+
+	if !claims.VerifyAudience(AuthzAudience, true) {
+		return Secret{}, errors.WithCode(code.ErrSignatureInvalid, "token audience is invalid")
+	}
+
+	if err := checkExpires(secret); err != nil {
+		return Secret{}, err
+	}
+
+	return secret, nil
+}
+
+func checkExpires(secret Secret) error {
+	if KeyExpired(secret.Expires) {
+		tm := time.Unix(secret.Expires, 0).Format("2006-01-02 15:04:05")
+
+		return errors.WithCode(code.ErrExpired, "expired at: %s", tm)
+	}
+
+	return nil
+}
+
+// KeyExpired 判断密钥是否过期，expires为0表示永不过期.
+func KeyExpired(expires int64) bool {
+	if expires >= 1 {
+		return time.Now().After(time.Unix(expires, 0))
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+)
+
+// TokenStrategy 定义了带固定前缀的不透明bearer token(例如个人访问令牌)的认证策略.
+type TokenStrategy struct {
+	prefix       string
+	authenticate func(c *gin.Context, token string) (string, error)
+}
+
+var _ middleware.AuthStrategy = &TokenStrategy{}
+
+// NewTokenStrategy 创建不透明token认证策略，authenticate校验token并返回token所属的用户名.
+func NewTokenStrategy(prefix string, authenticate func(c *gin.Context, token string) (string, error)) TokenStrategy {
+	return TokenStrategy{
+		prefix:       prefix,
+		authenticate: authenticate,
+	}
+}
+
+// Match 判断token是否应该由该策略处理.
+func (t TokenStrategy) Match(token string) bool {
+	return t.prefix != "" && strings.HasPrefix(token, t.prefix)
+}
+
+// AuthFunc 把不透明token认证策略作为gin的认证中间件.
+func (t TokenStrategy) AuthFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+		if len(header) != authHeaderCount || header[0] != "Bearer" || !t.Match(header[1]) {
+			core.WriteResponse(c, errors.WithCode(code.ErrInvalidAuthHeader, "Authorization header format is wrong."), nil)
+			c.Abort()
+
+			return
+		}
+
+		username, err := t.authenticate(c, header[1])
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+			c.Abort()
+
+			return
+		}
+
+		c.Set(middleware.UsernameKey, username)
+
+		c.Next()
+	}
+}
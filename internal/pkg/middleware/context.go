@@ -5,7 +5,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const UsernameKey = "username"
+const (
+	UsernameKey = "username"
+	// AuthMethodKey 保存请求使用的认证方式: basic, jwt, token, cert.
+	AuthMethodKey = "authMethod"
+)
 
 // Context 是一个中间件，它插入一些通用字段到gin.Context.
 func Context() gin.HandlerFunc {
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/redact"
+)
+
+// 超过这个大小的请求体和响应体无法隐藏敏感字段，只输出长度.
+const maxDumpCaptureBytes = 1 << 20
+
+// DumpConfig 是Dump中间件的配置.
+type DumpConfig struct {
+	// RedactFields 需要隐藏的json和表单字段.
+	RedactFields redact.Fields
+	// RedactHeaders 需要隐藏的请求头和响应头.
+	RedactHeaders redact.Fields
+	// MaxBodyBytes 输出的请求体和响应体的最大长度，超过的部分被截断，为0时不截断.
+	MaxBodyBytes int
+	// SamplePercent 输出的请求所占的百分比，取值范围为0到100.
+	SamplePercent float64
+	// Routes 只输出这些路由的请求，以*结尾时匹配前缀，为空时输出所有请求.
+	Routes []string
+}
+
+// DefaultDumpConfig 返回默认的Dump中间件配置.
+func DefaultDumpConfig() DumpConfig {
+	return DumpConfig{
+		RedactFields:  redact.NewFields(redact.DefaultFields()...),
+		RedactHeaders: redact.NewFields(redact.DefaultHeaders()...),
+		MaxBodyBytes:  4096,
+		SamplePercent: 100,
+	}
+}
+
+// dumpWriter 在写入响应的同时保存响应体.
+type dumpWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+	size int
+}
+
+func (w *dumpWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *dumpWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *dumpWriter) capture(data []byte) {
+	w.size += len(data)
+	if w.size <= maxDumpCaptureBytes {
+		w.body.Write(data)
+	}
+}
+
+// Dump 是一个中间件，通过日志输出请求和响应的头和内容，敏感字段和头被隐藏.
+func Dump(cfg DumpConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !matchRoute(c.FullPath(), cfg.Routes) || !sampled(cfg.SamplePercent) {
+			c.Next()
+
+			return
+		}
+
+		reqBody := readDumpBody(c)
+		reqSize := len(reqBody)
+		if reqSize > maxDumpCaptureBytes && c.Request.ContentLength > 0 {
+			reqSize = int(c.Request.ContentLength)
+		}
+
+		writer := &dumpWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		log.L(c).Infow("http dump",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"query", redact.Query(c.Request.URL.RawQuery, cfg.RedactFields),
+			"route", c.FullPath(),
+			"status", writer.Status(),
+			"requestHeader", redact.Header(c.Request.Header, cfg.RedactHeaders),
+			"requestBody", dumpBody(reqBody, reqSize, c.ContentType(), cfg),
+			"responseHeader", redact.Header(writer.Header(), cfg.RedactHeaders),
+			"responseBody", dumpBody(writer.body.Bytes(), writer.size, contentType(writer.Header()), cfg),
+		)
+	}
+}
+
+func matchRoute(route string, routes []string) bool {
+	if len(routes) == 0 {
+		return true
+	}
+
+	for _, r := range routes {
+		if prefix := strings.TrimSuffix(r, "*"); prefix != r {
+			if strings.HasPrefix(route, prefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if route == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sampled(percent float64) bool {
+	if percent >= 100 {
+		return true
+	}
+
+	return rand.Float64()*100 < percent //nolint: gosec
+}
+
+// readDumpBody 读取请求体并重新放回请求中，超过maxDumpCaptureBytes时只保留读取的部分.
+func readDumpBody(c *gin.Context) []byte {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDumpCaptureBytes+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	if err != nil {
+		return nil
+	}
+
+	return body
+}
+
+func contentType(header http.Header) string {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	return mediaType
+}
+
+// dumpBody 隐藏json和表单中的敏感字段后截断，其他内容只输出长度.
+func dumpBody(body []byte, size int, mediaType string, cfg DumpConfig) string {
+	if size == 0 {
+		return ""
+	}
+
+	if size > len(body) || len(body) > maxDumpCaptureBytes {
+		return fmt.Sprintf("<%d bytes omitted>", size)
+	}
+
+	var out string
+	switch {
+	case mediaType == gin.MIMEPOSTForm:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("<%d bytes omitted>", size)
+		}
+
+		out = redact.Form(values, cfg.RedactFields).Encode()
+	default:
+		// 没有声明类型的内容也可能是json. 其他内容(包括text/*)无法识别其中的敏感字段，只输出长度
+		data, ok := redact.JSON(body, cfg.RedactFields)
+		if !ok {
+			return fmt.Sprintf("<%d bytes omitted>", size)
+		}
+
+		out = string(data)
+	}
+
+	if cfg.MaxBodyBytes > 0 && len(out) > cfg.MaxBodyBytes {
+		return fmt.Sprintf("%s...(%d bytes truncated)", out[:cfg.MaxBodyBytes], len(out)-cfg.MaxBodyBytes)
+	}
+
+	return out
+}
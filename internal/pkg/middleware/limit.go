@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+)
+
+// MaxBodyBytes 是一个中间件，限制请求体的大小. Content-Length超过限制的请求直接返回错误，
+// 没有Content-Length的请求在读取超过限制时读取失败.
+func MaxBodyBytes(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			core.WriteResponse(c, errors.WithCode(code.ErrRequestTooLarge,
+				"request body is %d bytes, exceeds the limit of %d bytes", c.Request.ContentLength, limit), nil)
+			c.Abort()
+
+			return
+		}
+
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"time"
 
 	"github.com/cuizhaoyue/iams/pkg/log"
+	"github.com/cuizhaoyue/iams/pkg/redact"
 	"github.com/gin-gonic/gin"
 	"github.com/mattn/go-isatty"
 )
 
+// logRedactFields 是访问日志中需要隐藏的查询参数.
+var logRedactFields = redact.NewFields(redact.DefaultFields()...)
+
 // defaultLogFormatter 是Logger中间件使用的默认日志格式函数.
 var defaultLogFormatter = func(param gin.LogFormatterParams) string {
 	var statusColor, methodColor, resetColor string
@@ -120,8 +124,9 @@ func LoggerWithConfig(conf gin.LoggerConfig) gin.HandlerFunc {
 
 			param.BodySize = c.Writer.Size()
 
+			// 查询参数中可能有token，输出前隐藏敏感参数
 			if raw != "" {
-				path = path + "?" + raw
+				path = path + "?" + redact.Query(raw, logRedactFields)
 			}
 
 			param.Path = path
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	gindump "github.com/tpkeeper/gin-dump"
 )
 
 var Middlewares = defaultMiddlewares()
@@ -19,7 +18,7 @@ func defaultMiddlewares() map[string]gin.HandlerFunc {
 		"cors":      Cors(),
 		"requestid": RequestID(),
 		"logger":    Logger(),
-		"dump":      gindump.Dump(),
+		"dump":      Dump(DefaultDumpConfig()),
 	}
 }
 
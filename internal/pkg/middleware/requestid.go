@@ -22,7 +22,7 @@ func RequestID() gin.HandlerFunc {
 
 		// 如果没有请求id则创建一个uuid插入到header中和Context中
 		if rid == "" {
-			rid = uuid.NewV4().String()
+			rid = uuid.Must(uuid.NewV4()).String()
 			c.Request.Header.Set(XRequestIDKey, rid)
 			c.Set(XRequestIDKey, rid)
 		}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// 健康检查、监控指标和性能分析的请求不创建span.
+var untracedPaths = []string{"/healthz", "/livez", "/readyz", "/metrics", "/debug/pprof"}
+
+// Tracing 是一个中间件，为每个请求创建以路由命名的span，从请求头中的W3C trace context继续上游的trace.
+// 需要在其他中间件之前安装，让日志中带有trace id.
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithFilter(func(r *http.Request) bool {
+		for _, path := range untracedPaths {
+			if strings.HasPrefix(r.URL.Path, path) {
+				return false
+			}
+		}
+
+		return true
+	}))
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marmotedu/component-base/pkg/core"
+	"github.com/marmotedu/errors"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/code"
+)
+
+// AdminChecker 判断用户是否是管理员，不是管理员时返回错误.
+type AdminChecker func(c *gin.Context, username string) error
+
+// Validation 确保用户拥有操作资源的权限，管理员可以操作所有资源.
+func Validation(isAdmin AdminChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := isAdmin(c, c.GetString(UsernameKey)); err != nil {
+			switch c.FullPath() {
+			case "/v1/users":
+				if c.Request.Method != http.MethodPost {
+					core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied, ""), nil)
+					c.Abort()
+
+					return
+				}
+			case "/v1/users/:name/mfa/required", "/v1/oauth2/clients", "/v1/oauth2/clients/:name",
+				"/v1/oauth2/clients/:name/secret", "/v1/audit":
+				// 只有管理员可以要求用户必须使用MFA、注册OAuth2客户端和查询审计记录
+				core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied, ""), nil)
+				c.Abort()
+
+				return
+			case "/v1/users/:name/mfa", "/v1/users/:name/mfa/activate", "/v1/users/:name/mfa/recovery-codes",
+				"/v1/users/:name/sessions", "/v1/users/:name/sessions/:sid":
+				if c.GetString(UsernameKey) != c.Param("name") {
+					core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied, ""), nil)
+					c.Abort()
+
+					return
+				}
+			case "/v1/users/:name", "/v1/users/:name/change-password":
+				// 非管理员用户只能操作自己，并且不能删除用户
+				username := c.GetString(UsernameKey)
+				if c.Request.Method == http.MethodDelete || username != c.Param("name") {
+					core.WriteResponse(c, errors.WithCode(code.ErrPermissionDenied, ""), nil)
+					c.Abort()
+
+					return
+				}
+			default:
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,225 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/audit"
+	"github.com/cuizhaoyue/iams/pkg/redact"
+)
+
+// AuditOptions 定义了审计日志相关的配置选项.
+type AuditOptions struct {
+	Enabled       bool                 `json:"enabled"                  mapstructure:"enabled"`
+	Sinks         []string             `json:"sinks,omitempty"          mapstructure:"sinks"`
+	BufferSize    int                  `json:"buffer-size,omitempty"    mapstructure:"buffer-size"`
+	MaxBodyBytes  int                  `json:"max-body-bytes,omitempty" mapstructure:"max-body-bytes"`
+	RedactFields  []string             `json:"redact-fields,omitempty"  mapstructure:"redact-fields"`
+	ExcludeRoutes []string             `json:"exclude-routes,omitempty" mapstructure:"exclude-routes"`
+	File          *AuditFileOptions    `json:"file"                     mapstructure:"file"`
+	Webhook       *AuditWebhookOptions `json:"webhook"                  mapstructure:"webhook"`
+}
+
+// AuditFileOptions 定义了审计日志文件sink的配置选项.
+type AuditFileOptions struct {
+	Path       string `json:"path,omitempty"        mapstructure:"path"`
+	MaxSize    int    `json:"max-size,omitempty"    mapstructure:"max-size"`
+	MaxBackups int    `json:"max-backups,omitempty" mapstructure:"max-backups"`
+	MaxAge     int    `json:"max-age,omitempty"     mapstructure:"max-age"`
+	Compress   bool   `json:"compress"              mapstructure:"compress"`
+}
+
+// AuditWebhookOptions 定义了审计日志webhook sink的配置选项.
+type AuditWebhookOptions struct {
+	URL           string            `json:"url,omitempty"            mapstructure:"url"`
+	Timeout       time.Duration     `json:"timeout,omitempty"        mapstructure:"timeout"`
+	Headers       map[string]string `json:"-"                        mapstructure:"headers"`
+	MaxRetries    int               `json:"max-retries,omitempty"    mapstructure:"max-retries"`
+	RetryInterval time.Duration     `json:"retry-interval,omitempty" mapstructure:"retry-interval"`
+}
+
+// NewAuditOptions 创建带有默认参数的AuditOptions.
+func NewAuditOptions() *AuditOptions {
+	return &AuditOptions{
+		Enabled:       false,
+		Sinks:         []string{audit.SinkDB},
+		BufferSize:    1000,
+		MaxBodyBytes:  64 * 1024,
+		RedactFields:  redact.DefaultFields(),
+		ExcludeRoutes: []string{"/v1/token/introspect"},
+		File: &AuditFileOptions{
+			Path:       "/var/log/iam/iam-apiserver-audit.log",
+			MaxSize:    100,
+			MaxBackups: 10,
+			MaxAge:     30,
+			Compress:   false,
+		},
+		Webhook: &AuditWebhookOptions{
+			Timeout:       5 * time.Second,
+			Headers:       map[string]string{},
+			MaxRetries:    3,
+			RetryInterval: time.Second,
+		},
+	}
+}
+
+// ToMiddlewareConfig 转换为审计中间件使用的配置.
+func (o *AuditOptions) ToMiddlewareConfig() audit.Config {
+	return audit.Config{
+		RedactFields:  redact.NewFields(o.RedactFields...),
+		MaxBodyBytes:  o.MaxBodyBytes,
+		ExcludeRoutes: o.ExcludeRoutes,
+	}
+}
+
+// ToFileConfig 转换为文件sink使用的配置.
+func (o *AuditOptions) ToFileConfig() audit.FileConfig {
+	return audit.FileConfig{
+		Path:       o.File.Path,
+		MaxSize:    o.File.MaxSize,
+		MaxBackups: o.File.MaxBackups,
+		MaxAge:     o.File.MaxAge,
+		Compress:   o.File.Compress,
+	}
+}
+
+// ToWebhookConfig 转换为webhook sink使用的配置.
+func (o *AuditOptions) ToWebhookConfig() audit.WebhookConfig {
+	return audit.WebhookConfig{
+		URL:           o.Webhook.URL,
+		Timeout:       o.Webhook.Timeout,
+		Headers:       o.Webhook.Headers,
+		MaxRetries:    o.Webhook.MaxRetries,
+		RetryInterval: o.Webhook.RetryInterval,
+	}
+}
+
+// HasSink 判断是否启用了指定的sink.
+func (o *AuditOptions) HasSink(name string) bool {
+	for _, sink := range o.Sinks {
+		if sink == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate 校验审计日志参数是否合法.
+func (o *AuditOptions) Validate() []error {
+	var errs []error
+
+	if !o.Enabled {
+		return errs
+	}
+
+	if len(o.Sinks) == 0 {
+		errs = append(errs, fmt.Errorf("--audit.sinks can not be empty"))
+	}
+
+	for _, sink := range o.Sinks {
+		valid := false
+		for _, s := range audit.Sinks() {
+			if sink == s {
+				valid = true
+			}
+		}
+
+		if !valid {
+			errs = append(errs, fmt.Errorf("--audit.sinks must be in %s, got %s", strings.Join(audit.Sinks(), ", "), sink))
+		}
+	}
+
+	if o.BufferSize <= 0 {
+		errs = append(errs, fmt.Errorf("--audit.buffer-size must be greater than 0"))
+	}
+
+	if o.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("--audit.max-body-bytes can not be negative"))
+	}
+
+	if o.HasSink(audit.SinkFile) {
+		if o.File.Path == "" {
+			errs = append(errs, fmt.Errorf("--audit.file.path can not be empty when the file sink is enabled"))
+		}
+
+		if o.File.MaxSize <= 0 {
+			errs = append(errs, fmt.Errorf("--audit.file.max-size must be greater than 0"))
+		}
+
+		if o.File.MaxBackups < 0 || o.File.MaxAge < 0 {
+			errs = append(errs, fmt.Errorf("--audit.file.max-backups and --audit.file.max-age can not be negative"))
+		}
+	}
+
+	if o.HasSink(audit.SinkWebhook) {
+		if o.Webhook.URL == "" {
+			errs = append(errs, fmt.Errorf("--audit.webhook.url can not be empty when the webhook sink is enabled"))
+		}
+
+		if o.Webhook.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("--audit.webhook.timeout must be greater than 0"))
+		}
+
+		if o.Webhook.MaxRetries < 0 {
+			errs = append(errs, fmt.Errorf("--audit.webhook.max-retries can not be negative"))
+		}
+
+		if o.Webhook.RetryInterval <= 0 {
+			errs = append(errs, fmt.Errorf("--audit.webhook.retry-interval must be greater than 0"))
+		}
+	}
+
+	return errs
+}
+
+// AddFlags 添加审计日志相关的flag到指定的FlagSet中.
+func (o *AuditOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "audit.enabled", o.Enabled, ""+
+		"Record every POST, PUT, PATCH and DELETE request as an audit event.")
+
+	fs.StringSliceVar(&o.Sinks, "audit.sinks", o.Sinks, ""+
+		"Where audit events are written, any of "+strings.Join(audit.Sinks(), ", ")+". "+
+		"The db sink is required to query events with /v1/audit.")
+
+	fs.IntVar(&o.BufferSize, "audit.buffer-size", o.BufferSize, ""+
+		"Number of audit events buffered before they are written. Events are dropped when the buffer is full.")
+
+	fs.IntVar(&o.MaxBodyBytes, "audit.max-body-bytes", o.MaxBodyBytes, ""+
+		"Request bodies larger than this are recorded by size only. 0 means 1MiB.")
+
+	fs.StringSliceVar(&o.RedactFields, "audit.redact-fields", o.RedactFields, ""+
+		"Fields of request bodies and diffs whose values are masked, matched case-insensitively.")
+
+	fs.StringSliceVar(&o.ExcludeRoutes, "audit.exclude-routes", o.ExcludeRoutes, ""+
+		"Routes that are not audited, e.g. /v1/token/introspect.")
+
+	fs.StringVar(&o.File.Path, "audit.file.path", o.File.Path, "File that audit events are appended to.")
+
+	fs.IntVar(&o.File.MaxSize, "audit.file.max-size", o.File.MaxSize, ""+
+		"Maximum size in megabytes of the audit file before it gets rotated.")
+
+	fs.IntVar(&o.File.MaxBackups, "audit.file.max-backups", o.File.MaxBackups, ""+
+		"Maximum number of rotated audit files to retain. 0 means no limit.")
+
+	fs.IntVar(&o.File.MaxAge, "audit.file.max-age", o.File.MaxAge, ""+
+		"Maximum number of days to retain rotated audit files. 0 means no limit.")
+
+	fs.BoolVar(&o.File.Compress, "audit.file.compress", o.File.Compress, "Compress rotated audit files with gzip.")
+
+	fs.StringVar(&o.Webhook.URL, "audit.webhook.url", o.Webhook.URL, "URL that audit events are posted to as json.")
+
+	fs.DurationVar(&o.Webhook.Timeout, "audit.webhook.timeout", o.Webhook.Timeout, "Timeout of a webhook request.")
+
+	fs.StringToStringVar(&o.Webhook.Headers, "audit.webhook.headers", o.Webhook.Headers, ""+
+		"Headers sent with webhook requests, e.g. authorization=Bearer xxx.")
+
+	fs.IntVar(&o.Webhook.MaxRetries, "audit.webhook.max-retries", o.Webhook.MaxRetries, ""+
+		"Maximum number of retries when posting an audit event fails.")
+
+	fs.DurationVar(&o.Webhook.RetryInterval, "audit.webhook.retry-interval", o.Webhook.RetryInterval, ""+
+		"Interval before the first retry, doubled after each retry.")
+}
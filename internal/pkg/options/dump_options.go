@@ -0,0 +1,80 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/internal/pkg/server"
+	"github.com/cuizhaoyue/iams/pkg/redact"
+)
+
+// DumpOptions 定义了dump中间件相关的配置选项，dump中间件需要通过--server.middlewares启用.
+type DumpOptions struct {
+	RedactFields  []string `json:"redact-fields,omitempty"  mapstructure:"redact-fields"`
+	RedactHeaders []string `json:"redact-headers,omitempty" mapstructure:"redact-headers"`
+	MaxBodyBytes  int      `json:"max-body-bytes"           mapstructure:"max-body-bytes"`
+	SamplePercent float64  `json:"sample-percent"           mapstructure:"sample-percent"`
+	Routes        []string `json:"routes,omitempty"         mapstructure:"routes"`
+}
+
+// NewDumpOptions 创建带有默认参数的DumpOptions.
+func NewDumpOptions() *DumpOptions {
+	cfg := middleware.DefaultDumpConfig()
+
+	return &DumpOptions{
+		RedactFields:  redact.DefaultFields(),
+		RedactHeaders: redact.DefaultHeaders(),
+		MaxBodyBytes:  cfg.MaxBodyBytes,
+		SamplePercent: cfg.SamplePercent,
+		Routes:        cfg.Routes,
+	}
+}
+
+// ApplyTo 把配置选项应用到服务配置.
+func (o *DumpOptions) ApplyTo(c *server.Config) error {
+	c.Dump = middleware.DumpConfig{
+		RedactFields:  redact.NewFields(o.RedactFields...),
+		RedactHeaders: redact.NewFields(o.RedactHeaders...),
+		MaxBodyBytes:  o.MaxBodyBytes,
+		SamplePercent: o.SamplePercent,
+		Routes:        o.Routes,
+	}
+
+	return nil
+}
+
+// Validate 校验dump参数是否合法.
+func (o *DumpOptions) Validate() []error {
+	var errs []error
+
+	if o.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("--dump.max-body-bytes can not be negative"))
+	}
+
+	if o.SamplePercent < 0 || o.SamplePercent > 100 {
+		errs = append(errs, fmt.Errorf("--dump.sample-percent must be between 0 and 100"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加dump相关的flag到指定的FlagSet中.
+func (o *DumpOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&o.RedactFields, "dump.redact-fields", o.RedactFields, ""+
+		"JSON and form fields whose values are masked in dumped bodies, matched case-insensitively.")
+
+	fs.StringSliceVar(&o.RedactHeaders, "dump.redact-headers", o.RedactHeaders, ""+
+		"Request and response headers whose values are masked in dumps.")
+
+	fs.IntVar(&o.MaxBodyBytes, "dump.max-body-bytes", o.MaxBodyBytes, ""+
+		"Dumped bodies are truncated to this many bytes. 0 means no limit.")
+
+	fs.Float64Var(&o.SamplePercent, "dump.sample-percent", o.SamplePercent, ""+
+		"Percentage of requests to dump, between 0 and 100.")
+
+	fs.StringSliceVar(&o.Routes, "dump.routes", o.Routes, ""+
+		"Only dump requests matching these routes, e.g. /v1/users/:name. A trailing * matches a prefix. "+
+		"Empty means all routes.")
+}
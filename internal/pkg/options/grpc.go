@@ -2,36 +2,139 @@ package options
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 )
 
 type GRPCOptions struct {
-	BindAddress string `json:"bind-address,omitempty" mapstructure:"bind-address"`
-	BindPort    int    `json:"bind-port,omitempty"    mapstructure:"bind-port"`
-	MaxMsgSize  int    `json:"max-msg-size,omitempty" mapstructure:"max-msg-size"`
+	BindAddress string `json:"bind-address,omitempty"           mapstructure:"bind-address"`
+	BindPort    int    `json:"bind-port,omitempty"              mapstructure:"bind-port"`
+	MaxMsgSize  int    `json:"max-msg-size,omitempty"           mapstructure:"max-msg-size"`
+	// Interceptors 按顺序安装的通用拦截器: recovery, requestid, logger, metrics.
+	Interceptors []string `json:"interceptors,omitempty"           mapstructure:"interceptors"`
+	// Timeout 一元调用的默认截止时间，为0时不限制.
+	Timeout time.Duration   `json:"timeout,omitempty"                mapstructure:"timeout"`
+	Auth    GRPCAuthOptions `json:"auth"                             mapstructure:"auth"`
+	// Plaintext 为true时不使用TLS，只用于本地开发.
+	Plaintext bool `json:"plaintext,omitempty"              mapstructure:"plaintext"`
+	// Reflection 是否注册grpc反射服务.
+	Reflection bool `json:"reflection,omitempty"             mapstructure:"reflection"`
+	// MaxConcurrentStreams 每个连接允许的最大并发流数量，为0时不限制.
+	MaxConcurrentStreams uint32 `json:"max-concurrent-streams,omitempty" mapstructure:"max-concurrent-streams"`
+	// HealthCheckInterval 检查MySQL和Redis状态并更新健康检查服务的间隔.
+	HealthCheckInterval time.Duration `json:"health-check-interval,omitempty"  mapstructure:"health-check-interval"`
+	// WatchInterval Watch检查新变更的间隔.
+	WatchInterval time.Duration `json:"watch-interval,omitempty"         mapstructure:"watch-interval"`
+	// WatchReserveDays 变更记录保留的天数，超过的记录被定期清理，Watch无法从更早的revision恢复.
+	WatchReserveDays int                  `json:"watch-reserve-days,omitempty"     mapstructure:"watch-reserve-days"`
+	Keepalive        GRPCKeepaliveOptions `json:"keepalive"                        mapstructure:"keepalive"`
+}
+
+// GRPCKeepaliveOptions 包含grpc连接保活和连接生命周期相关的选项.
+type GRPCKeepaliveOptions struct {
+	// Time 连接空闲多久后服务端发送ping.
+	Time time.Duration `json:"time,omitempty"                     mapstructure:"time"`
+	// Timeout 发送ping后等待响应的时间，超时后关闭连接.
+	Timeout time.Duration `json:"timeout,omitempty"                  mapstructure:"timeout"`
+	// MinTime 客户端发送ping的最小间隔，更频繁的ping会导致连接被关闭.
+	MinTime time.Duration `json:"min-time,omitempty"                 mapstructure:"min-time"`
+	// PermitWithoutStream 是否允许客户端在没有活跃流时发送ping.
+	PermitWithoutStream bool `json:"permit-without-stream,omitempty"    mapstructure:"permit-without-stream"`
+	// MaxConnectionAge 连接的最长存活时间，为0时不限制.
+	MaxConnectionAge time.Duration `json:"max-connection-age,omitempty"       mapstructure:"max-connection-age"`
+	// MaxConnectionAgeGrace 连接达到最长存活时间后等待进行中调用结束的时间.
+	MaxConnectionAgeGrace time.Duration `json:"max-connection-age-grace,omitempty" mapstructure:"max-connection-age-grace"`
+}
+
+// GRPCAuthOptions 包含grpc服务认证相关的选项. 调用方需要提供由CAFile签发的客户端证书，
+// 或者在authorization metadata中携带bearer token(个人访问令牌或者使用密钥对签名的jwt).
+type GRPCAuthOptions struct {
+	// CAFile 用于校验客户端证书的CA证书文件，为空时不接受客户端证书认证.
+	CAFile string `json:"ca-file,omitempty"            mapstructure:"ca-file"`
+	// IdentityField 从客户端证书中获取调用方身份的字段: cn, san-dns, san-email, san-uri.
+	IdentityField string `json:"identity-field,omitempty"     mapstructure:"identity-field"`
+	// AllowedIdentities 允许访问grpc服务的身份，证书认证时是证书中的身份，token认证时是用户名.
+	AllowedIdentities []string `json:"allowed-identities,omitempty" mapstructure:"allowed-identities"`
 }
 
 // NewGRPCOptions 创建grpc配置的默认选项.
 func NewGRPCOptions() *GRPCOptions {
 	return &GRPCOptions{
-		BindAddress: "0.0.0.0",
-		BindPort:    8081,
-		MaxMsgSize:  4 * 1024 * 1024,
+		BindAddress:  "0.0.0.0",
+		BindPort:     8081,
+		MaxMsgSize:   4 * 1024 * 1024,
+		Interceptors: []string{"recovery", "requestid", "logger", "metrics"},
+		Timeout:      30 * time.Second,
+		Auth: GRPCAuthOptions{
+			IdentityField:     "cn",
+			AllowedIdentities: []string{"iam-authz-server"},
+		},
+		HealthCheckInterval: 10 * time.Second,
+		WatchInterval:       time.Second,
+		WatchReserveDays:    7,
+		Keepalive: GRPCKeepaliveOptions{
+			Time:    2 * time.Hour,
+			Timeout: 20 * time.Second,
+			MinTime: 5 * time.Minute,
+		},
 	}
 }
 
+// Enabled 返回是否启动grpc服务，端口为0时不启动.
+func (o *GRPCOptions) Enabled() bool {
+	return o.BindPort != 0
+}
+
 // Validate 难和解析命令行传入的参数是否合法.
 func (o *GRPCOptions) Validate() []error {
 	var errs []error
 
 	if o.BindPort < 0 || o.BindPort > 65535 {
 		errs = append(errs, fmt.Errorf(
-			"--insecure-port %v must be between 0 and 65535, inclusive. 0 for turning off insecure (HTTP) port",
+			"--grpc.bind-port %v must be between 0 and 65535, inclusive. 0 for turning off gRPC server",
 			o.BindPort,
 		))
 	}
 
+	if o.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("--grpc.timeout cannot be negative"))
+	}
+
+	if o.Plaintext && o.Auth.CAFile != "" {
+		errs = append(errs, fmt.Errorf("--grpc.auth.ca-file cannot be used with --grpc.plaintext"))
+	}
+
+	if o.HealthCheckInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--grpc.health-check-interval must be greater than 0"))
+	}
+
+	if o.WatchInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--grpc.watch-interval must be greater than 0"))
+	}
+
+	if o.WatchReserveDays <= 0 {
+		errs = append(errs, fmt.Errorf("--grpc.watch-reserve-days must be greater than 0"))
+	}
+
+	if o.Keepalive.Time <= 0 || o.Keepalive.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("--grpc.keepalive.time and --grpc.keepalive.timeout must be greater than 0"))
+	}
+
+	if o.Keepalive.MinTime < 0 || o.Keepalive.MaxConnectionAge < 0 || o.Keepalive.MaxConnectionAgeGrace < 0 {
+		errs = append(errs, fmt.Errorf("--grpc.keepalive durations cannot be negative"))
+	}
+
+	switch o.Auth.IdentityField {
+	case "cn", "san-dns", "san-email", "san-uri":
+	default:
+		errs = append(errs, fmt.Errorf("--grpc.auth.identity-field must be one of: cn, san-dns, san-email, san-uri"))
+	}
+
+	if len(o.Auth.AllowedIdentities) == 0 {
+		errs = append(errs, fmt.Errorf("--grpc.auth.allowed-identities cannot be empty"))
+	}
+
 	return errs
 }
 
@@ -47,4 +150,48 @@ func (o *GRPCOptions) AddFlags(fs *pflag.FlagSet) {
 		"port. This is performed by nginx in the default setup. Set to zero to disable.")
 
 	fs.IntVar(&o.MaxMsgSize, "grpc.max-msg-size", o.MaxMsgSize, "gRPC max message size.")
+
+	fs.StringSliceVar(&o.Interceptors, "grpc.interceptors", o.Interceptors, ""+
+		"List of interceptors installed in order, comma separated. "+
+		"Supported: recovery, requestid, logger, metrics.")
+	fs.DurationVar(&o.Timeout, "grpc.timeout", o.Timeout, ""+
+		"Default deadline of unary gRPC calls. Set to zero to disable.")
+
+	fs.BoolVar(&o.Plaintext, "grpc.plaintext", o.Plaintext, ""+
+		"Serve gRPC without TLS. Only for local development, client certificate auth is unavailable.")
+	fs.BoolVar(&o.Reflection, "grpc.reflection", o.Reflection, ""+
+		"Register the gRPC server reflection service.")
+	fs.Uint32Var(&o.MaxConcurrentStreams, "grpc.max-concurrent-streams", o.MaxConcurrentStreams, ""+
+		"Maximum number of concurrent streams per connection. Set to zero for no limit.")
+	fs.DurationVar(&o.HealthCheckInterval, "grpc.health-check-interval", o.HealthCheckInterval, ""+
+		"Interval of checking MySQL and Redis for the gRPC health service.")
+	fs.DurationVar(&o.WatchInterval, "grpc.watch-interval", o.WatchInterval, ""+
+		"Interval of checking new secret and policy changes for the Watch RPC.")
+	fs.IntVar(&o.WatchReserveDays, "grpc.watch-reserve-days", o.WatchReserveDays, ""+
+		"Number of days secret and policy changes are kept for the Watch RPC. Older changes are deleted periodically.")
+	fs.DurationVar(&o.Keepalive.Time, "grpc.keepalive.time", o.Keepalive.Time, ""+
+		"Ping the client if a connection is idle for this duration.")
+	fs.DurationVar(&o.Keepalive.Timeout, "grpc.keepalive.timeout", o.Keepalive.Timeout, ""+
+		"Close the connection if a ping is not acknowledged within this duration.")
+	fs.DurationVar(&o.Keepalive.MinTime, "grpc.keepalive.min-time", o.Keepalive.MinTime, ""+
+		"Minimum interval between client pings, connections pinging more often are closed.")
+	fs.BoolVar(&o.Keepalive.PermitWithoutStream, "grpc.keepalive.permit-without-stream", o.Keepalive.PermitWithoutStream, ""+
+		"Allow client pings when there are no active streams.")
+	fs.DurationVar(&o.Keepalive.MaxConnectionAge, "grpc.keepalive.max-connection-age", o.Keepalive.MaxConnectionAge, ""+
+		"Maximum age of a connection before it is gracefully closed. Set to zero for no limit.")
+	fs.DurationVar(
+		&o.Keepalive.MaxConnectionAgeGrace,
+		"grpc.keepalive.max-connection-age-grace",
+		o.Keepalive.MaxConnectionAgeGrace,
+		"Time to wait for in-flight calls after a connection reaches its maximum age. Set to zero for no limit.",
+	)
+
+	fs.StringVar(&o.Auth.CAFile, "grpc.auth.ca-file", o.Auth.CAFile, ""+
+		"File containing the CA certificates used to verify gRPC client certificates. "+
+		"If empty, callers must authenticate with a bearer token.")
+	fs.StringVar(&o.Auth.IdentityField, "grpc.auth.identity-field", o.Auth.IdentityField, ""+
+		"Client certificate field mapped to the caller identity. Supported: cn, san-dns, san-email, san-uri.")
+	fs.StringSliceVar(&o.Auth.AllowedIdentities, "grpc.auth.allowed-identities", o.Auth.AllowedIdentities, ""+
+		"Identities allowed to call the gRPC services, either client certificate identities or "+
+		"usernames of bearer tokens.")
 }
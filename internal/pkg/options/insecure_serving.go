@@ -12,14 +12,18 @@ import (
 
 // InsecureServingOptions 助于创建未认证、未授权、不安全的端口.
 type InsecureServingOptions struct {
-	BindAddress string `json:"bind-address,omitempty" mapstructure:"bind-address"`
-	BindPort    int    `json:"bind-port,omitempty"    mapstructure:"bind-port"`
+	// BindAddress 监听的IP地址，可以指定多个，IPv6地址不需要加方括号.
+	BindAddress []string `json:"bind-address,omitempty" mapstructure:"bind-address"`
+	// BindPort 为0时不监听tcp端口.
+	BindPort int `json:"bind-port,omitempty"    mapstructure:"bind-port"`
+	// UnixSocket 额外监听的unix domain socket文件.
+	UnixSocket string `json:"unix-socket,omitempty"  mapstructure:"unix-socket"`
 }
 
 // NewInsecureServingOptions 创建InsecureServingOptions的默认实例.
 func NewInsecureServingOptions() *InsecureServingOptions {
 	return &InsecureServingOptions{
-		BindAddress: "127.0.0.1",
+		BindAddress: []string{"127.0.0.1"},
 		BindPort:    8080,
 	}
 }
@@ -27,7 +31,8 @@ func NewInsecureServingOptions() *InsecureServingOptions {
 // ApplyTo 把配置选项应用到服务配置.
 func (o *InsecureServingOptions) ApplyTo(c *server.Config) error {
 	c.InsecureServing = &server.InsecureServingInfo{
-		Address: net.JoinHostPort(o.BindAddress, strconv.Itoa(o.BindPort)),
+		Addresses:  joinHostPorts(o.BindAddress, o.BindPort),
+		UnixSocket: o.UnixSocket,
 	}
 
 	return nil
@@ -44,17 +49,53 @@ func (o *InsecureServingOptions) Validate() []error {
 		))
 	}
 
+	errs = append(errs, validateBindAddresses("--insecure.bind-address", o.BindAddress)...)
+
 	return errs
 }
 
 // AddFlags 添加和insecure服务相关的flag到指定的FlagSet中.
 func (o *InsecureServingOptions) AddFlags(fs *pflag.FlagSet) {
-	fs.StringVar(&o.BindAddress, "insecure.bind-address", o.BindAddress, ""+
-		"The IP address on which to serve the --insecure.bind-port "+
+	fs.StringSliceVar(&o.BindAddress, "insecure.bind-address", o.BindAddress, ""+
+		"The IP addresses on which to serve the --insecure.bind-port, comma separated "+
 		"(set to 0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces).")
 	fs.IntVar(&o.BindPort, "insecure.bind-port", o.BindPort, ""+
 		"The port on which to serve unsecured, unauthenticated access. It is assumed "+
 		"that firewall rules are set up such that this port is not reachable from outside of "+
 		"the deployed machine and that port 443 on the iam public address is proxied to this "+
 		"port. This is performed by nginx in the default setup. Set to zero to disable.")
+	fs.StringVar(&o.UnixSocket, "insecure.unix-socket", o.UnixSocket, ""+
+		"The unix domain socket file on which to serve unsecured, unauthenticated access, "+
+		"in addition to --insecure.bind-port. A stale socket file left by a previous run is removed.")
+}
+
+// joinHostPorts 把每个IP地址和端口连接成监听地址，端口为0时返回nil.
+func joinHostPorts(addresses []string, port int) []string {
+	if port == 0 {
+		return nil
+	}
+
+	if len(addresses) == 0 {
+		addresses = []string{""}
+	}
+
+	hostPorts := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		hostPorts = append(hostPorts, net.JoinHostPort(address, strconv.Itoa(port)))
+	}
+
+	return hostPorts
+}
+
+// validateBindAddresses 校验监听地址都是合法的IP，空字符串表示所有网卡.
+func validateBindAddresses(flag string, addresses []string) []error {
+	var errs []error
+
+	for _, address := range addresses {
+		if address != "" && net.ParseIP(address) == nil {
+			errs = append(errs, fmt.Errorf("%s %q is not a valid IP address", flag, address))
+		}
+	}
+
+	return errs
 }
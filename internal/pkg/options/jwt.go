@@ -5,9 +5,10 @@ import (
 	"time"
 	"unicode/utf8"
 
-	"github.com/cuizhaoyue/iams/internal/pkg/server"
-
 	"github.com/spf13/pflag"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/server"
+	"github.com/cuizhaoyue/iams/pkg/jwks"
 )
 
 // JWTOptions 包含jwt功能相关的配置选项.
@@ -16,6 +17,20 @@ type JWTOptions struct {
 	Key        string        `json:"key,omitempty"         mapstructure:"key"`
 	Timeout    time.Duration `json:"timeout,omitempty"     mapstructure:"timeout"`
 	MaxRefresh time.Duration `json:"max-refresh,omitempty" mapstructure:"max-refresh"`
+
+	// SigningAlgorithm 为HS256时使用Key签名，否则使用Keys中的非对称密钥签名.
+	SigningAlgorithm string           `json:"signing-algorithm,omitempty" mapstructure:"signing-algorithm"`
+	Keys             []*JWTKeyOptions `json:"keys,omitempty"              mapstructure:"keys"`
+	GracePeriod      time.Duration    `json:"grace-period,omitempty"      mapstructure:"grace-period"`
+	Leeway           time.Duration    `json:"leeway,omitempty"            mapstructure:"leeway"`
+}
+
+// JWTKeyOptions 定义了一个非对称签名密钥，NotBefore和NotAfter使用RFC3339格式.
+type JWTKeyOptions struct {
+	ID             string `json:"kid"                 mapstructure:"kid"`
+	PrivateKeyFile string `json:"private-key-file"    mapstructure:"private-key-file"`
+	NotBefore      string `json:"not-before,omitempty" mapstructure:"not-before"`
+	NotAfter       string `json:"not-after,omitempty"  mapstructure:"not-after"`
 }
 
 // NewJWTOptions 创建带有默认参数的配置选项.
@@ -23,10 +38,13 @@ func NewJWTOptions() *JWTOptions {
 	defaults := server.NewConfig()
 
 	return &JWTOptions{
-		Realm:      defaults.Jwt.Realm,
-		Key:        defaults.Jwt.Key,
-		Timeout:    defaults.Jwt.Timeout,
-		MaxRefresh: defaults.Jwt.MaxRefresh,
+		Realm:            defaults.Jwt.Realm,
+		Key:              defaults.Jwt.Key,
+		Timeout:          defaults.Jwt.Timeout,
+		MaxRefresh:       defaults.Jwt.MaxRefresh,
+		SigningAlgorithm: "HS256",
+		Keys:             []*JWTKeyOptions{},
+		Leeway:           30 * time.Second,
 	}
 }
 
@@ -42,6 +60,64 @@ func (o *JWTOptions) ApplyTo(c *server.Config) error {
 	return nil
 }
 
+// KeySet 加载非对称签名密钥，使用HS256时返回nil.
+// GracePeriod为0时，旧密钥在max(Timeout, MaxRefresh)内仍可用于校验签名.
+func (o *JWTOptions) KeySet() (*jwks.KeySet, error) {
+	if o.SigningAlgorithm == "HS256" {
+		return nil, nil
+	}
+
+	keys := make([]*jwks.Key, 0, len(o.Keys))
+
+	for _, k := range o.Keys {
+		notBefore, notAfter, err := k.period()
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := jwks.LoadKey(k.ID, k.PrivateKeyFile, notBefore, notAfter)
+		if err != nil {
+			return nil, fmt.Errorf("load jwt key %s: %w", k.ID, err)
+		}
+
+		if key.Algorithm != o.SigningAlgorithm {
+			return nil, fmt.Errorf("jwt key %s is a %s key, but --jwt.signing-algorithm is %s",
+				k.ID, key.Algorithm, o.SigningAlgorithm)
+		}
+
+		keys = append(keys, key)
+	}
+
+	grace := o.GracePeriod
+	if grace == 0 {
+		grace = o.Timeout
+		if o.MaxRefresh > grace {
+			grace = o.MaxRefresh
+		}
+	}
+
+	return jwks.NewKeySet(keys, grace)
+}
+
+func (k *JWTKeyOptions) period() (time.Time, time.Time, error) {
+	var notBefore, notAfter time.Time
+	var err error
+
+	if k.NotBefore != "" {
+		if notBefore, err = time.Parse(time.RFC3339, k.NotBefore); err != nil {
+			return notBefore, notAfter, fmt.Errorf("jwt key %s: invalid not-before: %w", k.ID, err)
+		}
+	}
+
+	if k.NotAfter != "" {
+		if notAfter, err = time.Parse(time.RFC3339, k.NotAfter); err != nil {
+			return notBefore, notAfter, fmt.Errorf("jwt key %s: invalid not-after: %w", k.ID, err)
+		}
+	}
+
+	return notBefore, notAfter, nil
+}
+
 // Validate 校验参数是否合法
 func (o *JWTOptions) Validate() []error {
 	var errs []error
@@ -51,6 +127,26 @@ func (o *JWTOptions) Validate() []error {
 		errs = append(errs, fmt.Errorf("--jwt.key must greater than 5 and less than 33"))
 	}
 
+	switch o.SigningAlgorithm {
+	case "HS256":
+	case jwks.AlgorithmRS256, jwks.AlgorithmES256, jwks.AlgorithmEdDSA:
+		if len(o.Keys) == 0 {
+			errs = append(errs, fmt.Errorf("jwt.keys must be set when --jwt.signing-algorithm is %s", o.SigningAlgorithm))
+
+			break
+		}
+
+		if _, err := o.KeySet(); err != nil {
+			errs = append(errs, err)
+		}
+	default:
+		errs = append(errs, fmt.Errorf("--jwt.signing-algorithm must be one of: HS256, RS256, ES256, EdDSA"))
+	}
+
+	if o.GracePeriod < 0 || o.Leeway < 0 {
+		errs = append(errs, fmt.Errorf("--jwt.grace-period and --jwt.leeway can not be negative"))
+	}
+
 	return errs
 }
 
@@ -66,4 +162,17 @@ func (o *JWTOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.DurationVar(&o.MaxRefresh, "jwt.max-refresh", o.MaxRefresh, ""+
 		"This field allows clients to refresh their token until MaxRefresh has passed.")
+
+	fs.StringVar(&o.SigningAlgorithm, "jwt.signing-algorithm", o.SigningAlgorithm, ""+
+		"Algorithm used to sign jwt token, one of: HS256, RS256, ES256, EdDSA. HS256 signs with --jwt.key, "+
+		"the others sign with the PEM encoded private keys listed in `jwt.keys` of the config file, each with "+
+		"a kid, private-key-file and optional not-before/not-after (RFC3339). The newest key whose not-before "+
+		"has passed is used for signing, public keys are published at /.well-known/jwks.json.")
+
+	fs.DurationVar(&o.GracePeriod, "jwt.grace-period", o.GracePeriod, ""+
+		"How long a rotated out signing key is still accepted for verification. "+
+		"Defaults to the larger of --jwt.timeout and --jwt.max-refresh.")
+
+	fs.DurationVar(&o.Leeway, "jwt.leeway", o.Leeway, ""+
+		"Allowed clock skew when validating the exp, nbf and iat claims.")
 }
@@ -0,0 +1,175 @@
+package options
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/cuizhaoyue/iams/pkg/ldap"
+)
+
+// LDAPOptions 定义了LDAP认证相关的配置选项，URL为空时不启用LDAP认证.
+type LDAPOptions struct {
+	URL                string        `json:"url,omitempty"                  mapstructure:"url"`
+	BindDN             string        `json:"bind-dn,omitempty"              mapstructure:"bind-dn"`
+	BindPassword       string        `json:"-"                              mapstructure:"bind-password"`
+	BaseDN             string        `json:"base-dn,omitempty"              mapstructure:"base-dn"`
+	UserFilter         string        `json:"user-filter,omitempty"          mapstructure:"user-filter"`
+	StartTLS           bool          `json:"start-tls"                      mapstructure:"start-tls"`
+	CAFile             string        `json:"ca-file,omitempty"              mapstructure:"ca-file"`
+	InsecureSkipVerify bool          `json:"insecure-skip-verify,omitempty" mapstructure:"insecure-skip-verify"`
+	Timeout            time.Duration `json:"timeout,omitempty"              mapstructure:"timeout"`
+	UsernameAttribute  string        `json:"username-attribute,omitempty"   mapstructure:"username-attribute"`
+	NicknameAttribute  string        `json:"nickname-attribute,omitempty"   mapstructure:"nickname-attribute"`
+	EmailAttribute     string        `json:"email-attribute,omitempty"      mapstructure:"email-attribute"`
+	PhoneAttribute     string        `json:"phone-attribute,omitempty"      mapstructure:"phone-attribute"`
+	SyncInterval       time.Duration `json:"sync-interval,omitempty"        mapstructure:"sync-interval"`
+}
+
+// NewLDAPOptions 创建带有默认参数的LDAPOptions.
+func NewLDAPOptions() *LDAPOptions {
+	return &LDAPOptions{
+		UserFilter:        "(&(objectClass=person)(uid=%s))",
+		Timeout:           10 * time.Second,
+		UsernameAttribute: "uid",
+		NicknameAttribute: "cn",
+		EmailAttribute:    "mail",
+		PhoneAttribute:    "telephoneNumber",
+		SyncInterval:      time.Hour,
+	}
+}
+
+// Enabled 返回是否启用了LDAP认证.
+func (o *LDAPOptions) Enabled() bool {
+	return o.URL != ""
+}
+
+// ToLDAPConfig 转换为pkg/ldap使用的配置.
+func (o *LDAPOptions) ToLDAPConfig() (ldap.Config, error) {
+	cfg := ldap.Config{
+		URL:          o.URL,
+		BindDN:       o.BindDN,
+		BindPassword: o.BindPassword,
+		BaseDN:       o.BaseDN,
+		UserFilter:   o.UserFilter,
+		StartTLS:     o.StartTLS,
+		Timeout:      o.Timeout,
+		Attributes: ldap.Attributes{
+			Username: o.UsernameAttribute,
+			Nickname: o.NicknameAttribute,
+			Email:    o.EmailAttribute,
+			Phone:    o.PhoneAttribute,
+		},
+	}
+
+	if o.CAFile == "" && !o.InsecureSkipVerify {
+		return cfg, nil
+	}
+
+	host := strings.TrimPrefix(strings.TrimPrefix(o.URL, "ldaps://"), "ldap://")
+	host = strings.Split(strings.Split(host, "/")[0], ":")[0]
+
+	// nolint: gosec // 是否跳过证书校验由用户决定
+	cfg.TLSConfig = &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		data, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return cfg, fmt.Errorf("read ldap ca file: %w", err)
+		}
+
+		cfg.TLSConfig.RootCAs = x509.NewCertPool()
+		if !cfg.TLSConfig.RootCAs.AppendCertsFromPEM(data) {
+			return cfg, fmt.Errorf("no certificate found in ldap ca file %s", o.CAFile)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Validate 校验LDAP参数是否合法.
+func (o *LDAPOptions) Validate() []error {
+	if !o.Enabled() {
+		return nil
+	}
+
+	var errs []error
+
+	if !strings.HasPrefix(o.URL, "ldap://") && !strings.HasPrefix(o.URL, "ldaps://") {
+		errs = append(errs, fmt.Errorf("--ldap.url must start with ldap:// or ldaps://"))
+	}
+
+	if o.StartTLS && strings.HasPrefix(o.URL, "ldaps://") {
+		errs = append(errs, fmt.Errorf("--ldap.start-tls can not be used with ldaps://"))
+	}
+
+	if o.BaseDN == "" {
+		errs = append(errs, fmt.Errorf("--ldap.base-dn can not be empty"))
+	}
+
+	if strings.Count(o.UserFilter, "%s") != 1 {
+		errs = append(errs, fmt.Errorf("--ldap.user-filter must contain exactly one %%s"))
+	}
+
+	if o.UsernameAttribute == "" {
+		errs = append(errs, fmt.Errorf("--ldap.username-attribute can not be empty"))
+	}
+
+	if o.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("--ldap.timeout must be greater than 0"))
+	}
+
+	if o.SyncInterval < 0 {
+		errs = append(errs, fmt.Errorf("--ldap.sync-interval can not be negative"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加LDAP相关的flag到指定的FlagSet中.
+func (o *LDAPOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.URL, "ldap.url", o.URL, ""+
+		"Address of the LDAP server, e.g. ldap://ldap.example.com:389 or ldaps://ldap.example.com:636. "+
+		"LDAP authentication is disabled if empty.")
+
+	fs.StringVar(&o.BindDN, "ldap.bind-dn", o.BindDN, ""+
+		"DN of the service account used to search users. Search anonymously if empty.")
+
+	fs.StringVar(&o.BindPassword, "ldap.bind-password", o.BindPassword, "Password of the service account.")
+
+	fs.StringVar(&o.BaseDN, "ldap.base-dn", o.BaseDN, "Base DN to search users from.")
+
+	fs.StringVar(&o.UserFilter, "ldap.user-filter", o.UserFilter, ""+
+		"Filter used to find a user, %s is replaced by the escaped username.")
+
+	fs.BoolVar(&o.StartTLS, "ldap.start-tls", o.StartTLS, "Upgrade ldap:// connections to TLS with StartTLS.")
+
+	fs.StringVar(&o.CAFile, "ldap.ca-file", o.CAFile, "CA certificate file used to verify the LDAP server.")
+
+	fs.BoolVar(&o.InsecureSkipVerify, "ldap.insecure-skip-verify", o.InsecureSkipVerify, ""+
+		"Skip verifying the certificate of the LDAP server.")
+
+	fs.DurationVar(&o.Timeout, "ldap.timeout", o.Timeout, "Timeout of connecting and each request to the LDAP server.")
+
+	fs.StringVar(&o.UsernameAttribute, "ldap.username-attribute", o.UsernameAttribute, ""+
+		"LDAP attribute mapped to the username.")
+
+	fs.StringVar(&o.NicknameAttribute, "ldap.nickname-attribute", o.NicknameAttribute, ""+
+		"LDAP attribute mapped to the nickname.")
+
+	fs.StringVar(&o.EmailAttribute, "ldap.email-attribute", o.EmailAttribute, "LDAP attribute mapped to the email.")
+
+	fs.StringVar(&o.PhoneAttribute, "ldap.phone-attribute", o.PhoneAttribute, "LDAP attribute mapped to the phone.")
+
+	fs.DurationVar(&o.SyncInterval, "ldap.sync-interval", o.SyncInterval, ""+
+		"Interval of disabling users removed from the directory. 0 disables the periodic sync.")
+}
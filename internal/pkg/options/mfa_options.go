@@ -0,0 +1,82 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// MFAOptions 定义了多因素认证相关的配置选项.
+type MFAOptions struct {
+	Issuer            string        `json:"issuer,omitempty"              mapstructure:"issuer"`
+	Skew              uint          `json:"skew,omitempty"                mapstructure:"skew"`
+	PreAuthTimeout    time.Duration `json:"pre-auth-timeout,omitempty"    mapstructure:"pre-auth-timeout"`
+	RecoveryCodeCount int           `json:"recovery-code-count,omitempty" mapstructure:"recovery-code-count"`
+	MaxAttempts       int           `json:"max-attempts"                  mapstructure:"max-attempts"`
+	LockoutDuration   time.Duration `json:"lockout-duration,omitempty"    mapstructure:"lockout-duration"`
+}
+
+// NewMFAOptions 创建带有默认参数的MFAOptions.
+func NewMFAOptions() *MFAOptions {
+	return &MFAOptions{
+		Issuer:            "iam",
+		Skew:              1,
+		PreAuthTimeout:    5 * time.Minute,
+		RecoveryCodeCount: 10,
+		MaxAttempts:       5,
+		LockoutDuration:   15 * time.Minute,
+	}
+}
+
+// Validate 校验MFA参数是否合法.
+func (o *MFAOptions) Validate() []error {
+	var errs []error
+
+	if o.Issuer == "" {
+		errs = append(errs, fmt.Errorf("--mfa.issuer can not be empty"))
+	}
+
+	if o.Skew > 3 {
+		errs = append(errs, fmt.Errorf("--mfa.skew must be less than or equal to 3"))
+	}
+
+	if o.PreAuthTimeout <= 0 || o.PreAuthTimeout > 30*time.Minute {
+		errs = append(errs, fmt.Errorf("--mfa.pre-auth-timeout must be greater than 0 and at most 30m"))
+	}
+
+	if o.RecoveryCodeCount < 1 {
+		errs = append(errs, fmt.Errorf("--mfa.recovery-code-count must be greater than 0"))
+	}
+
+	if o.MaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("--mfa.max-attempts can not be negative"))
+	}
+
+	if o.MaxAttempts > 0 && o.LockoutDuration <= 0 {
+		errs = append(errs, fmt.Errorf("--mfa.lockout-duration must be greater than 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加MFA相关的flag到指定的FlagSet中.
+func (o *MFAOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Issuer, "mfa.issuer", o.Issuer, "Issuer name displayed by authenticator apps.")
+
+	fs.UintVar(&o.Skew, "mfa.skew", o.Skew, ""+
+		"Number of 30s time steps before and after the current one in which a TOTP code is still accepted.")
+
+	fs.DurationVar(&o.PreAuthTimeout, "mfa.pre-auth-timeout", o.PreAuthTimeout, ""+
+		"Lifetime of the pre-auth token issued after the password check, during which the TOTP code must be verified.")
+
+	fs.IntVar(&o.RecoveryCodeCount, "mfa.recovery-code-count", o.RecoveryCodeCount, ""+
+		"Number of one-time recovery codes generated when MFA is activated.")
+
+	fs.IntVar(&o.MaxAttempts, "mfa.max-attempts", o.MaxAttempts, ""+
+		"Number of failed TOTP or recovery code attempts after which MFA verification of the user is locked "+
+		"for --mfa.lockout-duration. Zero means no limit.")
+
+	fs.DurationVar(&o.LockoutDuration, "mfa.lockout-duration", o.LockoutDuration, ""+
+		"Window in which failed MFA attempts are counted, starting from the first failure.")
+}
@@ -0,0 +1,72 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// NotificationOptions 定义了通过redis发布资源变更通知相关的配置选项.
+type NotificationOptions struct {
+	Enabled       bool          `json:"enabled"                  mapstructure:"enabled"`
+	Channel       string        `json:"channel,omitempty"        mapstructure:"channel"`
+	MaxRetries    int           `json:"max-retries,omitempty"    mapstructure:"max-retries"`
+	RetryInterval time.Duration `json:"retry-interval,omitempty" mapstructure:"retry-interval"`
+	QueueSize     int           `json:"queue-size,omitempty"     mapstructure:"queue-size"`
+}
+
+// NewNotificationOptions 创建带有默认参数的NotificationOptions.
+func NewNotificationOptions() *NotificationOptions {
+	return &NotificationOptions{
+		Enabled:       true,
+		Channel:       "iam.cluster.notifications",
+		MaxRetries:    3,
+		RetryInterval: time.Second,
+		QueueSize:     1000,
+	}
+}
+
+// Validate 校验通知参数是否合法.
+func (o *NotificationOptions) Validate() []error {
+	var errs []error
+
+	if !o.Enabled {
+		return errs
+	}
+
+	if o.Channel == "" {
+		errs = append(errs, fmt.Errorf("--notification.channel can not be empty"))
+	}
+
+	if o.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("--notification.max-retries can not be negative"))
+	}
+
+	if o.RetryInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--notification.retry-interval must be greater than 0"))
+	}
+
+	if o.QueueSize <= 0 {
+		errs = append(errs, fmt.Errorf("--notification.queue-size must be greater than 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加通知相关的flag到指定的FlagSet中.
+func (o *NotificationOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "notification.enabled", o.Enabled, ""+
+		"Publish PolicyChanged and SecretChanged notifications to redis when policies or secrets change.")
+
+	fs.StringVar(&o.Channel, "notification.channel", o.Channel, "Redis pub/sub channel of the notifications.")
+
+	fs.IntVar(&o.MaxRetries, "notification.max-retries", o.MaxRetries, ""+
+		"Maximum number of retries when publishing a notification fails.")
+
+	fs.DurationVar(&o.RetryInterval, "notification.retry-interval", o.RetryInterval, ""+
+		"Interval before the first retry, doubled after each failed retry.")
+
+	fs.IntVar(&o.QueueSize, "notification.queue-size", o.QueueSize, ""+
+		"Number of notifications waiting to be published. Notifications are dropped when the queue is full.")
+}
@@ -0,0 +1,72 @@
+package options
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// OAuthOptions 定义了OAuth2/OIDC提供者相关的配置选项.
+type OAuthOptions struct {
+	Issuer             string        `json:"issuer,omitempty"               mapstructure:"issuer"`
+	LoginURL           string        `json:"login-url,omitempty"            mapstructure:"login-url"`
+	CodeTimeout        time.Duration `json:"code-timeout,omitempty"         mapstructure:"code-timeout"`
+	AccessTokenTimeout time.Duration `json:"access-token-timeout,omitempty" mapstructure:"access-token-timeout"`
+	IDTokenTimeout     time.Duration `json:"id-token-timeout,omitempty"     mapstructure:"id-token-timeout"`
+}
+
+// NewOAuthOptions 创建带有默认参数的OAuthOptions.
+func NewOAuthOptions() *OAuthOptions {
+	return &OAuthOptions{
+		Issuer:             "",
+		LoginURL:           "",
+		CodeTimeout:        time.Minute,
+		AccessTokenTimeout: time.Hour,
+		IDTokenTimeout:     time.Hour,
+	}
+}
+
+// Validate 校验OAuth2参数是否合法.
+func (o *OAuthOptions) Validate() []error {
+	var errs []error
+
+	for flag, value := range map[string]string{"issuer": o.Issuer, "login-url": o.LoginURL} {
+		if value == "" {
+			continue
+		}
+
+		if u, err := url.Parse(value); err != nil || !u.IsAbs() || u.RawQuery != "" || u.Fragment != "" {
+			errs = append(errs, fmt.Errorf("--oauth.%s must be an absolute url without query and fragment", flag))
+		}
+	}
+
+	if o.CodeTimeout <= 0 || o.CodeTimeout > 10*time.Minute {
+		errs = append(errs, fmt.Errorf("--oauth.code-timeout must be greater than 0 and at most 10m"))
+	}
+
+	if o.AccessTokenTimeout <= 0 || o.IDTokenTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("--oauth.access-token-timeout and --oauth.id-token-timeout must be greater than 0"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加OAuth2相关的flag到指定的FlagSet中.
+func (o *OAuthOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Issuer, "oauth.issuer", o.Issuer, ""+
+		"Issuer url of the OpenID Connect provider, e.g. https://iam.example.com. "+
+		"If empty, it is derived from the scheme and host of each request.")
+
+	fs.StringVar(&o.LoginURL, "oauth.login-url", o.LoginURL, ""+
+		"Login page unauthenticated users are redirected to from the authorization endpoint. "+
+		"The authorization request url is passed in the return_to query parameter.")
+
+	fs.DurationVar(&o.CodeTimeout, "oauth.code-timeout", o.CodeTimeout, "Lifetime of authorization codes.")
+
+	fs.DurationVar(&o.AccessTokenTimeout, "oauth.access-token-timeout", o.AccessTokenTimeout, ""+
+		"Lifetime of access tokens issued to OAuth2 clients. Refresh tokens expire after jwt.max-refresh.")
+
+	fs.DurationVar(&o.IDTokenTimeout, "oauth.id-token-timeout", o.IDTokenTimeout, "Lifetime of OpenID Connect ID tokens.")
+}
@@ -0,0 +1,101 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cuizhaoyue/iams/pkg/password"
+)
+
+// PasswordOptions 定义了密码哈希算法相关的配置选项.
+type PasswordOptions struct {
+	Algorithm         string `json:"algorithm,omitempty"          mapstructure:"algorithm"`
+	BcryptCost        int    `json:"bcrypt-cost,omitempty"        mapstructure:"bcrypt-cost"`
+	Argon2Memory      uint32 `json:"argon2-memory,omitempty"      mapstructure:"argon2-memory"`
+	Argon2Iterations  uint32 `json:"argon2-iterations,omitempty"  mapstructure:"argon2-iterations"`
+	Argon2Parallelism uint8  `json:"argon2-parallelism,omitempty" mapstructure:"argon2-parallelism"`
+	Argon2SaltLength  uint32 `json:"argon2-salt-length,omitempty" mapstructure:"argon2-salt-length"`
+	Argon2KeyLength   uint32 `json:"argon2-key-length,omitempty"  mapstructure:"argon2-key-length"`
+}
+
+// NewPasswordOptions 创建带有默认参数的PasswordOptions.
+func NewPasswordOptions() *PasswordOptions {
+	defaults := password.NewOptions()
+
+	return &PasswordOptions{
+		Algorithm:         defaults.Algorithm,
+		BcryptCost:        defaults.BcryptCost,
+		Argon2Memory:      defaults.Argon2Memory,
+		Argon2Iterations:  defaults.Argon2Iterations,
+		Argon2Parallelism: defaults.Argon2Parallelism,
+		Argon2SaltLength:  defaults.Argon2SaltLength,
+		Argon2KeyLength:   defaults.Argon2KeyLength,
+	}
+}
+
+// ToPasswordOptions 转换为pkg/password使用的选项.
+func (o *PasswordOptions) ToPasswordOptions() *password.Options {
+	return &password.Options{
+		Algorithm:         o.Algorithm,
+		BcryptCost:        o.BcryptCost,
+		Argon2Memory:      o.Argon2Memory,
+		Argon2Iterations:  o.Argon2Iterations,
+		Argon2Parallelism: o.Argon2Parallelism,
+		Argon2SaltLength:  o.Argon2SaltLength,
+		Argon2KeyLength:   o.Argon2KeyLength,
+	}
+}
+
+// Validate 校验密码哈希参数是否合法.
+func (o *PasswordOptions) Validate() []error {
+	var errs []error
+
+	switch o.Algorithm {
+	case password.AlgorithmBcrypt, password.AlgorithmArgon2id:
+	default:
+		errs = append(errs, fmt.Errorf("--password.algorithm must be one of: bcrypt, argon2id"))
+	}
+
+	if o.BcryptCost < bcrypt.MinCost || o.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("--password.bcrypt-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+
+	if o.Argon2Memory < 8*uint32(o.Argon2Parallelism) {
+		errs = append(errs, fmt.Errorf("--password.argon2-memory must be at least 8*argon2-parallelism KiB"))
+	}
+
+	if o.Argon2Iterations < 1 || o.Argon2Parallelism < 1 {
+		errs = append(errs, fmt.Errorf("--password.argon2-iterations and --password.argon2-parallelism must be greater than 0"))
+	}
+
+	if o.Argon2SaltLength < 8 || o.Argon2KeyLength < 16 {
+		errs = append(errs, fmt.Errorf("--password.argon2-salt-length must be >= 8 and --password.argon2-key-length >= 16"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加密码哈希相关的flag到指定的FlagSet中.
+func (o *PasswordOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Algorithm, "password.algorithm", o.Algorithm, ""+
+		"Hash algorithm used for new passwords. Supported: bcrypt, argon2id. Existing hashes are "+
+		"upgraded to this algorithm on the next successful login or password change.")
+
+	fs.IntVar(&o.BcryptCost, "password.bcrypt-cost", o.BcryptCost, "Cost of the bcrypt algorithm.")
+
+	fs.Uint32Var(&o.Argon2Memory, "password.argon2-memory", o.Argon2Memory, "Memory used by argon2id in KiB.")
+
+	fs.Uint32Var(&o.Argon2Iterations, "password.argon2-iterations", o.Argon2Iterations, ""+
+		"Number of iterations (passes over the memory) of argon2id.")
+
+	fs.Uint8Var(&o.Argon2Parallelism, "password.argon2-parallelism", o.Argon2Parallelism, ""+
+		"Number of threads used by argon2id.")
+
+	fs.Uint32Var(&o.Argon2SaltLength, "password.argon2-salt-length", o.Argon2SaltLength, ""+
+		"Length in bytes of the random salt used by argon2id.")
+
+	fs.Uint32Var(&o.Argon2KeyLength, "password.argon2-key-length", o.Argon2KeyLength, ""+
+		"Length in bytes of the key generated by argon2id.")
+}
@@ -1,23 +1,56 @@
 package options
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/cuizhaoyue/iams/internal/pkg/server"
+	"github.com/cuizhaoyue/iams/pkg/selfsigned"
 
 	"github.com/spf13/pflag"
 )
 
 // SecureServingOptions 包含和HTTPS服务启动配置相关的选项.
 type SecureServingOptions struct {
-	BindAddress string `json:"bind-address,omitempty" mapstructure:"bind-address"`
-	// 当Listener被设置时，BindPort会被忽略.
+	// BindAddress 监听的IP地址，可以指定多个，IPv6地址不需要加方括号.
+	BindAddress []string `json:"bind-address,omitempty" mapstructure:"bind-address"`
+	// BindPort 为0并且没有设置UnixSocket时不启动https服务.
 	BindPort int `json:"bind-port,omitempty"    mapstructure:"bind-port"`
+	// UnixSocket 额外监听的unix domain socket文件.
+	UnixSocket string `json:"unix-socket,omitempty"  mapstructure:"unix-socket"`
 	// Required设置为true时，BindPort不能为0.
 	Required bool
 	// ServerCert 是提供安全流量的TLS证书信息.
 	ServerCert GeneratableKeyCert `json:"tls"                    mapstructure:"tls"`
+	// ClientAuth 配置客户端证书认证.
+	ClientAuth ClientAuthOptions `json:"client-auth"            mapstructure:"client-auth"`
+}
+
+// 客户端证书的校验模式.
+const (
+	ClientAuthModeNone             = "none"
+	ClientAuthModeVerifyIfGiven    = "verify-if-given"
+	ClientAuthModeRequireAndVerify = "require-and-verify"
+)
+
+// ClientAuthOptions 包含客户端证书认证相关的选项.
+type ClientAuthOptions struct {
+	// Mode 客户端证书的校验模式: none, verify-if-given, require-and-verify.
+	Mode string `json:"mode"                     mapstructure:"mode"`
+	// CAFile 用于校验客户端证书的CA证书文件，可以包含多个证书.
+	CAFile string `json:"ca-file,omitempty"        mapstructure:"ca-file"`
+	// UsernameField 从客户端证书中获取用户名的字段: cn, san-dns, san-email, san-uri.
+	UsernameField string `json:"username-field,omitempty" mapstructure:"username-field"`
+}
+
+// Enabled 返回是否启用了客户端证书认证.
+func (o *ClientAuthOptions) Enabled() bool {
+	return o.Mode != "" && o.Mode != ClientAuthModeNone
 }
 
 // GeneratableKeyCert 包含和证书相关的配置选项.
@@ -25,6 +58,43 @@ type GeneratableKeyCert struct {
 	CertKey       CertKey `json:"cert-key"                 mapstructure:"cert-key"`
 	CertDirectory string  `json:"cert-directory,omitempty" mapstructure:"cert-directory"`
 	PairName      string  `json:"pair-name,omitempty"      mapstructure:"pair-name"`
+	// SelfSigned CertDirectory中没有证书时生成自签名证书的选项.
+	SelfSigned SelfSignedOptions `json:"self-signed"              mapstructure:"self-signed"`
+}
+
+// SelfSignedOptions 包含生成自签名证书相关的选项.
+type SelfSignedOptions struct {
+	// Enabled 是否在CertDirectory中没有证书时生成自签名的CA和服务端证书.
+	Enabled bool `json:"enabled"                mapstructure:"enabled"`
+	// Hosts 服务端证书中的域名或者IP.
+	Hosts []string `json:"hosts,omitempty"        mapstructure:"hosts"`
+	// KeyType 私钥类型: ecdsa-p256, ecdsa-p384, rsa-2048, rsa-4096, ed25519.
+	KeyType string `json:"key-type,omitempty"     mapstructure:"key-type"`
+	// Validity 服务端证书的有效期.
+	Validity time.Duration `json:"validity,omitempty"     mapstructure:"validity"`
+	// RenewBefore 生成的服务端证书在过期前多久重新生成.
+	RenewBefore time.Duration `json:"renew-before,omitempty" mapstructure:"renew-before"`
+}
+
+// Generatable 返回证书文件是否由CertDirectory和PairName生成，并且允许生成自签名证书.
+// 明确指定了证书文件时不会生成证书.
+func (c *GeneratableKeyCert) Generatable() bool {
+	return c.SelfSigned.Enabled && c.CertDirectory != "" &&
+		c.CertKey.CertFile == path.Join(c.CertDirectory, c.PairName+".crt") &&
+		c.CertKey.KeyFile == path.Join(c.CertDirectory, c.PairName+".key")
+}
+
+// SelfSignedOptions 返回生成自签名证书的配置.
+func (c *GeneratableKeyCert) SelfSignedOptions(commonName string) selfsigned.Options {
+	return selfsigned.Options{
+		Dir:         c.CertDirectory,
+		PairName:    c.PairName,
+		CommonName:  commonName,
+		Hosts:       c.SelfSigned.Hosts,
+		KeyType:     c.SelfSigned.KeyType,
+		Validity:    c.SelfSigned.Validity,
+		RenewBefore: c.SelfSigned.RenewBefore,
+	}
 }
 
 // CertKey 包含证书文件配置相关的选项.
@@ -36,27 +106,63 @@ type CertKey struct {
 // NewSecureServingOptions 创建带有默认参数的配置选项.
 func NewSecureServingOptions() *SecureServingOptions {
 	return &SecureServingOptions{
-		BindAddress: "0.0.0.0",
+		BindAddress: []string{"0.0.0.0"},
 		BindPort:    8443,
-		Required:    true,
 		ServerCert: GeneratableKeyCert{
 			PairName:      "iam",
 			CertDirectory: "/var/run/iam",
+			SelfSigned: SelfSignedOptions{
+				Enabled:     true,
+				Hosts:       []string{"localhost", "127.0.0.1"},
+				KeyType:     selfsigned.KeyTypeECDSAP256,
+				Validity:    365 * 24 * time.Hour,
+				RenewBefore: 30 * 24 * time.Hour,
+			},
+		},
+		ClientAuth: ClientAuthOptions{
+			Mode:          ClientAuthModeNone,
+			UsernameField: "cn",
 		},
 	}
 }
 
+// Enabled 返回是否启动https服务.
+func (o *SecureServingOptions) Enabled() bool {
+	return o != nil && (o.BindPort != 0 || o.UnixSocket != "")
+}
+
 // ApplyTo 应用配置选项到服务配置.
 func (o *SecureServingOptions) ApplyTo(c *server.Config) error {
 	c.SecureServing = &server.SecureServingInfo{
-		BindAddress: o.BindAddress,
-		BindPort:    o.BindPort,
+		Addresses:  joinHostPorts(o.BindAddress, o.BindPort),
+		UnixSocket: o.UnixSocket,
 		CertKey: server.CertKey{
 			CertFile: o.ServerCert.CertKey.CertFile,
 			KeyFile:  o.ServerCert.CertKey.KeyFile,
 		},
 	}
 
+	if !o.ClientAuth.Enabled() {
+		return nil
+	}
+
+	data, err := os.ReadFile(o.ClientAuth.CAFile)
+	if err != nil {
+		return fmt.Errorf("read client ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no certificate found in client ca file %s", o.ClientAuth.CAFile)
+	}
+
+	c.SecureServing.ClientCAs = pool
+	c.SecureServing.ClientAuth = tls.VerifyClientCertIfGiven
+
+	if o.ClientAuth.Mode == ClientAuthModeRequireAndVerify {
+		c.SecureServing.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
 	return nil
 }
 
@@ -67,20 +173,77 @@ func (o *SecureServingOptions) Validate() []error {
 	}
 
 	var errs []error
-	if o.Required && o.BindPort < 1 || o.BindPort > 65535 {
+	if o.Required && o.BindPort < 1 || o.BindPort < 0 || o.BindPort > 65535 {
+		desc := "0 for turning off secure (HTTPS) port"
+		if o.Required {
+			desc = "It cannot be turned off with 0"
+		}
+
 		errs = append(errs, fmt.Errorf(
-			"--secure.bind-port %v must be between 1 and 65535, inclusive. It cannot be turned off with 0",
-			o.BindPort,
+			"--secure.bind-port %v must be between 0 and 65535, inclusive. %s",
+			o.BindPort, desc,
 		))
 	}
 
+	errs = append(errs, validateBindAddresses("--secure.bind-address", o.BindAddress)...)
+
+	if o.Enabled() && o.ServerCert.CertKey.CertFile == "" {
+		errs = append(errs, fmt.Errorf(
+			"--secure.tls.cert-key.cert-file or --secure.tls.cert-dir is required when secure (HTTPS) serving is enabled",
+		))
+	}
+
+	if o.ServerCert.SelfSigned.Enabled {
+		errs = append(errs, o.ServerCert.SelfSigned.validate()...)
+	}
+
+	switch o.ClientAuth.Mode {
+	case ClientAuthModeNone, "":
+	case ClientAuthModeVerifyIfGiven, ClientAuthModeRequireAndVerify:
+		if o.ClientAuth.CAFile == "" {
+			errs = append(errs, fmt.Errorf("--secure.client-auth.ca-file is required when client certificate auth is enabled"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("--secure.client-auth.mode must be one of: none, verify-if-given, require-and-verify"))
+	}
+
+	switch o.ClientAuth.UsernameField {
+	case "cn", "san-dns", "san-email", "san-uri":
+	default:
+		errs = append(errs, fmt.Errorf("--secure.client-auth.username-field must be one of: cn, san-dns, san-email, san-uri"))
+	}
+
+	return errs
+}
+
+func (o *SelfSignedOptions) validate() []error {
+	var errs []error
+
+	found := false
+	for _, keyType := range selfsigned.KeyTypes() {
+		found = found || o.KeyType == keyType
+	}
+
+	if !found {
+		errs = append(errs, fmt.Errorf("--secure.tls.self-signed.key-type must be one of: %s",
+			strings.Join(selfsigned.KeyTypes(), ", ")))
+	}
+
+	if o.Validity <= 0 {
+		errs = append(errs, fmt.Errorf("--secure.tls.self-signed.validity must be greater than 0"))
+	}
+
+	if o.RenewBefore < 0 || o.RenewBefore >= o.Validity {
+		errs = append(errs, fmt.Errorf("--secure.tls.self-signed.renew-before must be between 0 and --secure.tls.self-signed.validity"))
+	}
+
 	return errs
 }
 
 // AddFlags 添加https服务配置相关的选项到指定的FlagSet中.
 func (o *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
-	fs.StringVar(&o.BindAddress, "secure.bind-address", o.BindAddress, ""+
-		"The IP address on which to listen for the --secure.bind-port port. The "+
+	fs.StringSliceVar(&o.BindAddress, "secure.bind-address", o.BindAddress, ""+
+		"The IP addresses on which to listen for the --secure.bind-port port, comma separated. The "+
 		"associated interface(s) must be reachable by the rest of the engine, and by CLI/web "+
 		"clients. If blank, all interfaces will be used (0.0.0.0 for all IPv4 interfaces and :: for all IPv6 interfaces).")
 
@@ -91,6 +254,9 @@ func (o *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		desc += " If 0, don't serve HTTPS at all."
 	}
 	fs.IntVar(&o.BindPort, "secure.bind-port", o.BindPort, desc)
+	fs.StringVar(&o.UnixSocket, "secure.unix-socket", o.UnixSocket, ""+
+		"The unix domain socket file on which to serve HTTPS, in addition to --secure.bind-port. "+
+		"A stale socket file left by a previous run is removed.")
 	fs.StringVar(&o.ServerCert.CertDirectory, "secure.tls.cert-dir", o.ServerCert.CertDirectory, ""+
 		"The directory where the TLS certs are located. "+
 		"If --secure.tls.cert-key.cert-file and --secure.tls.cert-key.private-key-file are provided, "+
@@ -113,11 +279,33 @@ func (o *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		""+
 			"File containing the default x509 private key matching --secure.tls.cert-key.cert-file.",
 	)
+	fs.BoolVar(&o.ServerCert.SelfSigned.Enabled, "secure.tls.self-signed.enabled", o.ServerCert.SelfSigned.Enabled, ""+
+		"Generate a self-signed CA and serving certificate into --secure.tls.cert-dir if the certificate files "+
+		"do not exist. Generated certificates are reused on later starts and regenerated when near expiry.")
+	fs.StringSliceVar(&o.ServerCert.SelfSigned.Hosts, "secure.tls.self-signed.hosts", o.ServerCert.SelfSigned.Hosts, ""+
+		"DNS names and IP addresses included in the generated serving certificate.")
+	fs.StringVar(&o.ServerCert.SelfSigned.KeyType, "secure.tls.self-signed.key-type", o.ServerCert.SelfSigned.KeyType, ""+
+		"Key type of the generated certificates. Supported: "+strings.Join(selfsigned.KeyTypes(), ", ")+".")
+	fs.DurationVar(&o.ServerCert.SelfSigned.Validity, "secure.tls.self-signed.validity", o.ServerCert.SelfSigned.Validity, ""+
+		"Validity of the generated serving certificate.")
+	fs.DurationVar(
+		&o.ServerCert.SelfSigned.RenewBefore,
+		"secure.tls.self-signed.renew-before",
+		o.ServerCert.SelfSigned.RenewBefore,
+		"Regenerate the generated serving certificate when it expires within this duration.",
+	)
+	fs.StringVar(&o.ClientAuth.Mode, "secure.client-auth.mode", o.ClientAuth.Mode, ""+
+		"Client certificate authentication mode. Supported: none, verify-if-given, require-and-verify. "+
+		"Verified client certificates authenticate requests without an Authorization header.")
+	fs.StringVar(&o.ClientAuth.CAFile, "secure.client-auth.ca-file", o.ClientAuth.CAFile, ""+
+		"File containing the CA certificates used to verify client certificates.")
+	fs.StringVar(&o.ClientAuth.UsernameField, "secure.client-auth.username-field", o.ClientAuth.UsernameField, ""+
+		"Certificate field mapped to the username. Supported: cn, san-dns, san-email, san-uri.")
 }
 
 // Complete 填充任何必要但是没有设置的字段.
 func (o *SecureServingOptions) Complete() error {
-	if o == nil || o.BindPort == 0 {
+	if !o.Enabled() {
 		return nil
 	}
 
@@ -1,15 +1,33 @@
 package options
 
 import (
+	"fmt"
+	"net"
+	"time"
+
 	"github.com/cuizhaoyue/iams/internal/pkg/server"
 	"github.com/spf13/pflag"
 )
 
 // ServerRunOptions 包含apiserver配置的通用选项.
 type ServerRunOptions struct {
-	Mode        string   `json:"mode,omitempty"        mapstructure:"mode"`
-	Healthz     bool     `json:"healthz,omitempty"     mapstructure:"healthz"`
-	Middlewares []string `json:"middlewares,omitempty" mapstructure:"middlewares"`
+	Mode                         string        `json:"mode,omitempty"                   mapstructure:"mode"`
+	Healthz                      bool          `json:"healthz,omitempty"                mapstructure:"healthz"`
+	Middlewares                  []string      `json:"middlewares,omitempty"            mapstructure:"middlewares"`
+	ReadHeaderTimeout            time.Duration `json:"read-header-timeout"              mapstructure:"read-header-timeout"`
+	ReadTimeout                  time.Duration `json:"read-timeout"                     mapstructure:"read-timeout"`
+	WriteTimeout                 time.Duration `json:"write-timeout"                    mapstructure:"write-timeout"`
+	IdleTimeout                  time.Duration `json:"idle-timeout"                     mapstructure:"idle-timeout"`
+	MaxHeaderBytes               int           `json:"max-header-bytes"                 mapstructure:"max-header-bytes"`
+	MaxRequestBodyBytes          int64         `json:"max-request-body-bytes"           mapstructure:"max-request-body-bytes"`
+	EnableH2C                    bool          `json:"h2c"                              mapstructure:"h2c"`
+	HTTP2MaxStreamsPerConnection uint32        `json:"http2-max-streams-per-connection" mapstructure:"http2-max-streams-per-connection"`
+	HTTP2MaxReadFrameSize        uint32        `json:"http2-max-read-frame-size"        mapstructure:"http2-max-read-frame-size"`
+	ShutdownTimeout              time.Duration `json:"shutdown-timeout"                 mapstructure:"shutdown-timeout"`
+	ShutdownDelay                time.Duration `json:"shutdown-delay"                   mapstructure:"shutdown-delay"`
+	HealthzDiskPath              string        `json:"healthz-disk-path"                mapstructure:"healthz-disk-path"`
+	HealthzDiskMinFree           uint64        `json:"healthz-disk-min-free"            mapstructure:"healthz-disk-min-free"`
+	TrustedProxies               []string      `json:"trusted-proxies"                  mapstructure:"trusted-proxies"`
 }
 
 // NewServerRunOptions 创建带有默认参数的ServerRunOptions对象.
@@ -17,9 +35,23 @@ func NewServerRunOptions() *ServerRunOptions {
 	cfg := server.NewConfig()
 
 	return &ServerRunOptions{
-		Mode:        cfg.Mode,
-		Healthz:     cfg.Healthz,
-		Middlewares: cfg.Middlewares,
+		Mode:                         cfg.Mode,
+		Healthz:                      cfg.Healthz,
+		Middlewares:                  cfg.Middlewares,
+		ReadHeaderTimeout:            cfg.HTTPServing.ReadHeaderTimeout,
+		ReadTimeout:                  cfg.HTTPServing.ReadTimeout,
+		WriteTimeout:                 cfg.HTTPServing.WriteTimeout,
+		IdleTimeout:                  cfg.HTTPServing.IdleTimeout,
+		MaxHeaderBytes:               cfg.HTTPServing.MaxHeaderBytes,
+		MaxRequestBodyBytes:          cfg.HTTPServing.MaxRequestBodyBytes,
+		EnableH2C:                    cfg.HTTPServing.EnableH2C,
+		HTTP2MaxStreamsPerConnection: cfg.HTTPServing.HTTP2MaxConcurrentStreams,
+		HTTP2MaxReadFrameSize:        cfg.HTTPServing.HTTP2MaxReadFrameSize,
+		ShutdownTimeout:              cfg.ShutdownTimeout,
+		ShutdownDelay:                cfg.ShutdownDelay,
+		HealthzDiskPath:              cfg.HealthzDiskPath,
+		HealthzDiskMinFree:           cfg.HealthzDiskMinFree,
+		TrustedProxies:               cfg.TrustedProxies,
 	}
 }
 
@@ -28,15 +60,75 @@ func (o *ServerRunOptions) ApplyTo(c *server.Config) error {
 	c.Mode = o.Mode
 	c.Healthz = o.Healthz
 	c.Middlewares = o.Middlewares
+	c.ShutdownTimeout = o.ShutdownTimeout
+	c.ShutdownDelay = o.ShutdownDelay
+	c.HealthzDiskPath = o.HealthzDiskPath
+	c.HealthzDiskMinFree = o.HealthzDiskMinFree
+	c.TrustedProxies = o.TrustedProxies
+	c.HTTPServing = &server.HTTPServingInfo{
+		ReadHeaderTimeout:         o.ReadHeaderTimeout,
+		ReadTimeout:               o.ReadTimeout,
+		WriteTimeout:              o.WriteTimeout,
+		IdleTimeout:               o.IdleTimeout,
+		MaxHeaderBytes:            o.MaxHeaderBytes,
+		MaxRequestBodyBytes:       o.MaxRequestBodyBytes,
+		EnableH2C:                 o.EnableH2C,
+		HTTP2MaxConcurrentStreams: o.HTTP2MaxStreamsPerConnection,
+		HTTP2MaxReadFrameSize:     o.HTTP2MaxReadFrameSize,
+	}
 
 	return nil
 }
 
 // Validate 校验命令行传入的参数是否合法.
 func (o *ServerRunOptions) Validate() []error {
-	var err []error
+	var errs []error
+
+	timeouts := []struct {
+		flag    string
+		timeout time.Duration
+	}{
+		{"--server.read-header-timeout", o.ReadHeaderTimeout},
+		{"--server.read-timeout", o.ReadTimeout},
+		{"--server.write-timeout", o.WriteTimeout},
+		{"--server.idle-timeout", o.IdleTimeout},
+		{"--server.shutdown-timeout", o.ShutdownTimeout},
+		{"--server.shutdown-delay", o.ShutdownDelay},
+	}
+	for _, t := range timeouts {
+		if t.timeout < 0 {
+			errs = append(errs, fmt.Errorf("%s cannot be negative", t.flag))
+		}
+	}
+
+	if o.ShutdownTimeout > 0 && o.ShutdownDelay >= o.ShutdownTimeout {
+		errs = append(errs, fmt.Errorf("--server.shutdown-delay must be less than --server.shutdown-timeout"))
+	}
 
-	return err
+	if o.MaxHeaderBytes < 0 {
+		errs = append(errs, fmt.Errorf("--server.max-header-bytes cannot be negative"))
+	}
+
+	if o.MaxRequestBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("--server.max-request-body-bytes cannot be negative"))
+	}
+
+	for _, proxy := range o.TrustedProxies {
+		if net.ParseIP(proxy) != nil {
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			errs = append(errs, fmt.Errorf("--server.trusted-proxies must be IP addresses or CIDRs, got %s", proxy))
+		}
+	}
+
+	// HTTP/2协议规定的帧大小范围
+	if o.HTTP2MaxReadFrameSize != 0 && (o.HTTP2MaxReadFrameSize < 1<<14 || o.HTTP2MaxReadFrameSize > 1<<24-1) {
+		errs = append(errs, fmt.Errorf("--server.http2-max-read-frame-size must be 0 or between 16384 and 16777215"))
+	}
+
+	return errs
 }
 
 // AddFlags 添加apiserver的通用配置选项到指定的FlagSet中.
@@ -45,9 +137,60 @@ func (o *ServerRunOptions) AddFlags(fs *pflag.FlagSet) {
 		"Start the server in a specified server mode. Supported server mode: debug, test, release.")
 
 	fs.BoolVar(&o.Healthz, "server.healthz", o.Healthz, ""+
-		"Add self readiness check and install /healthz router.")
+		"Add self readiness check and install /healthz, /livez and /readyz routers. "+
+		"Append ?verbose to list the result of each check and ?exclude=<check> to skip a check.")
 
 	fs.StringSliceVar(&o.Middlewares, "server.middlewares", o.Middlewares, ""+
 		"List of allowed middlewares for server, comma separated. If this list is empty default middlewares will be used.")
 
+	fs.DurationVar(&o.ReadHeaderTimeout, "server.read-header-timeout", o.ReadHeaderTimeout, ""+
+		"The amount of time allowed to read request headers. Zero means no timeout.")
+
+	fs.DurationVar(&o.ReadTimeout, "server.read-timeout", o.ReadTimeout, ""+
+		"The maximum duration for reading the entire request, including the body. Zero means no timeout.")
+
+	fs.DurationVar(&o.WriteTimeout, "server.write-timeout", o.WriteTimeout, ""+
+		"The maximum duration before timing out writes of the response. Zero means no timeout.")
+
+	fs.DurationVar(&o.IdleTimeout, "server.idle-timeout", o.IdleTimeout, ""+
+		"The maximum amount of time to wait for the next request when keep-alives are enabled. "+
+		"Zero means --server.read-timeout is used.")
+
+	fs.IntVar(&o.MaxHeaderBytes, "server.max-header-bytes", o.MaxHeaderBytes, ""+
+		"The maximum number of bytes the server will read parsing the request header's keys and values. "+
+		"Zero means the default of 1MB.")
+
+	fs.Int64Var(&o.MaxRequestBodyBytes, "server.max-request-body-bytes", o.MaxRequestBodyBytes, ""+
+		"The maximum number of bytes of a request body. Zero means no limit.")
+
+	fs.BoolVar(&o.EnableH2C, "server.h2c", o.EnableH2C, ""+
+		"Serve HTTP/2 without TLS (h2c) on the insecure port, in addition to HTTP/1.1.")
+
+	fs.Uint32Var(&o.HTTP2MaxStreamsPerConnection, "server.http2-max-streams-per-connection",
+		o.HTTP2MaxStreamsPerConnection, ""+
+			"The limit that the server gives to clients for the maximum number of streams in an HTTP/2 connection. "+
+			"Zero means to use golang's default.")
+
+	fs.Uint32Var(&o.HTTP2MaxReadFrameSize, "server.http2-max-read-frame-size", o.HTTP2MaxReadFrameSize, ""+
+		"The largest HTTP/2 frame the server is willing to read, between 16384 and 16777215. "+
+		"Zero means to use golang's default.")
+
+	fs.DurationVar(&o.ShutdownTimeout, "server.shutdown-timeout", o.ShutdownTimeout, ""+
+		"The maximum duration of a graceful shutdown, including --server.shutdown-delay, draining in-flight "+
+		"HTTP and gRPC requests, stopping background jobs and closing storage connections. Zero means no limit. "+
+		"Sending the termination signal again forces an immediate exit.")
+
+	fs.DurationVar(&o.ShutdownDelay, "server.shutdown-delay", o.ShutdownDelay, ""+
+		"The duration to keep serving after health checks start failing on shutdown, "+
+		"so that load balancers can stop sending new requests.")
+
+	fs.StringVar(&o.HealthzDiskPath, "server.healthz-disk-path", o.HealthzDiskPath, ""+
+		"The path whose file system free space is checked by /readyz. Empty disables the check.")
+
+	fs.Uint64Var(&o.HealthzDiskMinFree, "server.healthz-disk-min-free", o.HealthzDiskMinFree, ""+
+		"The minimum free bytes on --server.healthz-disk-path for /readyz to pass.")
+
+	fs.StringSliceVar(&o.TrustedProxies, "server.trusted-proxies", o.TrustedProxies, ""+
+		"IP addresses or CIDRs of reverse proxies whose X-Forwarded-For and X-Real-IP headers are trusted "+
+		"to find the client address. Empty means no proxy is trusted and the peer address is used.")
 }
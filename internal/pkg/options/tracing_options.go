@@ -0,0 +1,115 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/server"
+	"github.com/cuizhaoyue/iams/pkg/tracing"
+)
+
+// TracingOptions 定义了OpenTelemetry链路追踪相关的配置选项.
+type TracingOptions struct {
+	Enabled     bool              `json:"enabled"                mapstructure:"enabled"`
+	ServiceName string            `json:"service-name,omitempty" mapstructure:"service-name"`
+	Exporter    string            `json:"exporter,omitempty"     mapstructure:"exporter"`
+	Endpoint    string            `json:"endpoint,omitempty"     mapstructure:"endpoint"`
+	Insecure    bool              `json:"insecure,omitempty"     mapstructure:"insecure"`
+	Headers     map[string]string `json:"-"                      mapstructure:"headers"`
+	File        string            `json:"file,omitempty"         mapstructure:"file"`
+	SampleRatio float64           `json:"sample-ratio"           mapstructure:"sample-ratio"`
+}
+
+// NewTracingOptions 创建带有默认参数的TracingOptions.
+func NewTracingOptions() *TracingOptions {
+	return &TracingOptions{
+		Enabled:     false,
+		ServiceName: "iam-apiserver",
+		Exporter:    tracing.ExporterOTLPGRPC,
+		Headers:     map[string]string{},
+		File:        "/var/log/iam/iam-apiserver-trace.json",
+		SampleRatio: 1,
+	}
+}
+
+// ToTracingOptions 转换为tracing包使用的配置.
+func (o *TracingOptions) ToTracingOptions() tracing.Options {
+	return tracing.Options{
+		ServiceName: o.ServiceName,
+		Exporter:    o.Exporter,
+		Endpoint:    o.Endpoint,
+		Insecure:    o.Insecure,
+		Headers:     o.Headers,
+		File:        o.File,
+		SampleRatio: o.SampleRatio,
+	}
+}
+
+// ApplyTo 把配置选项应用到服务配置.
+func (o *TracingOptions) ApplyTo(c *server.Config) error {
+	c.EnableTracing = o.Enabled
+	c.ServiceName = o.ServiceName
+
+	return nil
+}
+
+// Validate 校验链路追踪参数是否合法.
+func (o *TracingOptions) Validate() []error {
+	var errs []error
+
+	if !o.Enabled {
+		return errs
+	}
+
+	if o.ServiceName == "" {
+		errs = append(errs, fmt.Errorf("--tracing.service-name can not be empty"))
+	}
+
+	valid := false
+	for _, exporter := range tracing.Exporters() {
+		if o.Exporter == exporter {
+			valid = true
+		}
+	}
+
+	if !valid {
+		errs = append(errs, fmt.Errorf("--tracing.exporter must be one of %s", strings.Join(tracing.Exporters(), ", ")))
+	}
+
+	if o.Exporter == tracing.ExporterFile && o.File == "" {
+		errs = append(errs, fmt.Errorf("--tracing.file can not be empty when --tracing.exporter is %s", tracing.ExporterFile))
+	}
+
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("--tracing.sample-ratio must be between 0 and 1"))
+	}
+
+	return errs
+}
+
+// AddFlags 添加链路追踪相关的flag到指定的FlagSet中.
+func (o *TracingOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "tracing.enabled", o.Enabled, ""+
+		"Export OpenTelemetry traces of http requests, grpc calls, service methods, sql queries and redis commands.")
+
+	fs.StringVar(&o.ServiceName, "tracing.service-name", o.ServiceName, "Service name reported in traces.")
+
+	fs.StringVar(&o.Exporter, "tracing.exporter", o.Exporter, ""+
+		"Trace exporter, one of "+strings.Join(tracing.Exporters(), ", ")+". "+
+		"stdout and file write spans as json lines and work without a collector.")
+
+	fs.StringVar(&o.Endpoint, "tracing.endpoint", o.Endpoint, ""+
+		"Address of the OTLP collector, e.g. 127.0.0.1:4317. Defaults to the OTLP default endpoint.")
+
+	fs.BoolVar(&o.Insecure, "tracing.insecure", o.Insecure, "Connect to the OTLP collector without TLS.")
+
+	fs.StringToStringVar(&o.Headers, "tracing.headers", o.Headers, ""+
+		"Headers sent to the OTLP collector, e.g. authorization=Bearer xxx.")
+
+	fs.StringVar(&o.File, "tracing.file", o.File, "File that spans are appended to when --tracing.exporter is file.")
+
+	fs.Float64Var(&o.SampleRatio, "tracing.sample-ratio", o.SampleRatio, ""+
+		"Ratio of traces to sample when the caller has not made a sampling decision, between 0 and 1.")
+}
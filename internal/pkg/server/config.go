@@ -1,11 +1,15 @@
 package server
 
 import (
-	"net"
-	"strconv"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"github.com/cuizhaoyue/iams/pkg/certreloader"
 )
 
 const (
@@ -20,6 +24,7 @@ const (
 type Config struct {
 	SecureServing   *SecureServingInfo
 	InsecureServing *InsecureServingInfo
+	HTTPServing     *HTTPServingInfo
 	Jwt             *JwtInfo
 	Mode            string
 	Middlewares     []string
@@ -27,13 +32,42 @@ type Config struct {
 	Healthz       bool
 	EnableProfile bool
 	EnableMetrics bool
+	EnableTracing bool
+
+	// ServiceName 服务名称，用于链路追踪.
+	ServiceName string
+
+	// HealthzDiskPath 就绪检查中检查可用空间的路径，为空时不检查.
+	HealthzDiskPath string
+	// HealthzDiskMinFree 就绪检查要求的最少可用字节数.
+	HealthzDiskMinFree uint64
+
+	// ShutdownTimeout 关闭服务时等待正在处理的请求完成的最长时间，为0时一直等待.
+	ShutdownTimeout time.Duration
+	// ShutdownDelay 关闭服务时设置为未就绪后等待多久再开始关闭服务，让负载均衡有时间摘除实例.
+	ShutdownDelay time.Duration
+
+	// Dump dump中间件的配置，只在Middlewares中包含dump时生效.
+	Dump middleware.DumpConfig
+
+	// TrustedProxies 信任的代理的地址或者网段，只有来自这些地址的请求才从X-Forwarded-For等请求头中获取客户端地址，
+	// 为空时不信任任何代理，客户端地址为连接的对端地址.
+	TrustedProxies []string
 }
 
 // SecureServingInfo 保存tls服务的配置.
 type SecureServingInfo struct {
-	BindAddress string
-	BindPort    int
-	CertKey     CertKey
+	// Addresses 监听的tcp地址，例如: 0.0.0.0:8443、[::]:8443.
+	Addresses []string
+	// UnixSocket 监听的unix domain socket文件.
+	UnixSocket string
+	CertKey    CertKey
+	// ClientCAs 用于校验客户端证书，为nil时不请求客户端证书.
+	ClientCAs *x509.CertPool
+	// ClientAuth 客户端证书的校验策略，只在ClientCAs不为nil时生效.
+	ClientAuth tls.ClientAuthType
+	// Certificates 提供服务端证书，证书文件更新或者收到SIGHUP信号时重新加载.
+	Certificates *certreloader.Reloader
 }
 
 // CertKey 包含证书文件配置相关的选项.
@@ -44,14 +78,57 @@ type CertKey struct {
 	KeyFile string
 }
 
-// Address 连接主机ip和端口, 例如: 0.0.0.0:8443.
-func (s *SecureServingInfo) Address() string {
-	return net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort))
+// Enabled 返回是否启动https服务.
+func (s *SecureServingInfo) Enabled() bool {
+	return s != nil && (len(s.Addresses) > 0 || s.UnixSocket != "")
+}
+
+// TLSConfig 返回https服务使用的tls配置，服务端证书由Certificates提供.
+func (s *SecureServingInfo) TLSConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if s.Certificates != nil {
+		cfg.GetCertificate = s.Certificates.GetCertificate
+	}
+
+	if s.ClientCAs != nil {
+		cfg.ClientCAs = s.ClientCAs
+		cfg.ClientAuth = s.ClientAuth
+	}
+
+	return cfg
 }
 
 // InsecureServingInfo 保存http服务的配置.
 type InsecureServingInfo struct {
-	Address string
+	// Addresses 监听的tcp地址，例如: 127.0.0.1:8080、[::1]:8080.
+	Addresses []string
+	// UnixSocket 监听的unix domain socket文件.
+	UnixSocket string
+}
+
+// Enabled 返回是否启动http服务.
+func (s *InsecureServingInfo) Enabled() bool {
+	return s != nil && (len(s.Addresses) > 0 || s.UnixSocket != "")
+}
+
+// HTTPServingInfo 保存http和https服务共用的超时时间和限制，超时时间为0表示不限制.
+type HTTPServingInfo struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// MaxRequestBodyBytes 请求体的最大字节数，为0时不限制.
+	MaxRequestBodyBytes int64
+	// EnableH2C 是否在http服务上支持不使用tls的HTTP/2.
+	EnableH2C bool
+	// HTTP2MaxConcurrentStreams 每个HTTP/2连接上同时处理的最大stream数.
+	HTTP2MaxConcurrentStreams uint32
+	// HTTP2MaxReadFrameSize HTTP/2连接上允许读取的最大帧，为0时使用默认值.
+	HTTP2MaxReadFrameSize uint32
 }
 
 // JwtInfo 定义了用来创建jwt认证中间件的字段.
@@ -69,16 +146,29 @@ type JwtInfo struct {
 // NewConfig 创建默认配置.
 func NewConfig() *Config {
 	return &Config{
+		HTTPServing: &HTTPServingInfo{
+			ReadHeaderTimeout:         10 * time.Second,
+			ReadTimeout:               30 * time.Second,
+			WriteTimeout:              60 * time.Second,
+			IdleTimeout:               120 * time.Second,
+			MaxHeaderBytes:            1 << 20,
+			MaxRequestBodyBytes:       3 << 20,
+			HTTP2MaxConcurrentStreams: 250,
+		},
 		Jwt: &JwtInfo{
 			Realm:      "iam jwt",
 			Timeout:    time.Hour * 1,
 			MaxRefresh: time.Hour * 1,
 		},
-		Mode:          gin.ReleaseMode,
-		Middlewares:   []string{},
-		Healthz:       true,
-		EnableProfile: true,
-		EnableMetrics: true,
+		Mode:               gin.ReleaseMode,
+		Middlewares:        []string{},
+		Healthz:            true,
+		EnableProfile:      true,
+		EnableMetrics:      true,
+		ShutdownTimeout:    30 * time.Second,
+		HealthzDiskPath:    "/",
+		HealthzDiskMinFree: 100 << 20,
+		Dump:               middleware.DefaultDumpConfig(),
 	}
 }
 
@@ -96,13 +186,32 @@ func (c *CompletedConfig) New() (*GenericAPIServer, error) {
 	// 执行gin.New前设置服务启动模式
 	gin.SetMode(c.Mode)
 
+	// 加载服务端证书，证书无效时不启动服务
+	if c.SecureServing != nil && c.SecureServing.CertKey.CertFile != "" {
+		certificates, err := certreloader.New(c.SecureServing.CertKey.CertFile, c.SecureServing.CertKey.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls certificate: %w", err)
+		}
+
+		c.SecureServing.Certificates = certificates
+	}
+
 	s := &GenericAPIServer{
 		SecureServingInfo:   c.SecureServing,
 		InsecureServingInfo: c.InsecureServing,
+		HTTPServingInfo:     c.HTTPServing,
 		middlewares:         c.Middlewares,
 		enableMetrics:       c.EnableMetrics,
 		enableProfile:       c.EnableProfile,
+		enableTracing:       c.EnableTracing,
+		serviceName:         c.ServiceName,
 		healthz:             c.Healthz,
+		healthzDiskPath:     c.HealthzDiskPath,
+		healthzDiskMinFree:  c.HealthzDiskMinFree,
+		ShutdownTimeout:     c.ShutdownTimeout,
+		ShutdownDelay:       c.ShutdownDelay,
+		dump:                c.Dump,
+		trustedProxies:      c.TrustedProxies,
 		Engine:              gin.New(),
 	}
 
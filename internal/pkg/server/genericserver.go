@@ -2,14 +2,18 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/marmotedu/errors"
 
 	"github.com/cuizhaoyue/iams/internal/pkg/middleware"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/cuizhaoyue/iams/pkg/log"
@@ -19,8 +23,7 @@ import (
 	"github.com/cuizhaoyue/toolkit/core"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
-
-	ginprometheus "github.com/zsais/go-gin-prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // GenericAPIServer 是一个通用服务.
@@ -29,30 +32,61 @@ type GenericAPIServer struct {
 	SecureServingInfo *SecureServingInfo
 	// InsecureServingInfo 保存http服务的配置
 	InsecureServingInfo *InsecureServingInfo
+	// HTTPServingInfo 保存http和https服务共用的超时时间和限制
+	HTTPServingInfo *HTTPServingInfo
 	// 需要加载的中间件
 	middlewares []string
 	// 服务关闭超时时间
 	ShutdownTimeout time.Duration
-
-	healthz       bool
-	enableProfile bool
-	enableMetrics bool
+	// 设置为未就绪后等待多久再关闭服务
+	ShutdownDelay time.Duration
+
+	healthz bool
+	// 就绪检查中检查可用空间的路径和最少可用字节数
+	healthzDiskPath    string
+	healthzDiskMinFree uint64
+	enableProfile      bool
+	enableMetrics      bool
+	enableTracing      bool
+	serviceName        string
+	// dump中间件的配置
+	dump middleware.DumpConfig
+	// 信任的代理的地址或者网段
+	trustedProxies []string
 
 	*gin.Engine
 	insecureServer *http.Server
 	secureServer   *http.Server
+	// 停止监听证书文件
+	stopCertWatch context.CancelFunc
+	// 开始关闭服务后健康检查返回未就绪
+	shuttingDown atomic.Bool
+	// 存活检查和就绪检查
+	livezChecks  healthChecks
+	readyzChecks healthChecks
 }
 
 // 对GenericAPIServer执行初始化操作.
 func initGenericAPIServer(server *GenericAPIServer) {
-	// 执行初始化操作, 包括设置debug日志格式、安装中间件、安装通用api.
+	// 执行初始化操作, 包括设置debug日志格式、添加默认的健康检查、安装中间件、安装通用api.
 	server.Setup()
+	server.installDefaultHealthChecks()
 	server.InstallMiddlewares()
 	server.InstallAPIs()
 }
 
 // Setup 为gin.Engine做一些设置工作.
 func (s *GenericAPIServer) Setup() {
+	// gin.Context查找不到的值从请求的上下文中查找，log.L(c)可以获取到tracing中间件创建的span
+	s.ContextWithFallback = true
+
+	// gin默认信任所有代理，任何调用方都可以通过X-Forwarded-For伪造c.ClientIP()的返回值.
+	// 只信任配置的代理，配置无效时不信任任何代理
+	if err := s.SetTrustedProxies(s.trustedProxies); err != nil {
+		log.Warnf("invalid trusted proxies %v, trust no proxy: %s", s.trustedProxies, err.Error())
+		_ = s.SetTrustedProxies(nil)
+	}
+
 	// 设置debug日志输出格式.
 	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
 		log.Infof(
@@ -65,12 +99,46 @@ func (s *GenericAPIServer) Setup() {
 	}
 }
 
+// installDefaultHealthChecks 添加默认的健康检查，开始关闭服务后就绪检查失败.
+func (s *GenericAPIServer) installDefaultHealthChecks() {
+	s.AddLivezChecks(PingHealthz)
+	s.AddReadyzChecks(PingHealthz, NamedCheck("shutdown", func(*http.Request) error {
+		if s.shuttingDown.Load() {
+			return fmt.Errorf("server is shutting down")
+		}
+
+		return nil
+	}))
+
+	if s.healthzDiskPath != "" {
+		s.AddReadyzChecks(DiskSpaceCheck(s.healthzDiskPath, s.healthzDiskMinFree))
+	}
+}
+
+// AddLivezChecks 添加存活检查，存活检查失败表示服务需要重启，不应该依赖外部服务.
+func (s *GenericAPIServer) AddLivezChecks(checks ...HealthChecker) {
+	s.livezChecks.add(checks...)
+}
+
+// AddReadyzChecks 添加就绪检查，就绪检查失败表示服务暂时不能处理请求.
+func (s *GenericAPIServer) AddReadyzChecks(checks ...HealthChecker) {
+	s.readyzChecks.add(checks...)
+}
+
 // InstallMiddlewares 安装通用中间件.
 func (s *GenericAPIServer) InstallMiddlewares() {
+	if s.enableTracing {
+		s.Use(middleware.Tracing(s.serviceName))
+	}
+
 	// 安装两个必要的中间件
 	s.Use(middleware.RequestID())
 	s.Use(middleware.Context())
 
+	if s.HTTPServingInfo != nil && s.HTTPServingInfo.MaxRequestBodyBytes > 0 {
+		s.Use(middleware.MaxBodyBytes(s.HTTPServingInfo.MaxRequestBodyBytes))
+	}
+
 	// 安装自定义的中间件
 	for _, m := range s.middlewares {
 		mw, ok := middleware.Middlewares[m]
@@ -79,6 +147,11 @@ func (s *GenericAPIServer) InstallMiddlewares() {
 			continue
 		}
 
+		// dump中间件使用服务的配置
+		if m == "dump" {
+			mw = middleware.Dump(s.dump)
+		}
+
 		log.Infof("install middleware: %s", m)
 		s.Use(mw)
 	}
@@ -87,17 +160,17 @@ func (s *GenericAPIServer) InstallMiddlewares() {
 // InstallAPIs 安装通用的api.
 // 包括健康检查api、暴露Metrics的api、启用性能分析的api.
 func (s *GenericAPIServer) InstallAPIs() {
-	// install healthz handler
-	if s.healthz {
-		s.GET("/healthz", func(c *gin.Context) {
-			core.WriteResponse(c, nil, map[string]string{"status": "ok"})
-		})
+	// install metric handler, 需要在添加其他路由前安装中间件
+	if s.enableMetrics {
+		s.Use(middleware.Metrics())
+		s.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	}
 
-	// install metric handler
-	if s.enableMetrics {
-		prometheus := ginprometheus.NewPrometheus("gin")
-		prometheus.Use(s.Engine)
+	// install healthz handler, /healthz和/readyz执行相同的检查
+	if s.healthz {
+		s.GET("/healthz", healthzHandler("healthz", &s.readyzChecks))
+		installHealthChecks(s.Engine, "livez", &s.livezChecks)
+		installHealthChecks(s.Engine, "readyz", &s.readyzChecks)
 	}
 
 	// install pprof handler
@@ -112,48 +185,92 @@ func (s *GenericAPIServer) InstallAPIs() {
 
 // Run 会生成 http 服务器。仅当初始化时无法侦听端口时，它才会返回.
 func (s *GenericAPIServer) Run() error {
-	// 对于可伸缩性，请在此处使用自定义 HTTP 配置模式
-	s.insecureServer = &http.Server{
-		Addr:    s.InsecureServingInfo.Address,
-		Handler: s,
+	if !s.InsecureServingInfo.Enabled() && !s.SecureServingInfo.Enabled() {
+		return fmt.Errorf("neither insecure (HTTP) nor secure (HTTPS) serving is enabled")
 	}
-	s.secureServer = &http.Server{
-		Addr:    s.SecureServingInfo.Address(),
-		Handler: s,
+
+	// 先监听所有地址，端口被占用等错误在启动时直接返回
+	var insecureEndpoints, secureEndpoints []endpoint
+	if s.InsecureServingInfo.Enabled() {
+		insecureEndpoints = endpoints(s.InsecureServingInfo.Addresses, s.InsecureServingInfo.UnixSocket)
+	}
+
+	if s.SecureServingInfo.Enabled() {
+		secureEndpoints = endpoints(s.SecureServingInfo.Addresses, s.SecureServingInfo.UnixSocket)
+	}
+
+	insecureListeners, err := listen(insecureEndpoints)
+	if err != nil {
+		return err
+	}
+
+	secureListeners, err := listen(secureEndpoints)
+	if err != nil {
+		closeListeners(insecureListeners)
+
+		return err
 	}
 
 	var eg errgroup.Group
 
 	// 在goroutine中初始化服务，所以它不会阻塞下面的优雅关闭服务.
-	eg.Go(func() error {
-		log.Infof(
-			"Start to listening the incoming requests on http address: %s",
-			s.InsecureServingInfo.Address,
-		)
+	if len(insecureListeners) > 0 {
+		s.insecureServer = s.newHTTPServer()
 
-		if err := s.insecureServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal(err.Error())
+		// h2c在不使用tls的连接上支持HTTP/2
+		if s.HTTPServingInfo != nil && s.HTTPServingInfo.EnableH2C {
+			s.insecureServer.Handler = h2c.NewHandler(s, s.http2Server())
+		}
 
-			return err
+		for i, l := range insecureListeners {
+			ep, l := insecureEndpoints[i], l
+			eg.Go(func() error {
+				log.Infof("Start to listening the incoming requests on http address: %s", ep)
+
+				return serve(ep, func() error { return s.insecureServer.Serve(l) })
+			})
 		}
+	}
 
-		log.Infof("Server on %s stopped", s.InsecureServingInfo.Address)
+	// 证书由TLSConfig.GetCertificate提供，证书文件更新后不需要重启服务.
+	// grpc服务也使用这个证书，所以不启动https服务时也需要监听证书文件.
+	if s.SecureServingInfo != nil && s.SecureServingInfo.Certificates != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopCertWatch = cancel
+
+		certificates := s.SecureServingInfo.Certificates
+		go func() {
+			if err := certificates.Watch(ctx); err != nil {
+				log.Warnf("Watch tls certificate failed, reload with SIGHUP is unavailable: %s", err.Error())
+			}
+		}()
+	}
 
-		return nil
-	})
+	if len(secureListeners) > 0 {
+		s.secureServer = s.newHTTPServer()
+		s.secureServer.TLSConfig = s.SecureServingInfo.TLSConfig()
 
-	eg.Go(func() error {
-		cert, key := s.SecureServingInfo.CertKey.CertFile, s.SecureServingInfo.CertKey.KeyFile
-		if err := s.secureServer.ListenAndServeTLS(cert, key); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal(err.Error())
+		if err := http2.ConfigureServer(s.secureServer, s.http2Server()); err != nil {
+			closeListeners(insecureListeners)
+			closeListeners(secureListeners)
 
-			return err
+			return fmt.Errorf("configure http2: %w", err)
 		}
 
-		log.Infof("Server on %s stopped", s.SecureServingInfo.Address())
+		cert, key := s.SecureServingInfo.CertKey.CertFile, s.SecureServingInfo.CertKey.KeyFile
+		if s.SecureServingInfo.Certificates != nil {
+			cert, key = "", ""
+		}
 
-		return nil
-	})
+		for i, l := range secureListeners {
+			ep, l := secureEndpoints[i], l
+			eg.Go(func() error {
+				log.Infof("Start to listening the incoming requests on https address: %s", ep)
+
+				return serve(ep, func() error { return s.secureServer.ServeTLS(l, cert, key) })
+			})
+		}
+	}
 
 	// 执行ping服务，做健康检查，确保10s内路由可以正常工作.
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
@@ -166,38 +283,146 @@ func (s *GenericAPIServer) Run() error {
 	}
 
 	// 等待启动http/https服务的goroutine执行完成
-	if err := eg.Wait(); err != nil {
-		log.Fatal(err.Error())
+	return eg.Wait()
+}
+
+// newHTTPServer 根据HTTPServingInfo创建http.Server.
+func (s *GenericAPIServer) newHTTPServer() *http.Server {
+	server := &http.Server{
+		Handler: s,
+	}
+
+	if info := s.HTTPServingInfo; info != nil {
+		server.ReadHeaderTimeout = info.ReadHeaderTimeout
+		server.ReadTimeout = info.ReadTimeout
+		server.WriteTimeout = info.WriteTimeout
+		server.IdleTimeout = info.IdleTimeout
+		server.MaxHeaderBytes = info.MaxHeaderBytes
 	}
 
+	return server
+}
+
+// http2Server 返回HTTP/2的配置，IdleTimeout和http服务相同.
+func (s *GenericAPIServer) http2Server() *http2.Server {
+	server := &http2.Server{}
+
+	if info := s.HTTPServingInfo; info != nil {
+		server.MaxConcurrentStreams = info.HTTP2MaxConcurrentStreams
+		server.MaxReadFrameSize = info.HTTP2MaxReadFrameSize
+		server.IdleTimeout = info.IdleTimeout
+	}
+
+	return server
+}
+
+// serve 运行服务直到服务被关闭.
+func serve(ep endpoint, fn func() error) error {
+	if err := fn(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorf("Server on %s failed: %s", ep, err.Error())
+
+		return err
+	}
+
+	log.Infof("Server on %s stopped", ep)
+
 	return nil
 }
 
-// Close 优雅关闭服务
+// StopReadiness 把健康检查设置为未就绪，并等待ShutdownDelay让负载均衡摘除实例，此时服务仍然处理请求.
+func (s *GenericAPIServer) StopReadiness() {
+	s.shuttingDown.Store(true)
+
+	if s.ShutdownDelay > 0 {
+		log.Infof("Server is not ready, wait %s before draining", s.ShutdownDelay)
+		time.Sleep(s.ShutdownDelay)
+	}
+}
+
+// Close 优雅关闭服务，最多等待ShutdownTimeout让正在处理的请求完成.
 func (s *GenericAPIServer) Close() {
-	// context用来通知服务它有10s的时间完成正在处理的请求.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	s.shuttingDown.Store(true)
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if s.ShutdownTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.ShutdownTimeout)
+	}
 	defer cancel()
 
-	if err := s.secureServer.Shutdown(ctx); err != nil {
-		log.Warnf("Shutdown secure server failed: %s", err.Error())
+	if s.stopCertWatch != nil {
+		s.stopCertWatch()
 	}
-	if err := s.insecureServer.Shutdown(ctx); err != nil {
-		log.Warnf("Shutdown insecure server failed: %s", err.Error())
+
+	// http和https服务同时关闭
+	var wg sync.WaitGroup
+	shutdown := func(name string, server *http.Server) {
+		defer wg.Done()
+
+		if err := server.Shutdown(ctx); err != nil {
+			log.Warnf("Shutdown %s server failed: %s", name, err.Error())
+		}
+	}
+
+	if s.secureServer != nil {
+		wg.Add(1)
+		go shutdown("secure", s.secureServer)
+	}
+
+	if s.insecureServer != nil {
+		wg.Add(1)
+		go shutdown("insecure", s.insecureServer)
 	}
+
+	wg.Wait()
+}
+
+// pingTarget 返回自检使用的地址和客户端，优先使用http服务.
+func (s *GenericAPIServer) pingTarget() (string, *http.Client, bool) {
+	if s.InsecureServingInfo.Enabled() {
+		ep := endpoints(s.InsecureServingInfo.Addresses, s.InsecureServingInfo.UnixSocket)[0]
+
+		return "http://" + pingHost(ep) + "/livez", &http.Client{
+			Transport: &http.Transport{DialContext: ep.dialContext()},
+		}, true
+	}
+
+	// 要求客户端证书时无法通过https自检
+	if s.SecureServingInfo.ClientCAs != nil && s.SecureServingInfo.ClientAuth == tls.RequireAndVerifyClientCert {
+		return "", nil, false
+	}
+
+	ep := endpoints(s.SecureServingInfo.Addresses, s.SecureServingInfo.UnixSocket)[0]
+
+	// 只检查路由是否正常工作，不校验服务端证书
+	return "https://" + pingHost(ep) + "/livez", &http.Client{
+		Transport: &http.Transport{
+			DialContext: ep.dialContext(),
+			// nolint: gosec
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}, true
+}
+
+// pingHost 返回请求中使用的host，unix domain socket没有host，使用localhost.
+func pingHost(ep endpoint) string {
+	if ep.network == "unix" {
+		return "localhost"
+	}
+
+	return ep.loopback()
 }
 
 // 通过ping http服务来确认路由正在工作.
 func (s *GenericAPIServer) ping(ctx context.Context) error {
-	// 当服务监听在所有网卡时，设置请求ip为127.0.0.1
-	// 当HTTP服务监听在指定网卡时，请求指定的网卡ip.
-	url := fmt.Sprintf("http://%s/healthz", s.InsecureServingInfo.Address)
-	if strings.Contains(s.InsecureServingInfo.Address, "0.0.0.0") {
-		url = fmt.Sprintf(
-			"http://127.0.0.1:%s/healthz",
-			strings.Split(s.InsecureServingInfo.Address, ":")[1],
-		)
+	// 当服务监听在所有网卡时，请求回环地址.
+	// 当服务监听在指定网卡时，请求指定的网卡ip.
+	url, client, ok := s.pingTarget()
+	if !ok {
+		log.Info("Secure server requires client certificates, skip the router self-check.")
+
+		return nil
 	}
+	defer client.CloseIdleConnections()
 
 	for {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -205,15 +430,17 @@ func (s *GenericAPIServer) ping(ctx context.Context) error {
 			return err
 		}
 
-		// Ping the server by sending a GET request to `/healthz`
-
-		resp, err := http.DefaultClient.Do(req)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			log.Infof("The router has been deployed successfully.")
+		// Ping the server by sending a GET request to `/livez`
 
+		resp, err := client.Do(req)
+		if err == nil {
 			resp.Body.Close()
 
-			return nil
+			if resp.StatusCode == http.StatusOK {
+				log.Infof("The router has been deployed successfully.")
+
+				return nil
+			}
 		}
 
 		// 检查失败后暂停1s再次检查
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, trustedProxies []string) *GenericAPIServer {
+	cfg := NewConfig()
+	cfg.Healthz = false
+	cfg.EnableMetrics = false
+	cfg.EnableProfile = false
+	cfg.TrustedProxies = trustedProxies
+
+	s, err := cfg.Complete().New()
+	assert.Nil(t, err)
+
+	s.GET("/ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	return s
+}
+
+func clientIP(s *GenericAPIServer, remoteAddr, forwardedFor string) string {
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	req.Header.Set("X-Real-IP", forwardedFor)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	return w.Body.String()
+}
+
+func TestClientIP_SpoofedForwardedFor(t *testing.T) {
+	// 没有配置信任的代理时忽略X-Forwarded-For
+	s := newTestServer(t, nil)
+	assert.Equal(t, "203.0.113.7", clientIP(s, "203.0.113.7:51234", "10.0.0.1"))
+
+	// 只有来自信任的代理的请求才使用X-Forwarded-For
+	s = newTestServer(t, []string{"192.0.2.0/24"})
+	assert.Equal(t, "10.0.0.1", clientIP(s, "192.0.2.10:51234", "10.0.0.1"))
+	assert.Equal(t, "203.0.113.7", clientIP(s, "203.0.113.7:51234", "10.0.0.1"))
+}
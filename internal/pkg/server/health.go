@@ -2,19 +2,38 @@ package server
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/cuizhaoyue/iams/pkg/log"
 )
 
-// ServeHealthCheck 运行一个http服务用来提供检查pump服务健康状态的api.
-func ServeHealthCheck(healthPath string, healthAddress string) {
-	http.HandleFunc("/"+healthPath, func(w http.ResponseWriter, r *http.Request) {
+// ServeHealthCheck 运行一个http服务用来提供检查pump服务健康状态的api，checks都通过时才返回成功.
+func ServeHealthCheck(healthPath string, healthAddress string, checks ...HealthChecker) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+healthPath, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-type", "application/json")
+
+		for _, check := range checks {
+			if err := check.Check(r); err != nil {
+				log.Warnf("Health check %s failed: %s", check.Name(), err.Error())
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"status": "failed"}`))
+
+				return
+			}
+		}
+
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"status": "ok"}`))
 	})
 
-	if err := http.ListenAndServe(healthAddress, nil); err != nil {
+	server := &http.Server{
+		Addr:              healthAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if err := server.ListenAndServe(); err != nil {
 		log.Fatalf("Error serving health check endpoint: %s", err.Error())
 	}
 }
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// HealthChecker 是一个命名的健康检查.
+type HealthChecker interface {
+	Name() string
+	Check(r *http.Request) error
+}
+
+type healthCheck struct {
+	name  string
+	check func(r *http.Request) error
+}
+
+func (c *healthCheck) Name() string {
+	return c.name
+}
+
+func (c *healthCheck) Check(r *http.Request) error {
+	return c.check(r)
+}
+
+// NamedCheck 使用函数创建一个健康检查，函数返回错误时检查失败.
+func NamedCheck(name string, check func(r *http.Request) error) HealthChecker {
+	return &healthCheck{name: name, check: check}
+}
+
+// PingHealthz 总是成功，服务可以处理请求时就是健康的.
+var PingHealthz = NamedCheck("ping", func(*http.Request) error {
+	return nil
+})
+
+// DiskSpaceCheck 检查path所在的文件系统可用空间是否不少于minFree字节.
+func DiskSpaceCheck(path string, minFree uint64) HealthChecker {
+	return NamedCheck("disk", func(*http.Request) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+
+		// nolint: unconvert
+		free := uint64(stat.Bavail) * uint64(stat.Bsize)
+		if free < minFree {
+			return fmt.Errorf("only %d bytes available on %s, need at least %d", free, path, minFree)
+		}
+
+		return nil
+	})
+}
+
+// healthChecks 是一组健康检查，服务运行时也可以添加.
+type healthChecks struct {
+	mu     sync.RWMutex
+	checks []HealthChecker
+}
+
+func (h *healthChecks) add(checks ...HealthChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, checks...)
+}
+
+func (h *healthChecks) list() []HealthChecker {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return append([]HealthChecker(nil), h.checks...)
+}
+
+// installHealthChecks 安装/<name>和/<name>/<check>路由.
+// /<name>执行所有检查，verbose参数输出每个检查的结果，exclude参数跳过指定的检查，可以指定多次.
+func installHealthChecks(r gin.IRoutes, name string, checks *healthChecks) {
+	r.GET("/"+name, healthzHandler(name, checks))
+	r.GET("/"+name+"/:check", healthzCheckHandler(checks))
+}
+
+func healthzHandler(name string, checks *healthChecks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		excluded := make(map[string]bool)
+		for _, check := range c.QueryArray("exclude") {
+			excluded[check] = true
+		}
+
+		var out bytes.Buffer
+		failed := false
+
+		for _, check := range checks.list() {
+			if excluded[check.Name()] {
+				delete(excluded, check.Name())
+				fmt.Fprintf(&out, "[+]%s excluded: ok\n", check.Name())
+
+				continue
+			}
+
+			// 失败原因可能包含内部信息，只记录在日志中
+			if err := check.Check(c.Request); err != nil {
+				log.L(c).Warnf("%s check %s failed: %s", name, check.Name(), err.Error())
+				fmt.Fprintf(&out, "[-]%s failed: reason withheld\n", check.Name())
+
+				failed = true
+
+				continue
+			}
+
+			fmt.Fprintf(&out, "[+]%s ok\n", check.Name())
+		}
+
+		unknown := make([]string, 0, len(excluded))
+		for check := range excluded {
+			unknown = append(unknown, check)
+		}
+		sort.Strings(unknown)
+
+		for _, check := range unknown {
+			fmt.Fprintf(&out, "warn: some health checks cannot be excluded: no matches for %q\n", check)
+		}
+
+		if failed {
+			c.String(http.StatusInternalServerError, "%s%s check failed\n", out.String(), name)
+
+			return
+		}
+
+		if _, verbose := c.GetQuery("verbose"); verbose {
+			c.String(http.StatusOK, "%s%s check passed\n", out.String(), name)
+
+			return
+		}
+
+		c.String(http.StatusOK, "ok")
+	}
+}
+
+func healthzCheckHandler(checks *healthChecks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("check")
+		for _, check := range checks.list() {
+			if check.Name() != name {
+				continue
+			}
+
+			if err := check.Check(c.Request); err != nil {
+				log.L(c).Warnf("health check %s failed: %s", name, err.Error())
+				c.String(http.StatusInternalServerError, "internal server error: %s check failed", name)
+
+				return
+			}
+
+			c.String(http.StatusOK, "ok")
+
+			return
+		}
+
+		c.String(http.StatusNotFound, "health check %q not found", name)
+	}
+}
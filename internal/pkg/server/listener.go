@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+)
+
+// endpoint 是服务的一个监听地址.
+type endpoint struct {
+	network string
+	address string
+}
+
+func (e endpoint) String() string {
+	if e.network == "unix" {
+		return "unix://" + e.address
+	}
+
+	return e.address
+}
+
+// endpoints 返回tcp地址和unix domain socket对应的监听地址.
+func endpoints(addresses []string, unixSocket string) []endpoint {
+	eps := make([]endpoint, 0, len(addresses)+1)
+	for _, address := range addresses {
+		eps = append(eps, endpoint{network: "tcp", address: address})
+	}
+
+	if unixSocket != "" {
+		eps = append(eps, endpoint{network: "unix", address: unixSocket})
+	}
+
+	return eps
+}
+
+// listen 监听所有地址，任意一个地址监听失败时关闭已经打开的监听并返回错误.
+func listen(eps []endpoint) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(eps))
+	for _, ep := range eps {
+		if ep.network == "unix" {
+			if err := removeStaleSocket(ep.address); err != nil {
+				closeListeners(listeners)
+
+				return nil, err
+			}
+		}
+
+		l, err := net.Listen(ep.network, ep.address)
+		if err != nil {
+			closeListeners(listeners)
+
+			return nil, fmt.Errorf("listen on %s: %w", ep, err)
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, l := range listeners {
+		_ = l.Close()
+	}
+}
+
+// removeStaleSocket 删除上次运行遗留的socket文件，已经有服务在监听或者不是socket文件时返回错误.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a unix domain socket", path)
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+
+	return os.Remove(path)
+}
+
+// loopback 返回用于访问endpoint的地址，监听在所有网卡时使用回环地址.
+func (e endpoint) loopback() string {
+	if e.network == "unix" {
+		return e.address
+	}
+
+	host, port, err := net.SplitHostPort(e.address)
+	if err != nil {
+		return e.address
+	}
+
+	switch ip := net.ParseIP(host); {
+	case host == "", ip != nil && ip.Equal(net.IPv4zero):
+		host = "127.0.0.1"
+	case ip != nil && ip.Equal(net.IPv6unspecified):
+		host = "::1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// dialContext 返回连接到endpoint的拨号函数，忽略请求中的地址.
+func (e endpoint) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	address := e.loopback()
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+
+		return d.DialContext(ctx, e.network, address)
+	}
+}
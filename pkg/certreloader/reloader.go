@@ -0,0 +1,197 @@
+// Package certreloader 提供可以热更新的tls证书，证书文件变化或者收到SIGHUP信号时重新加载，不需要重启服务.
+package certreloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cuizhaoyue/iams/pkg/log"
+)
+
+// 证书和私钥通常先后写入，文件在这段时间内没有新的变化后才重新加载.
+const reloadDelay = time.Second
+
+// 证书在这段时间内过期时加载后输出告警日志.
+const expiryWarning = 30 * 24 * time.Hour
+
+var certExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "iam_tls_certificate_expiry_timestamp_seconds",
+	Help: "Expiry time of the TLS certificate currently served, in unix seconds.",
+}, []string{"cert_file"})
+
+func init() {
+	prometheus.MustRegister(certExpiry)
+}
+
+// Reloader 从文件加载tls证书并在证书更新时替换. 新证书校验失败时继续使用原来的证书.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	now      func() time.Time
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// New 创建Reloader并加载证书，证书无效时返回错误.
+func New(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, now: time.Now}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate 返回当前的证书，用于tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// NotAfter 返回当前证书的过期时间.
+func (r *Reloader) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert.Leaf.NotAfter
+}
+
+// Reload 重新加载证书，证书和私钥不匹配、证书未生效或者已经过期时保留原来的证书并返回错误.
+func (r *Reloader) Reload() error {
+	cert, err := load(r.certFile, r.keyFile, r.now())
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	unchanged := r.cert != nil && bytes.Equal(r.cert.Certificate[0], cert.Certificate[0])
+	r.cert = cert
+	r.mu.Unlock()
+
+	if unchanged {
+		return nil
+	}
+
+	leaf := cert.Leaf
+	certExpiry.WithLabelValues(r.certFile).Set(float64(leaf.NotAfter.Unix()))
+	log.Infof("Loaded tls certificate %s, subject: %s, expires at %s",
+		r.certFile, leaf.Subject.String(), leaf.NotAfter.Format(time.RFC3339))
+
+	if leaf.NotAfter.Sub(r.now()) < expiryWarning {
+		log.Warnf("Tls certificate %s will expire at %s", r.certFile, leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Watch 监听证书文件和SIGHUP信号并重新加载证书，直到ctx结束. 监听的是证书所在的目录，
+// 通过重命名或者替换符号链接(例如kubernetes挂载的secret)更新证书时也能收到通知.
+func (r *Reloader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range uniqueDirs(r.certFile, r.keyFile) {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watch certificate directory %s: %w", dir, err)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil
+		case <-hup:
+			log.Info("Received SIGHUP, reload tls certificate")
+			r.reload()
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op != fsnotify.Chmod {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			log.Warnf("Watch tls certificate failed: %s", err.Error())
+		case <-timer.C:
+			r.reload()
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	if err := r.Reload(); err != nil {
+		log.Errorf("Reload tls certificate %s failed, keep serving the current one: %s", r.certFile, err.Error())
+	}
+}
+
+// load 加载并校验证书和私钥.
+func load(certFile, keyFile string, now time.Time) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %w", err)
+	}
+
+	if now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("certificate is not valid before %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+
+	if now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate has expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	cert.Leaf = leaf
+
+	return &cert, nil
+}
+
+func uniqueDirs(files ...string) []string {
+	var dirs []string
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
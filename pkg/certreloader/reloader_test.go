@@ -0,0 +1,132 @@
+package certreloader
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writePair 生成自签名证书并写入dir中的tls.crt和tls.key.
+func writePair(t *testing.T, dir, cn string, notBefore, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	cert, err := r.GetCertificate(nil)
+	assert.Nil(t, err)
+
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := writePair(t, dir, "first", now.Add(-time.Hour), now.Add(time.Hour))
+
+	r, err := New(certFile, keyFile)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", commonName(t, r))
+	assert.Equal(t, now.Add(time.Hour).Unix(), r.NotAfter().Unix())
+
+	writePair(t, dir, "second", now.Add(-time.Hour), now.Add(2*time.Hour))
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, "second", commonName(t, r))
+}
+
+func TestReloadKeepsCurrentOnInvalid(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := writePair(t, dir, "valid", now.Add(-time.Hour), now.Add(time.Hour))
+
+	r, err := New(certFile, keyFile)
+	assert.Nil(t, err)
+
+	// 过期的证书
+	writePair(t, dir, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	assert.NotNil(t, r.Reload())
+	assert.Equal(t, "valid", commonName(t, r))
+
+	// 还未生效的证书
+	writePair(t, dir, "future", now.Add(time.Hour), now.Add(2*time.Hour))
+	assert.NotNil(t, r.Reload())
+	assert.Equal(t, "valid", commonName(t, r))
+
+	// 证书和私钥不匹配
+	other := t.TempDir()
+	_, otherKey := writePair(t, other, "other", now.Add(-time.Hour), now.Add(time.Hour))
+	writePair(t, dir, "mismatch", now.Add(-time.Hour), now.Add(time.Hour))
+	data, err := os.ReadFile(otherKey)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(keyFile, data, 0o600))
+	assert.NotNil(t, r.Reload())
+	assert.Equal(t, "valid", commonName(t, r))
+}
+
+func TestNewInvalid(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := writePair(t, dir, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+
+	_, err := New(certFile, keyFile)
+	assert.NotNil(t, err)
+
+	_, err = New(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.NotNil(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := writePair(t, dir, "first", now.Add(-time.Hour), now.Add(time.Hour))
+
+	r, err := New(certFile, keyFile)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Watch(ctx)
+	}()
+
+	// 等待开始监听
+	time.Sleep(100 * time.Millisecond)
+	writePair(t, dir, "second", now.Add(-time.Hour), now.Add(time.Hour))
+
+	assert.Eventually(t, func() bool {
+		return commonName(t, r) == "second"
+	}, 5*time.Second, 100*time.Millisecond)
+
+	cancel()
+	assert.Nil(t, <-done)
+}
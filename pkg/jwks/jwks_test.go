@@ -0,0 +1,131 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateKeys(t *testing.T) map[string]crypto.Signer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	return map[string]crypto.Signer{
+		AlgorithmRS256: rsaKey,
+		AlgorithmES256: ecKey,
+		AlgorithmEdDSA: edKey,
+	}
+}
+
+func TestParsePrivateKeyPEM(t *testing.T) {
+	for alg, signer := range generateKeys(t) {
+		der, err := x509.MarshalPKCS8PrivateKey(signer)
+		assert.Nil(t, err)
+
+		parsed, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		assert.Nil(t, err, alg)
+
+		key, err := NewKey("k1", parsed, time.Time{}, time.Time{})
+		assert.Nil(t, err)
+		assert.Equal(t, alg, key.Algorithm)
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	for alg, signer := range generateKeys(t) {
+		jwk, err := NewJWK("k1", alg, signer.Public())
+		assert.Nil(t, err)
+
+		data, _ := json.Marshal(jwk)
+
+		var decoded JWK
+		assert.Nil(t, json.Unmarshal(data, &decoded))
+
+		pub, err := decoded.PublicKey()
+		assert.Nil(t, err, alg)
+		assert.True(t, pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(signer.Public()), alg)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	signer := generateKeys(t)[AlgorithmEdDSA]
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	k1, _ := NewKey("k1", signer, base, time.Time{})
+	k2, _ := NewKey("k2", signer, base.Add(24*time.Hour), time.Time{})
+
+	set, err := NewKeySet([]*Key{k2, k1}, time.Hour)
+	assert.Nil(t, err)
+
+	// 轮换前使用k1签名，k2提前发布
+	now := base.Add(time.Hour)
+	key, _ := set.SigningKey(now)
+	assert.Equal(t, "k1", key.ID)
+	assert.Len(t, set.JWKS(now).Keys, 2)
+
+	_, ok := set.Lookup("k2", now)
+	assert.False(t, ok)
+
+	// 轮换后的宽限期内k1仍然可以校验签名
+	now = base.Add(24*time.Hour + 30*time.Minute)
+	key, _ = set.SigningKey(now)
+	assert.Equal(t, "k2", key.ID)
+
+	_, ok = set.Lookup("k1", now)
+	assert.True(t, ok)
+
+	// 宽限期结束后k1不再可用
+	now = base.Add(26 * time.Hour)
+	_, ok = set.Lookup("k1", now)
+	assert.False(t, ok)
+	assert.Len(t, set.JWKS(now).Keys, 1)
+
+	_, err = NewKeySet([]*Key{k1, k1}, time.Hour)
+	assert.NotNil(t, err)
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	signer := generateKeys(t)[AlgorithmES256]
+	key, _ := NewKey("k1", signer, time.Now().Add(-time.Minute), time.Time{})
+	set, _ := NewKeySet([]*Key{key}, time.Hour)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(set.JWKS(time.Now()))
+	}))
+	defer srv.Close()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "admin"})
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(signer)
+	assert.Nil(t, err)
+
+	remote := NewRemoteKeySet(srv.URL, nil, time.Hour)
+
+	parsed, err := jwt.Parse(raw, remote.Keyfunc, jwt.WithValidMethods([]string{AlgorithmES256}))
+	assert.Nil(t, err)
+	assert.True(t, parsed.Valid)
+
+	token.Header["kid"] = "unknown"
+	raw, _ = token.SignedString(signer)
+	_, err = jwt.Parse(raw, remote.Keyfunc)
+	assert.NotNil(t, err)
+}
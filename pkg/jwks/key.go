@@ -0,0 +1,129 @@
+// Package jwks 实现了jwt非对称签名密钥的加载、轮换以及JSON Web Key Set(RFC 7517)的发布和获取.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 支持的签名算法.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// ErrUnsupportedKey 表示密钥类型不受支持.
+var ErrUnsupportedKey = errors.New("jwks: unsupported key type")
+
+// Key 是一个带有有效期的签名密钥.
+type Key struct {
+	// ID 写入jwt header中的kid.
+	ID string
+	// Algorithm 根据密钥类型推导出的签名算法.
+	Algorithm string
+	// Signer 私钥.
+	Signer crypto.Signer
+	// NotBefore 开始使用该密钥签名的时间.
+	NotBefore time.Time
+	// NotAfter 密钥的最终过期时间，为零值时不过期.
+	NotAfter time.Time
+}
+
+// NewKey 使用私钥创建签名密钥，签名算法根据密钥类型推导.
+func NewKey(id string, signer crypto.Signer, notBefore, notAfter time.Time) (*Key, error) {
+	alg, err := AlgorithmForKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:        id,
+		Algorithm: alg,
+		Signer:    signer,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+	}, nil
+}
+
+// LoadKey 从PEM文件中加载签名密钥.
+func LoadKey(id, file string, notBefore, notAfter time.Time) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	return NewKey(id, signer, notBefore, notAfter)
+}
+
+// Public 返回密钥对应的公钥.
+func (k *Key) Public() crypto.PublicKey {
+	return k.Signer.Public()
+}
+
+// ParsePrivateKeyPEM 解析PEM格式的私钥，支持PKCS#8、PKCS#1和SEC 1格式.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("jwks: no private key found in PEM data")
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, ErrUnsupportedKey
+			}
+
+			return signer, nil
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		}
+	}
+}
+
+// AlgorithmForKey 根据公钥类型返回签名算法: RSA使用RS256，P-256使用ES256，Ed25519使用EdDSA.
+func AlgorithmForKey(pub crypto.PublicKey) (string, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return "", fmt.Errorf("jwks: rsa key must be at least 2048 bits")
+		}
+
+		return AlgorithmRS256, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", fmt.Errorf("jwks: only P-256 curve is supported for ecdsa keys")
+		}
+
+		return AlgorithmES256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", ErrUnsupportedKey
+	}
+}
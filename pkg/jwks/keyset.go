@@ -0,0 +1,133 @@
+package jwks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrNoSigningKey 表示当前没有可以用于签名的密钥.
+var ErrNoSigningKey = errors.New("jwks: no active signing key")
+
+// KeySet 管理按时间轮换的签名密钥.
+//
+// 在任意时刻，NotBefore不晚于当前时间且最新的密钥用于签名；被新密钥取代的旧密钥在grace时间内仍然可以
+// 校验签名，保证轮换前签发的token在过期前可用；NotBefore晚于当前时间的密钥提前发布到JWKS中，
+// 让下游服务在轮换前就能缓存新的公钥.
+type KeySet struct {
+	keys  []*Key
+	grace time.Duration
+}
+
+// NewKeySet 创建密钥集合，grace一般不小于token的最长有效期.
+func NewKeySet(keys []*Key, grace time.Duration) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: at least one key is required")
+	}
+
+	ids := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("jwks: key id can not be empty")
+		}
+
+		if _, ok := ids[k.ID]; ok {
+			return nil, fmt.Errorf("jwks: duplicate key id %s", k.ID)
+		}
+
+		ids[k.ID] = struct{}{}
+	}
+
+	sorted := make([]*Key, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].NotBefore.Before(sorted[j].NotBefore)
+	})
+
+	return &KeySet{keys: sorted, grace: grace}, nil
+}
+
+// SigningKey 返回当前用于签名的密钥.
+func (s *KeySet) SigningKey(now time.Time) (*Key, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		k := s.keys[i]
+		if k.NotBefore.After(now) {
+			continue
+		}
+
+		if !k.NotAfter.IsZero() && !now.Before(k.NotAfter) {
+			continue
+		}
+
+		return k, nil
+	}
+
+	return nil, ErrNoSigningKey
+}
+
+// Lookup 根据kid返回当前可以用于校验签名的密钥.
+func (s *KeySet) Lookup(kid string, now time.Time) (*Key, bool) {
+	for i, k := range s.keys {
+		if k.ID == kid {
+			return k, s.verifiable(i, now)
+		}
+	}
+
+	return nil, false
+}
+
+// Algorithms 返回集合中所有密钥使用的签名算法.
+func (s *KeySet) Algorithms() []string {
+	seen := map[string]struct{}{}
+	algs := make([]string, 0, len(s.keys))
+
+	for _, k := range s.keys {
+		if _, ok := seen[k.Algorithm]; !ok {
+			seen[k.Algorithm] = struct{}{}
+			algs = append(algs, k.Algorithm)
+		}
+	}
+
+	return algs
+}
+
+// JWKS 返回需要对外发布的公钥: 可以校验签名的密钥以及即将启用的密钥.
+func (s *KeySet) JWKS(now time.Time) Set {
+	set := Set{Keys: []JWK{}}
+
+	for i, k := range s.keys {
+		if !k.NotBefore.After(now) && !s.verifiable(i, now) {
+			continue
+		}
+
+		jwk, err := NewJWK(k.ID, k.Algorithm, k.Public())
+		if err != nil {
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func (s *KeySet) verifiable(i int, now time.Time) bool {
+	k := s.keys[i]
+	if k.NotBefore.After(now) {
+		return false
+	}
+
+	if !k.NotAfter.IsZero() && !now.Before(k.NotAfter.Add(s.grace)) {
+		return false
+	}
+
+	// 被后续密钥取代的时间
+	for _, next := range s.keys[i+1:] {
+		if next.NotBefore.After(k.NotBefore) && !next.NotBefore.After(now) {
+			return now.Before(next.NotBefore.Add(s.grace))
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,230 @@
+// Package ldap 使用LDAP目录服务校验用户名和密码，并读取用户的属性.
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// ErrInvalidCredentials 表示用户名或者密码错误.
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+	// ErrUserNotFound 表示目录中没有找到用户，或者找到了多个用户.
+	ErrUserNotFound = errors.New("ldap: user not found")
+)
+
+// 分页查询时每页的条目数.
+const pageSize = 500
+
+// Attributes 定义了用户字段和LDAP属性的映射关系.
+type Attributes struct {
+	Username string
+	Nickname string
+	Email    string
+	Phone    string
+}
+
+// Config 定义了连接LDAP服务器和查找用户的配置.
+type Config struct {
+	// URL LDAP服务器地址，例如ldap://ldap.example.com:389或者ldaps://ldap.example.com:636.
+	URL string
+	// BindDN和BindPassword是用于查找用户的服务账号，为空时匿名查找.
+	BindDN       string
+	BindPassword string
+	// BaseDN 查找用户的起始节点.
+	BaseDN string
+	// UserFilter 查找用户的过滤器，%s会被替换为转义后的用户名，例如(&(objectClass=person)(uid=%s)).
+	UserFilter string
+	// StartTLS 使用ldap://连接后是否升级为TLS连接.
+	StartTLS bool
+	// TLSConfig 用于ldaps://和StartTLS.
+	TLSConfig *tls.Config
+	// Timeout 建立连接和每次请求的超时时间.
+	Timeout time.Duration
+
+	Attributes Attributes
+}
+
+// Entry 表示目录中的一个用户.
+type Entry struct {
+	DN       string
+	Username string
+	Nickname string
+	Email    string
+	Phone    string
+}
+
+// Client 是LDAP认证客户端，每次请求使用单独的连接，可以并发使用.
+type Client struct {
+	cfg Config
+}
+
+// New 创建LDAP认证客户端.
+func New(cfg Config) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("ldap url is required")
+	}
+
+	if strings.Count(cfg.UserFilter, "%s") != 1 {
+		return nil, fmt.Errorf("ldap user filter must contain exactly one %%s")
+	}
+
+	if _, err := goldap.CompileFilter(fmt.Sprintf(cfg.UserFilter, "user")); err != nil {
+		return nil, fmt.Errorf("invalid ldap user filter: %w", err)
+	}
+
+	if cfg.Attributes.Username == "" {
+		return nil, fmt.Errorf("ldap username attribute is required")
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &Client{cfg: cfg}, nil
+}
+
+// Authenticate 使用服务账号查找用户，然后以用户的DN和密码绑定校验密码.
+func (c *Client) Authenticate(username, password string) (*Entry, error) {
+	// 空密码会被服务器当作匿名绑定并返回成功，必须拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result, err := conn.Search(c.searchRequest(fmt.Sprintf(c.cfg.UserFilter, goldap.EscapeFilter(username)), 2))
+	if err != nil && !goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("search ldap user: %w", err)
+	}
+
+	if result == nil || len(result.Entries) != 1 {
+		return nil, ErrUserNotFound
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+
+		return nil, fmt.Errorf("bind ldap user: %w", err)
+	}
+
+	return c.toEntry(entry), nil
+}
+
+// Users 返回目录中所有匹配过滤器的用户.
+func (c *Client) Users() ([]*Entry, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result, err := conn.SearchWithPaging(c.searchRequest(fmt.Sprintf(c.cfg.UserFilter, "*"), 0), pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("search ldap users: %w", err)
+	}
+
+	entries := make([]*Entry, 0, len(result.Entries))
+	for _, e := range result.Entries {
+		if entry := c.toEntry(e); entry.Username != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// connect 建立连接，按需升级为TLS，并使用服务账号绑定.
+func (c *Client) connect() (*goldap.Conn, error) {
+	conn, err := goldap.DialURL(c.cfg.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: c.cfg.Timeout}),
+		goldap.DialWithTLSConfig(c.cfg.TLSConfig))
+	if err != nil {
+		return nil, fmt.Errorf("connect to ldap server: %w", err)
+	}
+
+	conn.SetTimeout(c.cfg.Timeout)
+
+	if c.cfg.StartTLS {
+		if err := conn.StartTLS(c.tlsConfig()); err != nil {
+			conn.Close()
+
+			return nil, fmt.Errorf("ldap start tls: %w", err)
+		}
+	}
+
+	if c.cfg.BindDN != "" {
+		err = conn.Bind(c.cfg.BindDN, c.cfg.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("bind ldap service account: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (c *Client) tlsConfig() *tls.Config {
+	if c.cfg.TLSConfig != nil {
+		return c.cfg.TLSConfig
+	}
+
+	host := strings.TrimPrefix(strings.TrimPrefix(c.cfg.URL, "ldap://"), "ldaps://")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+}
+
+func (c *Client) searchRequest(filter string, sizeLimit int) *goldap.SearchRequest {
+	attrs := c.cfg.Attributes
+	attributes := []string{attrs.Username}
+
+	for _, a := range []string{attrs.Nickname, attrs.Email, attrs.Phone} {
+		if a != "" {
+			attributes = append(attributes, a)
+		}
+	}
+
+	return goldap.NewSearchRequest(c.cfg.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		sizeLimit, int(c.cfg.Timeout.Seconds()), false, filter, attributes, nil)
+}
+
+func (c *Client) toEntry(e *goldap.Entry) *Entry {
+	attrs := c.cfg.Attributes
+
+	value := func(name string) string {
+		if name == "" {
+			return ""
+		}
+
+		return e.GetAttributeValue(name)
+	}
+
+	return &Entry{
+		DN:       e.DN,
+		Username: value(attrs.Username),
+		Nickname: value(attrs.Nickname),
+		Email:    value(attrs.Email),
+		Phone:    value(attrs.Phone),
+	}
+}
@@ -0,0 +1,163 @@
+package ldap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cuizhaoyue/iams/pkg/ldap/ldaptest"
+)
+
+var testEntries = []ldaptest.Entry{
+	{
+		DN:       "uid=alice,ou=people,dc=example,dc=com",
+		Password: "alice-secret",
+		Attrs:    map[string]string{"uid": "alice", "cn": "Alice", "mail": "alice@example.com", "mobile": "1001"},
+	},
+	{
+		DN:       "uid=bob,ou=people,dc=example,dc=com",
+		Password: "bob-secret",
+		Attrs:    map[string]string{"uid": "bob", "cn": "Bob", "mail": "bob@example.com"},
+	},
+}
+
+func testConfig(url string) Config {
+	return Config{
+		URL:          url,
+		BindDN:       ldaptest.ServiceDN,
+		BindPassword: ldaptest.ServicePassword,
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(uid=%s))",
+		Timeout:      5 * time.Second,
+		Attributes:   Attributes{Username: "uid", Nickname: "cn", Email: "mail", Phone: "mobile"},
+	}
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	s := ldaptest.NewServer(t, testEntries)
+
+	c, err := New(testConfig(s.URL()))
+	assert.Nil(t, err)
+
+	entry, err := c.Authenticate("alice", "alice-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, &Entry{
+		DN:       "uid=alice,ou=people,dc=example,dc=com",
+		Username: "alice",
+		Nickname: "Alice",
+		Email:    "alice@example.com",
+		Phone:    "1001",
+	}, entry)
+
+	_, err = c.Authenticate("alice", "wrong")
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	_, err = c.Authenticate("carol", "carol-secret")
+	assert.Equal(t, ErrUserNotFound, err)
+
+	// 空密码会被当作匿名绑定，必须拒绝
+	_, err = c.Authenticate("alice", "")
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	// 用户名中的过滤器特殊字符会被转义
+	_, err = c.Authenticate("*", "alice-secret")
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestClient_ServiceAccount(t *testing.T) {
+	s := ldaptest.NewServer(t, testEntries)
+
+	cfg := testConfig(s.URL())
+	cfg.BindPassword = "wrong"
+	c, _ := New(cfg)
+
+	_, err := c.Authenticate("alice", "alice-secret")
+	assert.NotNil(t, err)
+	assert.NotEqual(t, ErrInvalidCredentials, err)
+}
+
+func TestClient_Users(t *testing.T) {
+	s := ldaptest.NewServer(t, testEntries)
+	c, _ := New(testConfig(s.URL()))
+
+	users, err := c.Users()
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Username)
+	assert.Equal(t, "bob", users[1].Username)
+	assert.Equal(t, "", users[1].Phone)
+}
+
+func TestClient_StartTLS(t *testing.T) {
+	s := ldaptest.NewServer(t, testEntries)
+	s.TLSConfig, s.RequireTLS = testTLSConfig(t), true
+
+	cfg := testConfig(s.URL())
+	c, _ := New(cfg)
+
+	_, err := c.Authenticate("bob", "bob-secret")
+	assert.NotNil(t, err)
+
+	cfg.StartTLS = true
+	cfg.TLSConfig = &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "127.0.0.1", MinVersion: tls.VersionTLS12}
+	cfg.TLSConfig.RootCAs.AddCert(s.TLSConfig.Certificates[0].Leaf)
+	c, _ = New(cfg)
+
+	entry, err := c.Authenticate("bob", "bob-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "bob@example.com", entry.Email)
+}
+
+func TestNew(t *testing.T) {
+	cfg := testConfig("ldap://127.0.0.1")
+	cfg.UserFilter = "(uid=alice)"
+	_, err := New(cfg)
+	assert.NotNil(t, err)
+
+	cfg.UserFilter = "(uid=%s"
+	_, err = New(cfg)
+	assert.NotNil(t, err)
+
+	cfg = testConfig("")
+	_, err = New(cfg)
+	assert.NotNil(t, err)
+}
+
+func testTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
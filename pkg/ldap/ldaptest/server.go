@@ -0,0 +1,232 @@
+// Package ldaptest 提供一个进程内的LDAP服务器，用于测试LDAP认证和同步.
+package ldaptest
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+const (
+	// ServiceDN 是服务账号的DN.
+	ServiceDN = "cn=admin,dc=example,dc=com"
+	// ServicePassword 是服务账号的密码.
+	ServicePassword = "admin"
+
+	startTLSOID = "1.3.6.1.4.1.1466.20037"
+)
+
+// Entry 是Server中的一个条目，Password为空的条目不能绑定.
+type Entry struct {
+	DN       string
+	Password string
+	Attrs    map[string]string
+}
+
+// Server 是一个只支持bind、search、StartTLS和unbind的LDAP服务器.
+type Server struct {
+	// TLSConfig 不为nil时支持StartTLS.
+	TLSConfig *tls.Config
+	// RequireTLS 为true时拒绝没有使用StartTLS的绑定.
+	RequireTLS bool
+
+	ln      net.Listener
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// NewServer 启动一个包含entries的LDAP服务器，测试结束时自动关闭.
+func NewServer(t testing.TB, entries []Entry) *Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err.Error())
+	}
+
+	s := &Server{ln: ln, entries: entries}
+	go s.serve()
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return s
+}
+
+// URL 返回服务器的地址.
+func (s *Server) URL() string {
+	return "ldap://" + s.ln.Addr().String()
+}
+
+// SetEntries 替换服务器中的所有条目.
+func (s *Server) SetEntries(entries []Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = entries
+}
+
+func (s *Server) snapshot() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.entries
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	secure := false
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ber.Tag(0): // BindRequest
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+
+			code := int64(49) // invalidCredentials
+			switch {
+			case s.RequireTLS && !secure:
+				code = 13 // confidentialityRequired
+			case dn == ServiceDN && password == ServicePassword:
+				code = 0
+			default:
+				for _, e := range s.snapshot() {
+					if e.DN == dn && e.Password != "" && e.Password == password {
+						code = 0
+					}
+				}
+			}
+
+			write(conn, id, result(1, code))
+		case ber.Tag(2): // UnbindRequest
+			return
+		case ber.Tag(3): // SearchRequest
+			filter := op.Children[6]
+			for _, e := range s.snapshot() {
+				if match(filter, e) {
+					write(conn, id, searchEntry(e))
+				}
+			}
+
+			write(conn, id, result(5, 0))
+		case ber.Tag(23): // ExtendedRequest
+			if op.Children[0].Data.String() != startTLSOID || s.TLSConfig == nil {
+				write(conn, id, result(24, 2))
+
+				continue
+			}
+
+			write(conn, id, result(24, 0))
+
+			tlsConn := tls.Server(conn, s.TLSConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+
+			conn = tlsConn
+			secure = true
+		default:
+			return
+		}
+	}
+}
+
+func write(conn net.Conn, id int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	packet.AppendChild(op)
+
+	_, _ = conn.Write(packet.Bytes())
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+	return op
+}
+
+func searchEntry(e Entry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, 4, nil, "")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, ""))
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, value := range e.Attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		attr.AppendChild(values)
+		attrs.AppendChild(attr)
+	}
+
+	op.AppendChild(attrs)
+
+	return op
+}
+
+// match 支持and、or、not、equalityMatch和present过滤器.
+func match(filter *ber.Packet, e Entry) bool {
+	switch filter.Tag {
+	case 0:
+		for _, child := range filter.Children {
+			if !match(child, e) {
+				return false
+			}
+		}
+
+		return true
+	case 1:
+		for _, child := range filter.Children {
+			if match(child, e) {
+				return true
+			}
+		}
+
+		return false
+	case 2:
+		return !match(filter.Children[0], e)
+	case 3:
+		name := filter.Children[0].Data.String()
+		value := filter.Children[1].Data.String()
+
+		if strings.EqualFold(name, "objectClass") {
+			return value == "person"
+		}
+
+		return e.Attrs[name] == value
+	case 7:
+		name := filter.Data.String()
+		_, ok := e.Attrs[name]
+
+		return ok || strings.EqualFold(name, "objectClass")
+	default:
+		return false
+	}
+}
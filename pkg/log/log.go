@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"log"
@@ -469,6 +470,12 @@ func (l *zapLogger) L(ctx context.Context) *zapLogger {
 	if watcherName := ctx.Value(KeyWatcherName); watcherName != nil {
 		lg.zapLogger = lg.zapLogger.With(zap.Any(KeyWatcherName, watcherName))
 	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		lg.zapLogger = lg.zapLogger.With(
+			zap.String(KeyTraceID, spanContext.TraceID().String()),
+			zap.String(KeySpanID, spanContext.SpanID().String()),
+		)
+	}
 
 	return lg
 }
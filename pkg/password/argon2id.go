@@ -0,0 +1,128 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/marmotedu/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// ErrInvalidHash 在哈希字符串格式不正确时返回.
+var ErrInvalidHash = errors.New("password: invalid hash format")
+
+// argon2Params 是argon2id算法的参数.
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+type argon2idHasher struct {
+	params argon2Params
+}
+
+var _ Hasher = &argon2idHasher{}
+
+func newArgon2idHasher(opts *Options) *argon2idHasher {
+	return &argon2idHasher{params: argon2Params{
+		memory:      opts.Argon2Memory,
+		iterations:  opts.Argon2Iterations,
+		parallelism: opts.Argon2Parallelism,
+		saltLength:  opts.Argon2SaltLength,
+		keyLength:   opts.Argon2KeyLength,
+	}}
+}
+
+func (a *argon2idHasher) Algorithm() string {
+	return AlgorithmArgon2id
+}
+
+// Hash 生成PHC格式的哈希字符串: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>.
+func (a *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := a.params
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.memory,
+		p.iterations,
+		p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *argon2idHasher) Compare(hashed, password string) error {
+	p, salt, key, err := decodeArgon2idHash(hashed)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
+
+func (a *argon2idHasher) Identify(hashed string) bool {
+	return strings.HasPrefix(hashed, argon2idPrefix)
+}
+
+func (a *argon2idHasher) NeedsRehash(hashed string) bool {
+	p, _, _, err := decodeArgon2idHash(hashed)
+	if err != nil {
+		return true
+	}
+
+	return p != a.params
+}
+
+// 解析PHC格式的argon2id哈希字符串.
+func decodeArgon2idHash(hashed string) (p argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("password: incompatible argon2 version %d", version)
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	p.saltLength = uint32(len(salt))
+	p.keyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,59 @@
+package password
+
+import (
+	"strings"
+
+	"github.com/marmotedu/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultBcryptCost = bcrypt.DefaultCost
+
+type bcryptHasher struct {
+	cost int
+}
+
+var _ Hasher = &bcryptHasher{}
+
+func newBcryptHasher(cost int) *bcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = defaultBcryptCost
+	}
+
+	return &bcryptHasher{cost: cost}
+}
+
+func (b *bcryptHasher) Algorithm() string {
+	return AlgorithmBcrypt
+}
+
+func (b *bcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+
+	return string(hashed), err
+}
+
+func (b *bcryptHasher) Compare(hashed, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatchedPassword
+	}
+
+	return err
+}
+
+// Identify bcrypt哈希以$2a$、$2b$或$2y$开头.
+func (b *bcryptHasher) Identify(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") ||
+		strings.HasPrefix(hashed, "$2b$") ||
+		strings.HasPrefix(hashed, "$2y$")
+}
+
+func (b *bcryptHasher) NeedsRehash(hashed string) bool {
+	cost, err := bcrypt.Cost([]byte(hashed))
+	if err != nil {
+		return true
+	}
+
+	return cost != b.cost
+}
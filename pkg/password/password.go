@@ -0,0 +1,153 @@
+// Package password 提供可插拔的密码哈希算法，支持bcrypt和argon2id.
+// 生成的哈希字符串是自描述的，包含了算法和参数，可以据此判断是否需要重新哈希.
+package password
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/marmotedu/errors"
+)
+
+// 支持的密码哈希算法.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	// ErrMismatchedPassword 在密码和哈希值不匹配时返回.
+	ErrMismatchedPassword = errors.New("password: hashed password is not the hash of the given password")
+
+	// ErrUnknownAlgorithm 在无法识别哈希字符串使用的算法时返回.
+	ErrUnknownAlgorithm = errors.New("password: unknown hash algorithm")
+)
+
+// Hasher 定义了一种密码哈希算法需要实现的方法.
+type Hasher interface {
+	// Algorithm 返回算法名称.
+	Algorithm() string
+	// Hash 对明文密码进行哈希运算，返回自描述的哈希字符串.
+	Hash(password string) (string, error)
+	// Compare 比较哈希字符串和明文密码是否匹配.
+	Compare(hashed, password string) error
+	// Identify 判断哈希字符串是否由该算法生成.
+	Identify(hashed string) bool
+	// NeedsRehash 判断哈希字符串使用的参数是否和当前参数不一致.
+	NeedsRehash(hashed string) bool
+}
+
+// Options 定义了密码哈希算法的选项.
+type Options struct {
+	Algorithm         string // 新密码使用的哈希算法: bcrypt, argon2id
+	BcryptCost        int    // bcrypt的cost参数
+	Argon2Memory      uint32 // argon2id使用的内存大小，单位KiB
+	Argon2Iterations  uint32 // argon2id的迭代次数
+	Argon2Parallelism uint8  // argon2id的并行度
+	Argon2SaltLength  uint32 // argon2id盐值长度
+	Argon2KeyLength   uint32 // argon2id生成的密钥长度
+}
+
+// NewOptions 创建带有默认值的Options.
+func NewOptions() *Options {
+	return &Options{
+		Algorithm:         AlgorithmBcrypt,
+		BcryptCost:        defaultBcryptCost,
+		Argon2Memory:      64 * 1024,
+		Argon2Iterations:  3,
+		Argon2Parallelism: 2,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+	}
+}
+
+// Manager 使用当前算法生成哈希，并能校验所有已知算法生成的哈希.
+type Manager struct {
+	current Hasher
+	hashers []Hasher
+}
+
+// New 根据选项创建Manager.
+func New(opts *Options) (*Manager, error) {
+	bc := newBcryptHasher(opts.BcryptCost)
+	ag := newArgon2idHasher(opts)
+
+	m := &Manager{hashers: []Hasher{bc, ag}}
+
+	switch opts.Algorithm {
+	case AlgorithmBcrypt:
+		m.current = bc
+	case AlgorithmArgon2id:
+		m.current = ag
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %q", opts.Algorithm)
+	}
+
+	return m, nil
+}
+
+// Hash 使用当前算法对密码进行哈希.
+func (m *Manager) Hash(password string) (string, error) {
+	return m.current.Hash(password)
+}
+
+// Compare 校验密码，校验成功后如果哈希的算法或参数和当前配置不一致，rehash返回true.
+func (m *Manager) Compare(hashed, password string) (rehash bool, err error) {
+	for _, h := range m.hashers {
+		if !h.Identify(hashed) {
+			continue
+		}
+
+		if err := h.Compare(hashed, password); err != nil {
+			return false, err
+		}
+
+		return h.Algorithm() != m.current.Algorithm() || h.NeedsRehash(hashed), nil
+	}
+
+	return false, ErrUnknownAlgorithm
+}
+
+var (
+	std = mustNew(NewOptions())
+	mu  sync.RWMutex
+)
+
+func mustNew(opts *Options) *Manager {
+	m, err := New(opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+// Init 根据选项初始化全局的Manager.
+func Init(opts *Options) error {
+	m, err := New(opts)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	std = m
+	mu.Unlock()
+
+	return nil
+}
+
+// Hash 使用全局Manager对密码进行哈希.
+func Hash(password string) (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return std.Hash(password)
+}
+
+// Compare 使用全局Manager校验密码.
+func Compare(hashed, password string) (rehash bool, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return std.Compare(hashed, password)
+}
@@ -0,0 +1,87 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOptions(algorithm string) *Options {
+	opts := NewOptions()
+	opts.Algorithm = algorithm
+	opts.BcryptCost = 4
+	opts.Argon2Memory = 1024
+	opts.Argon2Iterations = 1
+
+	return opts
+}
+
+func TestManager_HashAndCompare(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmBcrypt, AlgorithmArgon2id} {
+		m, err := New(testOptions(algorithm))
+		assert.Nil(t, err)
+
+		hashed, err := m.Hash("Admin@2021")
+		assert.Nil(t, err)
+
+		rehash, err := m.Compare(hashed, "Admin@2021")
+		assert.Nil(t, err)
+		assert.False(t, rehash, algorithm)
+
+		_, err = m.Compare(hashed, "wrong")
+		assert.Equal(t, ErrMismatchedPassword, err, algorithm)
+	}
+}
+
+func TestManager_Argon2idFormat(t *testing.T) {
+	m, _ := New(testOptions(AlgorithmArgon2id))
+
+	hashed, err := m.Hash("Admin@2021")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=2$"))
+}
+
+func TestManager_RehashOnAlgorithmChange(t *testing.T) {
+	bc, _ := New(testOptions(AlgorithmBcrypt))
+	ag, _ := New(testOptions(AlgorithmArgon2id))
+
+	hashed, _ := bc.Hash("Admin@2021")
+
+	rehash, err := ag.Compare(hashed, "Admin@2021")
+	assert.Nil(t, err)
+	assert.True(t, rehash)
+}
+
+func TestManager_RehashOnParamsChange(t *testing.T) {
+	old, _ := New(testOptions(AlgorithmArgon2id))
+	hashed, _ := old.Hash("Admin@2021")
+
+	opts := testOptions(AlgorithmArgon2id)
+	opts.Argon2Iterations = 2
+	current, _ := New(opts)
+
+	rehash, err := current.Compare(hashed, "Admin@2021")
+	assert.Nil(t, err)
+	assert.True(t, rehash)
+
+	opts = testOptions(AlgorithmBcrypt)
+	opts.BcryptCost = 5
+	bc, _ := New(testOptions(AlgorithmBcrypt))
+	hashed, _ = bc.Hash("Admin@2021")
+	current, _ = New(opts)
+
+	rehash, err = current.Compare(hashed, "Admin@2021")
+	assert.Nil(t, err)
+	assert.True(t, rehash)
+}
+
+func TestManager_UnknownAlgorithm(t *testing.T) {
+	m, _ := New(testOptions(AlgorithmBcrypt))
+
+	_, err := m.Compare("plaintext", "plaintext")
+	assert.Equal(t, ErrUnknownAlgorithm, err)
+
+	_, err = New(testOptions("md5"))
+	assert.NotNil(t, err)
+}
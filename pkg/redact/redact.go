@@ -0,0 +1,129 @@
+// Package redact 隐藏json、表单和http头中的敏感字段，用于在日志和审计记录中输出请求内容.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mask 替换敏感字段的值.
+const Mask = "******"
+
+// DefaultFields 返回默认隐藏的json和表单字段.
+func DefaultFields() []string {
+	return []string{
+		"password", "newPassword", "oldPassword", "secretKey", "clientSecret", "client_secret",
+		"token", "accessToken", "access_token", "refreshToken", "refresh_token", "passcode", "recoveryCodes",
+	}
+}
+
+// DefaultHeaders 返回默认隐藏的http头.
+func DefaultHeaders() []string {
+	return []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+}
+
+// Fields 是需要隐藏的字段名集合，匹配时不区分大小写.
+type Fields map[string]struct{}
+
+// NewFields 使用字段名创建Fields.
+func NewFields(names ...string) Fields {
+	fields := make(Fields, len(names))
+	for _, name := range names {
+		fields[strings.ToLower(name)] = struct{}{}
+	}
+
+	return fields
+}
+
+// Has 返回字段是否需要隐藏.
+func (f Fields) Has(name string) bool {
+	_, ok := f[strings.ToLower(name)]
+
+	return ok
+}
+
+// JSON 隐藏data中任意层级的敏感字段，data不是合法的json时返回false.
+func JSON(data []byte, fields Fields) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return nil, false
+	}
+
+	out, err := json.Marshal(Value(v, fields))
+	if err != nil {
+		return nil, false
+	}
+
+	return out, true
+}
+
+// Value 递归隐藏json解码后的值中的敏感字段，会直接修改传入的map和slice.
+func Value(v interface{}, fields Fields) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if fields.Has(k) {
+				val[k] = Mask
+
+				continue
+			}
+
+			val[k] = Value(item, fields)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = Value(item, fields)
+		}
+	}
+
+	return v
+}
+
+// Form 返回隐藏了敏感字段的表单副本.
+func Form(values url.Values, fields Fields) url.Values {
+	out := make(url.Values, len(values))
+	for k, v := range values {
+		if fields.Has(k) {
+			out[k] = []string{Mask}
+
+			continue
+		}
+
+		out[k] = append([]string(nil), v...)
+	}
+
+	return out
+}
+
+// Query 返回隐藏了敏感参数的查询字符串，无法解析时只返回长度.
+func Query(rawQuery string, fields Fields) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return fmt.Sprintf("<%d bytes omitted>", len(rawQuery))
+	}
+
+	return Form(values, fields).Encode()
+}
+
+// Header 返回隐藏了敏感头的http头副本.
+func Header(header http.Header, names Fields) http.Header {
+	out := header.Clone()
+	for k := range out {
+		if names.Has(k) {
+			out[k] = []string{Mask}
+		}
+	}
+
+	return out
+}
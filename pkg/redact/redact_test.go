@@ -0,0 +1,54 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	fields := NewFields(DefaultFields()...)
+
+	out, ok := JSON([]byte(`{"metadata":{"name":"colin"},"Password":"Admin@2021","id":12345678901234567890,`+
+		`"items":[{"secretKey":"abc","expires":0}]}`), fields)
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"metadata":{"name":"colin"},"Password":"******","id":12345678901234567890,`+
+		`"items":[{"secretKey":"******","expires":0}]}`, string(out))
+
+	_, ok = JSON([]byte(`password=Admin@2021`), fields)
+	assert.False(t, ok)
+
+	_, ok = JSON([]byte(`{"a":1}{"b":2}`), fields)
+	assert.False(t, ok)
+}
+
+func TestForm(t *testing.T) {
+	values := url.Values{"client_id": {"app"}, "client_secret": {"s3cret"}}
+
+	out := Form(values, NewFields(DefaultFields()...))
+	assert.Equal(t, "app", out.Get("client_id"))
+	assert.Equal(t, Mask, out.Get("client_secret"))
+	assert.Equal(t, "s3cret", values.Get("client_secret"))
+}
+
+func TestQuery(t *testing.T) {
+	fields := NewFields(DefaultFields()...)
+
+	// JWT认证允许通过?token=传递token
+	assert.Equal(t, "name=colin&token=%2A%2A%2A%2A%2A%2A", Query("token=eyJhbGciOiJIUzI1NiJ9.e30.sig&name=colin", fields))
+	assert.Equal(t, "", Query("", fields))
+	assert.Equal(t, "<15 bytes omitted>", Query("token=%zz&a=b;c", fields))
+}
+
+func TestHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer xxx")
+	header.Set("Content-Type", "application/json")
+
+	out := Header(header, NewFields(DefaultHeaders()...))
+	assert.Equal(t, Mask, out.Get("Authorization"))
+	assert.Equal(t, "application/json", out.Get("Content-Type"))
+	assert.Equal(t, "Bearer xxx", header.Get("Authorization"))
+}
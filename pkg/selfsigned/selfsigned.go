@@ -0,0 +1,326 @@
+// Package selfsigned 生成自签名的CA和由它签发的服务端证书，用于没有提供证书时启动tls服务.
+package selfsigned
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 支持的私钥类型.
+const (
+	KeyTypeECDSAP256 = "ecdsa-p256"
+	KeyTypeECDSAP384 = "ecdsa-p384"
+	KeyTypeRSA2048   = "rsa-2048"
+	KeyTypeRSA4096   = "rsa-4096"
+	KeyTypeEd25519   = "ed25519"
+)
+
+// KeyTypes 返回支持的私钥类型.
+func KeyTypes() []string {
+	return []string{KeyTypeECDSAP256, KeyTypeECDSAP384, KeyTypeRSA2048, KeyTypeRSA4096, KeyTypeEd25519}
+}
+
+// CA的有效期，服务端证书的有效期不会超过CA.
+const caValidity = 10 * 365 * 24 * time.Hour
+
+// Options 是生成证书的配置.
+type Options struct {
+	// Dir 证书所在的目录，生成的文件为<PairName>.crt、<PairName>.key、<PairName>-ca.crt和<PairName>-ca.key.
+	Dir      string
+	PairName string
+	// CommonName 服务端证书的CN.
+	CommonName string
+	// Hosts 服务端证书中的域名或者IP.
+	Hosts []string
+	// KeyType 私钥类型，CA和服务端证书使用相同的类型.
+	KeyType string
+	// Validity 服务端证书的有效期.
+	Validity time.Duration
+	// RenewBefore 服务端证书在过期前多久重新生成.
+	RenewBefore time.Duration
+
+	now func() time.Time
+}
+
+// CertFile 返回服务端证书文件，文件中包含服务端证书和CA证书.
+func (o *Options) CertFile() string {
+	return filepath.Join(o.Dir, o.PairName+".crt")
+}
+
+// KeyFile 返回服务端私钥文件.
+func (o *Options) KeyFile() string {
+	return filepath.Join(o.Dir, o.PairName+".key")
+}
+
+// CAFile 返回CA证书文件，客户端使用它校验服务端证书.
+func (o *Options) CAFile() string {
+	return filepath.Join(o.Dir, o.PairName+"-ca.crt")
+}
+
+// CAKeyFile 返回CA私钥文件.
+func (o *Options) CAKeyFile() string {
+	return filepath.Join(o.Dir, o.PairName+"-ca.key")
+}
+
+func (o *Options) time() time.Time {
+	if o.now != nil {
+		return o.now()
+	}
+
+	return time.Now()
+}
+
+// Ensure 确保Dir中有可用的服务端证书，返回是否生成了新的证书.
+//
+// 证书或者私钥不存在时生成CA和服务端证书. 已经存在的证书只有在由Ensure生成(存在CA私钥)时才会被替换:
+// 服务端证书即将过期或者没有包含所有的Hosts时使用原来的CA重新签发，CA即将过期时同时重新生成CA.
+func Ensure(o Options) (bool, error) {
+	if _, err := keyGenerator(o.KeyType); err != nil {
+		return false, err
+	}
+
+	if o.Validity <= 0 {
+		return false, fmt.Errorf("certificate validity must be greater than 0")
+	}
+
+	_, certErr := os.Stat(o.CertFile())
+	_, keyErr := os.Stat(o.KeyFile())
+
+	if certErr == nil && keyErr == nil {
+		// 外部提供的证书不做修改
+		if !o.generated() {
+			return false, nil
+		}
+
+		cert, err := tls.LoadX509KeyPair(o.CertFile(), o.KeyFile())
+		if err == nil {
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err == nil && !o.needsRenew(leaf) {
+				return false, nil
+			}
+		}
+	}
+
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return false, err
+	}
+
+	ca, caKey, err := o.loadOrCreateCA()
+	if err != nil {
+		return false, err
+	}
+
+	if err := o.createServingCert(ca, caKey); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// generated 返回证书是否由Ensure生成.
+func (o *Options) generated() bool {
+	_, err := os.Stat(o.CAKeyFile())
+
+	return err == nil
+}
+
+// needsRenew 判断服务端证书是否即将过期或者没有包含所有的Hosts.
+func (o *Options) needsRenew(cert *x509.Certificate) bool {
+	if o.time().Add(o.RenewBefore).After(cert.NotAfter) {
+		return true
+	}
+
+	for _, host := range o.Hosts {
+		if cert.VerifyHostname(host) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadOrCreateCA 加载已经存在的CA，CA不存在或者在新签发的服务端证书过期前就会过期时重新生成.
+func (o *Options) loadOrCreateCA() (*x509.Certificate, crypto.Signer, error) {
+	if pair, err := tls.LoadX509KeyPair(o.CAFile(), o.CAKeyFile()); err == nil {
+		ca, err := x509.ParseCertificate(pair.Certificate[0])
+		signer, ok := pair.PrivateKey.(crypto.Signer)
+
+		if err == nil && ok && ca.IsCA && ca.NotAfter.After(o.time().Add(o.Validity)) {
+			return ca, signer, nil
+		}
+	}
+
+	key, err := o.generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := o.time()
+	tmpl := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s-ca@%d", o.CommonName, now.Unix())},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := createCertificate(tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeKey(o.CAKeyFile(), key); err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeFile(o.CAFile(), pemCert(der), 0o644); err != nil {
+		return nil, nil, err
+	}
+
+	return ca, key, nil
+}
+
+// createServingCert 使用CA签发服务端证书，证书文件中追加CA证书.
+func (o *Options) createServingCert(ca *x509.Certificate, caKey crypto.Signer) error {
+	key, err := o.generateKey()
+	if err != nil {
+		return err
+	}
+
+	now := o.time()
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: o.CommonName},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(o.Validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if tmpl.NotAfter.After(ca.NotAfter) {
+		tmpl.NotAfter = ca.NotAfter
+	}
+
+	// 使用RSA密钥交换时需要KeyEncipherment
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	for _, host := range o.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, host)
+		}
+	}
+
+	der, err := createCertificate(tmpl, ca, key.Public(), caKey)
+	if err != nil {
+		return err
+	}
+
+	// 先写私钥再写证书，证书和私钥短暂不匹配时加载会失败并保留原来的证书
+	if err := writeKey(o.KeyFile(), key); err != nil {
+		return err
+	}
+
+	return writeFile(o.CertFile(), append(pemCert(der), pemCert(ca.Raw)...), 0o644)
+}
+
+func (o *Options) generateKey() (crypto.Signer, error) {
+	generate, err := keyGenerator(o.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	return generate()
+}
+
+func keyGenerator(keyType string) (func() (crypto.Signer, error), error) {
+	switch keyType {
+	case KeyTypeECDSAP256:
+		return func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P256(), rand.Reader) }, nil
+	case KeyTypeECDSAP384:
+		return func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P384(), rand.Reader) }, nil
+	case KeyTypeRSA2048:
+		return func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 2048) }, nil
+	case KeyTypeRSA4096:
+		return func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 4096) }, nil
+	case KeyTypeEd25519:
+		return func() (crypto.Signer, error) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+
+			return key, err
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+func createCertificate(tmpl, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.SerialNumber = serial
+
+	return x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+}
+
+func pemCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func writeKey(file string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
+
+// writeFile 先写临时文件再重命名，避免读到不完整的文件.
+func writeFile(file string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,137 @@
+package selfsigned
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOptions(t *testing.T) Options {
+	return Options{
+		Dir:         t.TempDir(),
+		PairName:    "iam",
+		CommonName:  "iam-apiserver",
+		Hosts:       []string{"localhost", "127.0.0.1"},
+		KeyType:     KeyTypeECDSAP256,
+		Validity:    365 * 24 * time.Hour,
+		RenewBefore: 30 * 24 * time.Hour,
+	}
+}
+
+// verify 使用CA文件校验服务端证书，返回服务端证书.
+func verify(t *testing.T, o Options, host string) *x509.Certificate {
+	pair, err := tls.LoadX509KeyPair(o.CertFile(), o.KeyFile())
+	assert.Nil(t, err)
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	assert.Nil(t, err)
+
+	caData, err := os.ReadFile(o.CAFile())
+	assert.Nil(t, err)
+
+	roots := x509.NewCertPool()
+	assert.True(t, roots.AppendCertsFromPEM(caData))
+
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots, CurrentTime: o.time()})
+	assert.Nil(t, err)
+
+	return leaf
+}
+
+func TestEnsure(t *testing.T) {
+	for _, keyType := range []string{KeyTypeECDSAP256, KeyTypeECDSAP384, KeyTypeRSA2048, KeyTypeEd25519} {
+		o := testOptions(t)
+		o.KeyType = keyType
+
+		generated, err := Ensure(o)
+		assert.Nil(t, err, keyType)
+		assert.True(t, generated, keyType)
+
+		leaf := verify(t, o, "localhost")
+		verify(t, o, "127.0.0.1")
+		assert.Equal(t, "iam-apiserver", leaf.Subject.CommonName)
+
+		info, err := os.Stat(o.KeyFile())
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestEnsureReuse(t *testing.T) {
+	o := testOptions(t)
+
+	_, err := Ensure(o)
+	assert.Nil(t, err)
+	first := verify(t, o, "localhost")
+
+	generated, err := Ensure(o)
+	assert.Nil(t, err)
+	assert.False(t, generated)
+	assert.Equal(t, first.SerialNumber, verify(t, o, "localhost").SerialNumber)
+}
+
+func TestEnsureRenew(t *testing.T) {
+	o := testOptions(t)
+
+	_, err := Ensure(o)
+	assert.Nil(t, err)
+	first := verify(t, o, "localhost")
+
+	caData, err := os.ReadFile(o.CAFile())
+	assert.Nil(t, err)
+
+	// 即将过期时使用原来的CA重新签发
+	o.now = func() time.Time { return time.Now().Add(340 * 24 * time.Hour) }
+	generated, err := Ensure(o)
+	assert.Nil(t, err)
+	assert.True(t, generated)
+
+	renewed := verify(t, o, "localhost")
+	assert.NotEqual(t, first.SerialNumber, renewed.SerialNumber)
+
+	newCAData, err := os.ReadFile(o.CAFile())
+	assert.Nil(t, err)
+	assert.Equal(t, caData, newCAData)
+
+	// 新增的域名不在证书中时重新签发
+	o.Hosts = append(o.Hosts, "iam.example.com")
+	generated, err = Ensure(o)
+	assert.Nil(t, err)
+	assert.True(t, generated)
+	verify(t, o, "iam.example.com")
+}
+
+func TestEnsureKeepsExternal(t *testing.T) {
+	o := testOptions(t)
+
+	_, err := Ensure(o)
+	assert.Nil(t, err)
+	first := verify(t, o, "localhost")
+
+	// 没有CA私钥的证书被认为是外部提供的，即将过期也不替换
+	assert.Nil(t, os.Remove(o.CAKeyFile()))
+
+	o.now = func() time.Time { return time.Now().Add(340 * 24 * time.Hour) }
+	generated, err := Ensure(o)
+	assert.Nil(t, err)
+	assert.False(t, generated)
+	assert.Equal(t, first.SerialNumber, verify(t, o, "localhost").SerialNumber)
+}
+
+func TestEnsureInvalid(t *testing.T) {
+	o := testOptions(t)
+	o.KeyType = "dsa"
+
+	_, err := Ensure(o)
+	assert.NotNil(t, err)
+
+	o = testOptions(t)
+	o.Validity = 0
+
+	_, err = Ensure(o)
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,11 @@
 package shutdown
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
 
 // ShutdownManager 是一个由服务关闭管理器实现的接口.
 type ShutdownManager interface {
@@ -46,17 +51,66 @@ type GSInterface interface {
 
 var _ GSInterface = &GracefuleShutdown{}
 
+// Phase 是关闭服务的阶段，阶段按照从小到大的顺序依次执行，同一阶段中的回调并发执行.
+type Phase int
+
+// 关闭服务的阶段.
+const (
+	// PhaseStopReadiness 停止接收新的流量，例如把健康检查设置为未就绪.
+	PhaseStopReadiness Phase = iota
+	// PhaseDrain 等待正在处理的请求完成并关闭服务.
+	PhaseDrain
+	// PhaseStopBackground 停止后台任务，AddShutdownCallback添加的回调在这个阶段执行.
+	PhaseStopBackground
+	// PhaseCloseStorage 关闭数据库、缓存等存储的连接.
+	PhaseCloseStorage
+)
+
+// String 返回阶段的名称.
+func (p Phase) String() string {
+	switch p {
+	case PhaseStopReadiness:
+		return "stop-readiness"
+	case PhaseDrain:
+		return "drain"
+	case PhaseStopBackground:
+		return "stop-background"
+	case PhaseCloseStorage:
+		return "close-storage"
+	default:
+		return fmt.Sprintf("phase-%d", int(p))
+	}
+}
+
+// CallbackObserver 在每个回调完成或者超时后调用，可以用来记录关闭操作的耗时.
+type CallbackObserver func(phase Phase, name string, duration time.Duration, err error)
+
+// phaseCallback 是添加到指定阶段的回调.
+type phaseCallback struct {
+	phase    Phase
+	name     string
+	timeout  time.Duration
+	callback ShutdownCallback
+}
+
 // GracefuleShutdown 用于实现优雅关闭的主要结构体.由这来处理ShutdownCallback实例和ShutdownManager实例.
 type GracefuleShutdown struct {
-	callbacks    []ShutdownCallback
+	callbacks    []phaseCallback
 	managers     []ShutdownManager
 	errorHandler ErrorHandler
+	// timeout 所有阶段执行的总时间，为0时不限制
+	timeout  time.Duration
+	observer CallbackObserver
+
+	once sync.Once
+	done chan struct{}
 }
 
 func New() *GracefuleShutdown {
 	return &GracefuleShutdown{
-		callbacks: make([]ShutdownCallback, 0, 10),
+		callbacks: make([]phaseCallback, 0, 10),
 		managers:  make([]ShutdownManager, 0, 3),
+		done:      make(chan struct{}),
 	}
 }
 
@@ -81,27 +135,116 @@ func (gs *GracefuleShutdown) SetErrorHandler(errHandler ErrorHandler) {
 	gs.errorHandler = errHandler
 }
 
+// SetTimeout 设置所有阶段执行的总时间，超时后跳过剩余的阶段. 为0时不限制.
+func (gs *GracefuleShutdown) SetTimeout(timeout time.Duration) {
+	gs.timeout = timeout
+}
+
+// SetCallbackObserver 设置CallbackObserver.
+func (gs *GracefuleShutdown) SetCallbackObserver(observer CallbackObserver) {
+	gs.observer = observer
+}
+
+// Done 返回一个在关闭操作全部完成后关闭的channel.
+func (gs *GracefuleShutdown) Done() <-chan struct{} {
+	return gs.done
+}
+
+// StartShutdown 按阶段执行关闭服务的回调，只有第一次调用生效.
 func (gs *GracefuleShutdown) StartShutdown(sm ShutdownManager) {
+	gs.once.Do(func() {
+		defer close(gs.done)
+
+		gs.shutdown(sm)
+	})
+}
+
+func (gs *GracefuleShutdown) shutdown(sm ShutdownManager) {
 	// 执行关闭服务的预操作
 	gs.ReportError(sm.PreShutdown())
 
-	// 执行关闭服务相关的操作函数
-	var wg sync.WaitGroup
-	for _, callback := range gs.callbacks {
-		wg.Add(1)
-		go func(callback ShutdownCallback) {
-			defer wg.Done()
-
-			gs.ReportError(callback.OnShutdown(sm.GetName()))
-		}(callback)
+	var deadline time.Time
+	if gs.timeout > 0 {
+		deadline = time.Now().Add(gs.timeout)
 	}
 
-	wg.Wait()
+	callbacks := make([]phaseCallback, len(gs.callbacks))
+	copy(callbacks, gs.callbacks)
+	sort.SliceStable(callbacks, func(i, j int) bool {
+		return callbacks[i].phase < callbacks[j].phase
+	})
+
+	// 执行关闭服务相关的操作函数，同一阶段的回调并发执行，上一阶段完成后才执行下一阶段
+	for start := 0; start < len(callbacks); {
+		end := start
+		for end < len(callbacks) && callbacks[end].phase == callbacks[start].phase {
+			end++
+		}
+
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			gs.ReportError(fmt.Errorf("shutdown timed out after %s, skip the remaining phases", gs.timeout))
+
+			break
+		}
+
+		var wg sync.WaitGroup
+		for _, callback := range callbacks[start:end] {
+			wg.Add(1)
+			go func(callback phaseCallback) {
+				defer wg.Done()
+
+				start := time.Now()
+				err := gs.run(callback, sm.GetName(), deadline)
+				if gs.observer != nil {
+					gs.observer(callback.phase, callback.name, time.Since(start), err)
+				}
+
+				gs.ReportError(err)
+			}(callback)
+		}
+		wg.Wait()
+
+		start = end
+	}
 
 	// 执行关闭服务完成之后的操作
 	gs.ReportError(sm.PostShutdown())
 }
 
+// run 执行回调，回调超时后不再等待它完成.
+func (gs *GracefuleShutdown) run(callback phaseCallback, managerName string, deadline time.Time) error {
+	timeout := callback.timeout
+	if !deadline.IsZero() {
+		if remaining := time.Until(deadline); timeout == 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- callback.callback.OnShutdown(managerName)
+	}()
+
+	if timeout <= 0 {
+		return <-done
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		name := callback.name
+		if name == "" {
+			name = "unnamed"
+		}
+
+		return fmt.Errorf("shutdown callback %s did not finish within %s", name, timeout)
+	}
+}
+
 // ReportError 用来汇报error
 func (gs *GracefuleShutdown) ReportError(err error) {
 	if err != nil && gs.errorHandler != nil {
@@ -109,6 +252,17 @@ func (gs *GracefuleShutdown) ReportError(err error) {
 	}
 }
 
+// AddShutdownCallback 添加在PhaseStopBackground阶段执行的回调.
 func (gs *GracefuleShutdown) AddShutdownCallback(callback ShutdownCallback) {
-	gs.callbacks = append(gs.callbacks, callback)
+	gs.AddPhaseCallback(PhaseStopBackground, "", 0, callback)
+}
+
+// AddPhaseCallback 添加在指定阶段执行的回调，name用于超时时的错误信息，timeout为0时只受总时间的限制.
+func (gs *GracefuleShutdown) AddPhaseCallback(phase Phase, name string, timeout time.Duration, callback ShutdownCallback) {
+	gs.callbacks = append(gs.callbacks, phaseCallback{
+		phase:    phase,
+		name:     name,
+		timeout:  timeout,
+		callback: callback,
+	})
 }
@@ -2,6 +2,7 @@ package shutdown
 
 import (
 	"errors"
+	"sync"
 	"testing"
 	"time"
 )
@@ -248,3 +249,135 @@ func TestShutdownManagerName(t *testing.T) {
 		t.Error("Expected shutdownManager to be 'test-sm'.")
 	}
 }
+
+func TestPhasesRunInOrder(t *testing.T) {
+	gs := New()
+
+	var mu sync.Mutex
+	var order []Phase
+
+	record := func(phase Phase) ShutdownFunc {
+		return func(string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, phase)
+			return nil
+		}
+	}
+
+	gs.AddPhaseCallback(PhaseCloseStorage, "storage", 0, record(PhaseCloseStorage))
+	gs.AddShutdownCallback(record(PhaseStopBackground))
+	gs.AddPhaseCallback(PhaseDrain, "drain", 0, ShutdownFunc(func(s string) error {
+		time.Sleep(10 * time.Millisecond)
+		return record(PhaseDrain)(s)
+	}))
+	gs.AddPhaseCallback(PhaseStopReadiness, "readiness", 0, record(PhaseStopReadiness))
+
+	gs.StartShutdown(SMFinishFunc(func() error {
+		return nil
+	}))
+
+	expected := []Phase{PhaseStopReadiness, PhaseDrain, PhaseStopBackground, PhaseCloseStorage}
+	if len(order) != len(expected) {
+		t.Fatal("Expected 4 callbacks to be called, got ", len(order))
+	}
+
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Error("Expected phases in order ", expected, ", got ", order)
+		}
+	}
+
+	select {
+	case <-gs.Done():
+	default:
+		t.Error("Expected Done to be closed after shutdown.")
+	}
+}
+
+func TestCallbackTimeout(t *testing.T) {
+	c := make(chan int, 100)
+	gs := New()
+
+	gs.SetErrorHandler(ErrorFunc(func(err error) {
+		c <- 1
+	}))
+
+	gs.AddPhaseCallback(PhaseDrain, "slow", 10*time.Millisecond, ShutdownFunc(func(string) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+
+	start := time.Now()
+	gs.StartShutdown(SMFinishFunc(func() error {
+		return nil
+	}))
+
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected shutdown not to wait for the slow callback.")
+	}
+
+	if len(c) != 1 {
+		t.Error("Expected 1 timeout error, got ", len(c))
+	}
+}
+
+func TestShutdownTimeoutSkipsRemainingPhases(t *testing.T) {
+	c := make(chan int, 100)
+	gs := New()
+	gs.SetTimeout(20 * time.Millisecond)
+
+	gs.AddPhaseCallback(PhaseDrain, "slow", 0, ShutdownFunc(func(string) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+	gs.AddPhaseCallback(PhaseCloseStorage, "storage", 0, ShutdownFunc(func(string) error {
+		c <- 1
+		return nil
+	}))
+
+	start := time.Now()
+	gs.StartShutdown(SMFinishFunc(func() error {
+		return nil
+	}))
+
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected shutdown to finish within the timeout.")
+	}
+
+	if len(c) != 0 {
+		t.Error("Expected phases after the timeout to be skipped.")
+	}
+}
+
+func TestCallbackObserver(t *testing.T) {
+	gs := New()
+
+	var mu sync.Mutex
+	observed := make(map[string]error)
+
+	gs.SetCallbackObserver(func(phase Phase, name string, duration time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed[phase.String()+"/"+name] = err
+	})
+
+	gs.AddPhaseCallback(PhaseDrain, "ok", 0, ShutdownFunc(func(string) error {
+		return nil
+	}))
+	gs.AddPhaseCallback(PhaseCloseStorage, "failed", 0, ShutdownFunc(func(string) error {
+		return errors.New("my-error")
+	}))
+
+	gs.StartShutdown(SMFinishFunc(func() error {
+		return nil
+	}))
+
+	if err, ok := observed["drain/ok"]; !ok || err != nil {
+		t.Error("Expected drain/ok to be observed without error, got ", observed)
+	}
+
+	if err, ok := observed["close-storage/failed"]; !ok || err == nil {
+		t.Error("Expected close-storage/failed to be observed with error, got ", observed)
+	}
+}
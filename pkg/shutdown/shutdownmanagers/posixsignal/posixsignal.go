@@ -1,6 +1,7 @@
 package posixsignal
 
 import (
+	"fmt"
 	"github.com/cuizhaoyue/iams/pkg/shutdown"
 	"os"
 	"os/signal"
@@ -12,9 +13,13 @@ const Name = "PosixSignalManager"
 
 // PosixSignalManager 是一种服务关闭管理器，它实现了ShutdownManager接口.
 type PosixSignalManager struct {
-	signals []os.Signal
+	signals   []os.Signal
+	forceExit bool
 }
 
+// exit 用于强制退出进程，测试时可以替换.
+var exit = os.Exit
+
 var _ shutdown.ShutdownManager = &PosixSignalManager{}
 
 func NewPosixSignalManager(sig ...os.Signal) *PosixSignalManager {
@@ -33,15 +38,30 @@ func (pm *PosixSignalManager) GetName() string {
 	return Name
 }
 
+// EnableForceExit 开始关闭服务后再次收到信号时立即退出进程，不再等待关闭操作完成.
+func (pm *PosixSignalManager) EnableForceExit() *PosixSignalManager {
+	pm.forceExit = true
+
+	return pm
+}
+
 // Start 关闭服务启动入口.
 func (pm *PosixSignalManager) Start(gs shutdown.GSInterface) error {
 	go func() {
-		c := make(chan os.Signal, 1)
+		c := make(chan os.Signal, 2)
 		signal.Notify(c, pm.signals...)
 
 		// 接收到信号前一直阻塞
 		<-c
 
+		if pm.forceExit {
+			go func() {
+				sig := <-c
+				fmt.Fprintf(os.Stderr, "Received signal %s again, exit immediately\n", sig)
+				exit(1)
+			}()
+		}
+
 		// 调用服务关闭相关的操作函数
 		gs.StartShutdown(pm)
 	}()
@@ -54,9 +74,7 @@ func (pm *PosixSignalManager) PreShutdown() error {
 	return nil
 }
 
-// PostShutdown 退出应用.
+// PostShutdown does nothing. 关闭操作完成后由应用自己退出，可以通过GracefuleShutdown.Done等待关闭操作完成.
 func (pm *PosixSignalManager) PostShutdown() error {
-	os.Exit(0)
-
 	return nil
 }
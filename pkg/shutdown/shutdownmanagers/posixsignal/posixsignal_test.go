@@ -2,6 +2,7 @@ package posixsignal
 
 import (
 	"github.com/cuizhaoyue/iams/pkg/shutdown"
+	"os"
 	"syscall"
 	"testing"
 	"time"
@@ -67,3 +68,34 @@ func TestStartShutdownCalledCustomSignal(t *testing.T) {
 
 	waitSig(t, c)
 }
+
+func TestForceExitOnSecondSignal(t *testing.T) {
+	c := make(chan int, 100)
+	exited := make(chan int, 1)
+
+	exit = func(code int) {
+		exited <- code
+	}
+	defer func() {
+		exit = os.Exit
+	}()
+
+	psm := NewPosixSignalManager(syscall.SIGUSR1).EnableForceExit()
+	psm.Start(startShutdownFunc(func(sm shutdown.ShutdownManager) {
+		c <- 1
+	}))
+
+	time.Sleep(time.Millisecond)
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitSig(t, c)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Error("Expected exit code 1, got ", code)
+		}
+	case <-time.After(time.Second):
+		t.Error("Timeout waiting for forced exit.")
+	}
+}
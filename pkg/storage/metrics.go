@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// redisCollector 在采集时读取redis的连接状态和连接池统计信息.
+type redisCollector struct {
+	up         *prometheus.Desc
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+var _ prometheus.Collector = &redisCollector{}
+
+func newRedisCollector() *redisCollector {
+	labels := []string{"pool"}
+
+	return &redisCollector{
+		up: prometheus.NewDesc("iam_redis_up",
+			"Whether the redis cluster is reachable (1) or not (0).", nil, nil),
+		hits: prometheus.NewDesc("iam_redis_pool_hits_total",
+			"Number of times a free connection was found in the pool.", labels, nil),
+		misses: prometheus.NewDesc("iam_redis_pool_misses_total",
+			"Number of times a free connection was not found in the pool.", labels, nil),
+		timeouts: prometheus.NewDesc("iam_redis_pool_timeouts_total",
+			"Number of times a wait for a connection timed out.", labels, nil),
+		totalConns: prometheus.NewDesc("iam_redis_pool_connections",
+			"Number of connections in the pool.", labels, nil),
+		idleConns: prometheus.NewDesc("iam_redis_pool_idle_connections",
+			"Number of idle connections in the pool.", labels, nil),
+		staleConns: prometheus.NewDesc("iam_redis_pool_stale_connections_total",
+			"Number of stale connections removed from the pool.", labels, nil),
+	}
+}
+
+func (c *redisCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.up, c.hits, c.misses, c.timeouts, c.totalConns, c.idleConns, c.staleConns,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *redisCollector) Collect(ch chan<- prometheus.Metric) {
+	up := 0.0
+	if Connected() {
+		up = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up)
+
+	for pool, cache := range map[string]bool{"default": false, "cache": true} {
+		client := singleton(cache)
+		if client == nil {
+			continue
+		}
+
+		stats := client.PoolStats()
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits), pool)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses), pool)
+		ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts), pool)
+		ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns), pool)
+		ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns), pool)
+		ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns), pool)
+	}
+}
+
+func init() {
+	prometheus.MustRegister(newRedisCollector())
+}
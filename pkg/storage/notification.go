@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cuizhaoyue/toolkit/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultNotificationChannel 是发布资源变更通知的默认channel.
+const DefaultNotificationChannel = "iam.cluster.notifications"
+
+// NotificationCommand 定义了通知的类型.
+type NotificationCommand string
+
+// 资源变更通知的类型.
+const (
+	NoticePolicyChanged NotificationCommand = "PolicyChanged"
+	NoticeSecretChanged NotificationCommand = "SecretChanged"
+)
+
+// 资源的变更类型.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Event 是可以通过pub/sub发布的事件.
+type Event interface {
+	Command() NotificationCommand
+}
+
+// PolicyChanged 在policy被创建、更新或删除时发布.
+type PolicyChanged struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// Command 返回事件对应的通知类型.
+func (e *PolicyChanged) Command() NotificationCommand {
+	return NoticePolicyChanged
+}
+
+// SecretChanged 在secret被创建、更新或删除时发布.
+type SecretChanged struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	SecretID string `json:"secretID"`
+}
+
+// Command 返回事件对应的通知类型.
+func (e *SecretChanged) Command() NotificationCommand {
+	return NoticeSecretChanged
+}
+
+// Notification 是在channel中传输的消息，Payload是json格式的事件. Checksum只用于发现损坏的消息，
+// 没有使用密钥，不能证明消息的来源.
+type Notification struct {
+	Command      NotificationCommand `json:"command"`
+	Payload      string              `json:"payload"`
+	Checksum     string              `json:"checksum"`
+	ChecksumAlgo crypto.Hash         `json:"algorithm"`
+}
+
+// NewNotification 把事件编码为带有校验和的通知.
+func NewNotification(event Event) (*Notification, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notification{Command: event.Command(), Payload: string(payload)}
+	n.SetChecksum()
+
+	return n, nil
+}
+
+// SetChecksum 使用SHA256计算通知的校验和.
+func (n *Notification) SetChecksum() {
+	n.ChecksumAlgo = crypto.SHA256
+	n.Checksum = n.digest()
+}
+
+// Event 检查校验和并解码通知中的事件.
+func (n *Notification) Event() (Event, error) {
+	if n.Checksum != n.digest() {
+		return nil, fmt.Errorf("checksum of notification %s does not match", n.Command)
+	}
+
+	var event Event
+
+	switch n.Command {
+	case NoticePolicyChanged:
+		event = &PolicyChanged{}
+	case NoticeSecretChanged:
+		event = &SecretChanged{}
+	default:
+		return nil, fmt.Errorf("unknown notification command `%s`", n.Command)
+	}
+
+	if err := json.Unmarshal([]byte(n.Payload), event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (n *Notification) digest() string {
+	hash := sha256.Sum256([]byte(string(n.Command) + n.Payload))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// PublishEvent 把事件编码为通知后发布到channel.
+func (r *RedisCluster) PublishEvent(ctx context.Context, channel string, event Event) error {
+	n, err := NewNotification(event)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	return r.Publish(ctx, channel, string(data))
+}
+
+// EventHandler 返回StartPubSubHandler使用的回调，把收到的消息解码为事件后交给handle处理，无法解码的消息会被忽略.
+func EventHandler(handle func(Event)) func(*redis.Message) {
+	return func(msg *redis.Message) {
+		var n Notification
+		if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+			log.Warnf("Malformed notification: %s", err.Error())
+
+			return
+		}
+
+		event, err := n.Event()
+		if err != nil {
+			log.Warnf("Drop notification: %s", err.Error())
+
+			return
+		}
+
+		handle(event)
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotification_Event(t *testing.T) {
+	n, err := NewNotification(&SecretChanged{Type: ChangeDeleted, Username: "admin", Name: "s1", SecretID: "id1"})
+	assert.Nil(t, err)
+	assert.Equal(t, NoticeSecretChanged, n.Command)
+
+	event, err := n.Event()
+	assert.Nil(t, err)
+	assert.Equal(t, &SecretChanged{Type: ChangeDeleted, Username: "admin", Name: "s1", SecretID: "id1"}, event)
+
+	// 内容被修改后校验和不匹配
+	n.Payload = `{"type":"deleted","username":"other","name":"s1"}`
+	_, err = n.Event()
+	assert.NotNil(t, err)
+
+	n = &Notification{Command: "Unknown", Payload: "{}"}
+	n.SetChecksum()
+	_, err = n.Event()
+	assert.NotNil(t, err)
+}
+
+func TestEventHandler(t *testing.T) {
+	var got []Event
+	handle := EventHandler(func(e Event) { got = append(got, e) })
+
+	n, _ := NewNotification(&PolicyChanged{Type: ChangeCreated, Username: "admin", Name: "p1"})
+	data, _ := json.Marshal(n)
+
+	handle(&redis.Message{Payload: string(data)})
+	handle(&redis.Message{Payload: "not json"})
+
+	assert.Equal(t, []Event{&PolicyChanged{Type: ChangeCreated, Username: "admin", Name: "p1"}}, got)
+}
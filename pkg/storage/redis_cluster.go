@@ -83,6 +83,8 @@ func NewRedisClusterPool(opts *Options) redis.UniversalClient {
 		client = redis.NewClient(redisOpts.simpleOpts())
 	}
 
+	client.AddHook(tracingHook{})
+
 	return client
 }
 
@@ -283,7 +285,7 @@ type RedisCluster struct {
 // 添加一个测试key检测连接是否正常
 func checkClusterConnectionIsOpen(cluster RedisCluster) bool {
 	client := singleton(cluster.IsCache)
-	testKey := "redis-test-" + uuid.NewV4().String()
+	testKey := "redis-test-" + uuid.Must(uuid.NewV4()).String()
 
 	if err := client.Set(context.Background(), testKey, "test", time.Second).Err(); err != nil {
 		log.Warnf("Error trying to set test key: %s", err.Error())
@@ -351,3 +353,269 @@ again:
 		}
 	}
 }
+
+// Connected 返回redis是否可用.
+func Connected() bool {
+	if v := redisUp.Load(); v != nil {
+		return v.(bool)
+	}
+
+	return false
+}
+
+func (r *RedisCluster) singleton() redis.UniversalClient {
+	return singleton(r.IsCache)
+}
+
+func (r *RedisCluster) hashKey(in string) string {
+	if !r.HashKey {
+		// 不做hash时直接返回原始key
+		return in
+	}
+
+	return HashStr(in)
+}
+
+func (r *RedisCluster) fixKey(keyName string) string {
+	return r.KeyPrefix + r.hashKey(keyName)
+}
+
+func (r *RedisCluster) up() error {
+	if !Connected() {
+		return ErrRedisDown
+	}
+
+	return nil
+}
+
+// GetKey 获取key的值.
+func (r *RedisCluster) GetKey(ctx context.Context, keyName string) (string, error) {
+	if err := r.up(); err != nil {
+		return "", err
+	}
+
+	value, err := r.singleton().Get(ctx, r.fixKey(keyName)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrKeyNotFound
+		}
+
+		log.Debugf("Error trying to get value: %s", err.Error())
+
+		return "", err
+	}
+
+	return value, nil
+}
+
+// SetKey 创建或者更新key，timeout为0时永不过期.
+func (r *RedisCluster) SetKey(ctx context.Context, keyName, value string, timeout time.Duration) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	if err := r.singleton().Set(ctx, r.fixKey(keyName), value, timeout).Err(); err != nil {
+		log.Errorf("Error trying to set value: %s", err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// SetKeyIfExists 只在key存在时更新key的值，key不存在时返回false.
+func (r *RedisCluster) SetKeyIfExists(ctx context.Context, keyName, value string, timeout time.Duration) (bool, error) {
+	if err := r.up(); err != nil {
+		return false, err
+	}
+
+	ok, err := r.singleton().SetXX(ctx, r.fixKey(keyName), value, timeout).Result()
+	if err != nil {
+		log.Errorf("Error trying to set value: %s", err.Error())
+
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// incrementWithExpireScript 对key加1，key是新创建的时候设置过期时间.
+var incrementWithExpireScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// IncrementWithExpire 对key的值加1并返回结果，key不存在时从0开始并设置过期时间.
+func (r *RedisCluster) IncrementWithExpire(ctx context.Context, keyName string, expire time.Duration) (int64, error) {
+	if err := r.up(); err != nil {
+		return 0, err
+	}
+
+	n, err := incrementWithExpireScript.Run(ctx, r.singleton(), []string{r.fixKey(keyName)}, expire.Milliseconds()).Int64()
+	if err != nil {
+		log.Errorf("Error trying to increment value: %s", err.Error())
+
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// SetExp 设置key的过期时间.
+func (r *RedisCluster) SetExp(ctx context.Context, keyName string, timeout time.Duration) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	err := r.singleton().Expire(ctx, r.fixKey(keyName), timeout).Err()
+	if err != nil {
+		log.Errorf("Could not EXPIRE key: %s", err.Error())
+	}
+
+	return err
+}
+
+// Exists 判断key是否存在.
+func (r *RedisCluster) Exists(ctx context.Context, keyName string) (bool, error) {
+	if err := r.up(); err != nil {
+		return false, err
+	}
+
+	n, err := r.singleton().Exists(ctx, r.fixKey(keyName)).Result()
+	if err != nil {
+		log.Errorf("Error trying to check if key exists: %s", err.Error())
+
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// DeleteKey 删除key，key存在并被删除时返回true.
+func (r *RedisCluster) DeleteKey(ctx context.Context, keyName string) bool {
+	if err := r.up(); err != nil {
+		return false
+	}
+
+	n, err := r.singleton().Del(ctx, r.fixKey(keyName)).Result()
+	if err != nil {
+		log.Errorf("Error trying to delete key: %s", err.Error())
+	}
+
+	return n > 0
+}
+
+// DeleteKeys 按顺序批量删除key，遇到错误时停止并返回错误，不存在的key会被忽略.
+func (r *RedisCluster) DeleteKeys(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	// 集群模式下多个key可能不在同一个slot，逐个删除
+	for _, key := range keys {
+		if err := r.singleton().Del(ctx, r.fixKey(key)).Err(); err != nil {
+			log.Errorf("Error trying to delete key: %s", err.Error())
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetSet 返回集合中的所有成员.
+func (r *RedisCluster) GetSet(ctx context.Context, keyName string) ([]string, error) {
+	if err := r.up(); err != nil {
+		return nil, err
+	}
+
+	val, err := r.singleton().SMembers(ctx, r.fixKey(keyName)).Result()
+	if err != nil {
+		log.Errorf("Error trying to get key set: %s", err.Error())
+
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// AddToSet 向集合中添加成员.
+func (r *RedisCluster) AddToSet(ctx context.Context, keyName, value string) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	err := r.singleton().SAdd(ctx, r.fixKey(keyName), value).Err()
+	if err != nil {
+		log.Errorf("Error trying to append keys: %s", err.Error())
+	}
+
+	return err
+}
+
+// RemoveFromSet 从集合中删除成员.
+func (r *RedisCluster) RemoveFromSet(ctx context.Context, keyName, value string) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	err := r.singleton().SRem(ctx, r.fixKey(keyName), value).Err()
+	if err != nil {
+		log.Errorf("Error trying to remove keys: %s", err.Error())
+	}
+
+	return err
+}
+
+// Publish 发布消息到指定的channel.
+func (r *RedisCluster) Publish(ctx context.Context, channel, message string) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	if err := r.singleton().Publish(ctx, channel, message).Err(); err != nil {
+		log.Errorf("Error trying to publish message: %s", err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// StartPubSubHandler 订阅channel并对收到的每条消息调用callback，直到ctx被取消或者连接断开.
+func (r *RedisCluster) StartPubSubHandler(ctx context.Context, channel string, callback func(*redis.Message)) error {
+	if err := r.up(); err != nil {
+		return err
+	}
+
+	pubsub := r.singleton().Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// 等待订阅确认
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Errorf("Error while receiving pubsub message: %s", err.Error())
+
+		return err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return errors.New("pubsub channel closed")
+			}
+
+			callback(msg)
+		}
+	}
+}
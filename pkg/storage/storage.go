@@ -1 +1,19 @@
+// Package storage 定义了基于redis的存储.
 package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/marmotedu/errors"
+)
+
+// ErrKeyNotFound 在key不存在时返回.
+var ErrKeyNotFound = errors.New("key not found")
+
+// HashStr 返回字符串的sha256哈希值.
+func HashStr(in string) string {
+	sum := sha256.Sum256([]byte(in))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+// Package tracing 初始化OpenTelemetry链路追踪，支持通过OTLP导出，也可以写到标准输出或者文件中离线查看.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 支持的导出方式.
+const (
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterStdout   = "stdout"
+	ExporterFile     = "file"
+)
+
+// Exporters 返回支持的导出方式.
+func Exporters() []string {
+	return []string{ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterStdout, ExporterFile}
+}
+
+// Options 是链路追踪的配置.
+type Options struct {
+	// ServiceName 上报的服务名.
+	ServiceName string
+	// Exporter 导出方式.
+	Exporter string
+	// Endpoint OTLP接收端的地址，例如: 127.0.0.1:4317，为空时使用OTLP默认地址.
+	Endpoint string
+	// Insecure OTLP不使用tls.
+	Insecure bool
+	// Headers OTLP请求附带的头，一般用于认证.
+	Headers map[string]string
+	// File 导出方式为file时写入的文件.
+	File string
+	// SampleRatio 没有上游采样决定时的采样比例，取值范围[0, 1].
+	SampleRatio float64
+}
+
+// Init 创建TracerProvider并设置为全局的TracerProvider，同时使用W3C Trace Context和Baggage在服务间传递上下文.
+// 返回的函数用于在服务退出时导出剩余的span并释放资源.
+func Init(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporter, closer, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case ExporterOTLPGRPC:
+		var options []otlptracegrpc.Option
+		if opts.Endpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+
+		if opts.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+
+		if len(opts.Headers) > 0 {
+			options = append(options, otlptracegrpc.WithHeaders(opts.Headers))
+		}
+
+		exporter, err := otlptrace.New(ctx, otlptracegrpc.NewClient(options...))
+
+		return exporter, nil, err
+	case ExporterOTLPHTTP:
+		var options []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+
+		if opts.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		if len(opts.Headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(opts.Headers))
+		}
+
+		exporter, err := otlptrace.New(ctx, otlptracehttp.NewClient(options...))
+
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+		return exporter, nil, err
+	case ExporterFile:
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open trace file: %w", err)
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+
+			return nil, nil, err
+		}
+
+		return exporter, f, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported trace exporter %q", opts.Exporter)
+	}
+}
+
+// Tracer 返回指定名称的Tracer，在Init之前获取的Tracer也会使用Init设置的TracerProvider.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// RecordError 在span中记录错误并把状态设置为失败，err为nil时不做处理.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
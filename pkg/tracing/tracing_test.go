@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestInitFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.json")
+
+	shutdown, err := Init(context.Background(), Options{
+		ServiceName: "iam-apiserver",
+		Exporter:    ExporterFile,
+		File:        file,
+		SampleRatio: 1,
+	})
+	assert.Nil(t, err)
+
+	ctx, span := Tracer("test").Start(context.Background(), "parent")
+	assert.True(t, span.SpanContext().IsSampled())
+
+	// 下游服务通过traceparent头获得相同的trace id
+	carrier := propagation.HeaderCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	assert.Contains(t, carrier.Get("traceparent"), span.SpanContext().TraceID().String())
+
+	span.End()
+	assert.Nil(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"Name":"parent"`)
+	assert.Contains(t, string(data), "iam-apiserver")
+}
+
+func TestInitInvalid(t *testing.T) {
+	_, err := Init(context.Background(), Options{Exporter: "zipkin"})
+	assert.NotNil(t, err)
+
+	_, err = Init(context.Background(), Options{Exporter: ExporterFile, File: filepath.Join(t.TempDir(), "none", "trace.json")})
+	assert.NotNil(t, err)
+}